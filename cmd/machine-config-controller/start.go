@@ -38,6 +38,10 @@ var (
 		templates                string
 		promMetricsListenAddress string
 		resourceLockNamespace    string
+		etcdLeaderEndpoint       string
+		etcdLeaderCAFile         string
+		etcdLeaderCertFile       string
+		etcdLeaderKeyFile        string
 	}
 )
 
@@ -46,6 +50,10 @@ func init() {
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Path to the template files used for creating MachineConfig objects")
 	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsListenAddress, "metrics-listen-address", "127.0.0.1:8797", "Listen address for prometheus metrics listener")
+	startCmd.PersistentFlags().StringVar(&startOpts.etcdLeaderEndpoint, "etcd-leader-endpoint", "", "etcd endpoint queried for the current leader so its node is updated last in the master pool; empty disables leader deferral")
+	startCmd.PersistentFlags().StringVar(&startOpts.etcdLeaderCAFile, "etcd-leader-cafile", "", "CA bundle used to verify the etcd leader endpoint")
+	startCmd.PersistentFlags().StringVar(&startOpts.etcdLeaderCertFile, "etcd-leader-certfile", "", "Client certificate used to authenticate against the etcd leader endpoint")
+	startCmd.PersistentFlags().StringVar(&startOpts.etcdLeaderKeyFile, "etcd-leader-keyfile", "", "Client key used to authenticate against the etcd leader endpoint")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -76,6 +84,8 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		draincontroller := drain.New(
 			drain.DefaultConfig(),
 			ctrlctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctrlctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctrlctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctrlctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
 			ctrlctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
 		)
@@ -180,21 +190,32 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+			ctx.KubeInformerFactory.Core().V1().Nodes(),
 			ctx.ClientBuilder.KubeClientOrDie("render-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("render-controller"),
 		),
-		// The node controller consumes data written by the above
-		node.New(
-			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
-			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
-			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
-			ctx.KubeInformerFactory.Core().V1().Nodes(),
-			ctx.ConfigInformerFactory.Config().V1().Schedulers(),
-			ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
-			ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
-		),
 	)
 
+	// The node controller consumes data written by the above
+	nodeController := node.New(
+		ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+		ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+		ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+		ctx.KubeInformerFactory.Core().V1().Nodes(),
+		ctx.ConfigInformerFactory.Config().V1().Schedulers(),
+		ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
+		ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
+	)
+	if startOpts.etcdLeaderEndpoint != "" {
+		source, err := node.NewEtcdMemberStatusLeaderSource(startOpts.etcdLeaderEndpoint, startOpts.etcdLeaderCAFile, startOpts.etcdLeaderCertFile, startOpts.etcdLeaderKeyFile)
+		if err != nil {
+			ctrlcommon.WriteTerminationError(fmt.Errorf("creating etcd leader source: %w", err))
+		}
+		nodeController.SetEtcdLeaderSource(source)
+	}
+	nodeController.SetMachineDeleter(node.NewMachineAPIDeleter(ctx.ClientBuilder.DynamicClientOrDie("node-update-controller")))
+	controllers = append(controllers, nodeController)
+
 	return controllers
 }
 
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	mcoResourceRead "github.com/openshift/machine-config-operator/lib/resourceread"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+	previewCmd = &cobra.Command{
+		Use:   "preview",
+		Short: "Preview what a MachineConfig change will do to the nodes of a pool",
+		Long: `Renders the pool's MachineConfigs with the given MachineConfig added or replaced and
+prints, as JSON, what the MachineConfigDaemon would do to update the pool's nodes from
+their current rendered config: errors, changed files, units, kernel arguments and
+extensions, whether nodes are drained and the post config change actions.
+Nothing is changed in the cluster.`,
+		Args: cobra.MaximumNArgs(0),
+		Run:  runPreviewCmd,
+	}
+
+	previewOpts struct {
+		kubeconfig    string
+		pool          string
+		machineConfig string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+	previewCmd.PersistentFlags().StringVar(&previewOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access the cluster")
+	previewCmd.PersistentFlags().StringVar(&previewOpts.pool, "pool", "worker", "MachineConfigPool to preview the change for")
+	previewCmd.PersistentFlags().StringVar(&previewOpts.machineConfig, "machineconfig", "", "File containing the candidate MachineConfig")
+}
+
+func runPreviewCmd(_ *cobra.Command, _ []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if previewOpts.machineConfig == "" {
+		glog.Fatal("--machineconfig is required")
+	}
+	content, err := os.ReadFile(previewOpts.machineConfig)
+	if err != nil {
+		glog.Fatalf("Reading MachineConfig: %v", err)
+	}
+	candidate, err := mcoResourceRead.ReadMachineConfigV1(content)
+	if err != nil {
+		glog.Fatalf("Parsing MachineConfig: %v", err)
+	}
+
+	cb, err := clients.NewBuilder(previewOpts.kubeconfig)
+	if err != nil {
+		glog.Fatalf("Failed to initialize ClientBuilder: %v", err)
+	}
+	preview, err := previewMachineConfig(cb.MachineConfigClientOrDie(componentName), previewOpts.pool, candidate)
+	if err != nil {
+		glog.Fatalf("Previewing MachineConfig %s: %v", candidate.Name, err)
+	}
+
+	out, err := json.MarshalIndent(preview, "", "  ")
+	if err != nil {
+		glog.Fatalf("Encoding preview: %v", err)
+	}
+	fmt.Println(string(out))
+}
+
+// previewMachineConfig renders poolName's MachineConfigs with candidate added or replacing the
+// MachineConfig of the same name, and previews the update from the pool's current rendered config.
+func previewMachineConfig(client mcfgclientset.Interface, poolName string, candidate *mcfgv1.MachineConfig) (*daemon.UpdatePreview, error) {
+	ctx := context.TODO()
+	pool, err := client.MachineconfigurationV1().MachineConfigPools().Get(ctx, poolName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	cconfig, err := client.MachineconfigurationV1().ControllerConfigs().Get(ctx, ctrlcommon.ControllerConfigName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	current, err := client.MachineconfigurationV1().MachineConfigs().Get(ctx, pool.Spec.Configuration.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting current rendered config of pool %s: %w", poolName, err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+	if !selector.Matches(labels.Set(candidate.Labels)) {
+		return nil, fmt.Errorf("MachineConfig %s is not selected by pool %s", candidate.Name, poolName)
+	}
+	mcList, err := client.MachineconfigurationV1().MachineConfigs().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	configs := []*mcfgv1.MachineConfig{candidate}
+	for i := range mcList.Items {
+		if mcList.Items[i].Name != candidate.Name {
+			configs = append(configs, &mcList.Items[i])
+		}
+	}
+
+	preview := &daemon.UpdatePreview{OldConfig: current.Name}
+	for _, config := range configs {
+		if err := ctrlcommon.ValidateMachineConfig(config.Spec); err != nil {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("invalid MachineConfig %s: %v", config.Name, err))
+		}
+	}
+	if len(preview.Errors) > 0 {
+		return preview, nil
+	}
+	merged, err := ctrlcommon.MergeMachineConfigs(configs, cconfig)
+	if err != nil {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("merging MachineConfigs: %v", err))
+		return preview, nil
+	}
+	return daemon.PreviewUpdate(current, merged, pool), nil
+}
@@ -20,6 +20,7 @@ var (
 	bootstrapOpts struct {
 		serverBaseDir    string
 		serverKubeConfig string
+		allowedPools     []string
 	}
 )
 
@@ -27,6 +28,7 @@ func init() {
 	rootCmd.AddCommand(bootstrapCmd)
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapOpts.serverBaseDir, "server-basedir", "/etc/mcs/bootstrap", "base directory on the host, relative to which machine-configs and pools can be found.")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapOpts.serverKubeConfig, "bootstrap-kubeconfig", "/etc/kubernetes/kubeconfig", "path to bootstrap kubeconfig served by the bootstrap server.")
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapOpts.allowedPools, "allowed-pools", []string{"master"}, "pools served by the bootstrap server, \""+server.BootstrapAllPools+"\" to serve every pool rendered into machine-pools/.")
 }
 
 func runBootstrapCmd(cmd *cobra.Command, args []string) {
@@ -36,13 +38,14 @@ func runBootstrapCmd(cmd *cobra.Command, args []string) {
 	// To help debugging, immediately log version
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
-	bs, err := server.NewBootstrapServer(bootstrapOpts.serverBaseDir, bootstrapOpts.serverKubeConfig)
+	bs, err := server.NewBootstrapServer(bootstrapOpts.serverBaseDir, bootstrapOpts.serverKubeConfig, bootstrapOpts.allowedPools)
 
 	if err != nil {
 		glog.Exitf("Machine Config Server exited with error: %v", err)
 	}
 
 	apiHandler := server.NewServerAPIHandler(bs)
+	configureAPIHandler(apiHandler)
 	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key)
 	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "")
 
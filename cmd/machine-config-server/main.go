@@ -22,10 +22,13 @@ var (
 	}
 
 	rootOpts struct {
-		sport  int
-		isport int
-		cert   string
-		key    string
+		sport         int
+		isport        int
+		cert          string
+		key           string
+		jsonAccessLog bool
+		rateLimit     float64
+		rateBurst     int
 	}
 )
 
@@ -35,9 +38,22 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&rootOpts.cert, "cert", "/etc/ssl/mcs/tls.crt", "cert file for TLS")
 	rootCmd.PersistentFlags().StringVar(&rootOpts.key, "key", "/etc/ssl/mcs/tls.key", "key file for TLS")
 	rootCmd.PersistentFlags().IntVar(&rootOpts.isport, "insecure-port", server.InsecurePort, "insecure port to serve ignition configs")
+	rootCmd.PersistentFlags().BoolVar(&rootOpts.jsonAccessLog, "json-access-log", false, "write a JSON access log line per config request to stdout")
+	rootCmd.PersistentFlags().Float64Var(&rootOpts.rateLimit, "rate-limit", 0, "config requests allowed per second and source IP, 0 disables rate limiting")
+	rootCmd.PersistentFlags().IntVar(&rootOpts.rateBurst, "rate-limit-burst", 10, "config requests a source IP may burst over the rate limit")
 	rootCmd.PersistentFlags().StringVar(&version.ReleaseVersion, "payload-version", version.ReleaseVersion, "Version of the openshift release")
 }
 
+// configureAPIHandler applies the access log and rate limit options to h.
+func configureAPIHandler(h *server.APIHandler) {
+	if rootOpts.jsonAccessLog {
+		h.SetAccessLog(os.Stdout)
+	}
+	if rootOpts.rateLimit > 0 {
+		h.SetRateLimit(rootOpts.rateLimit, rootOpts.rateBurst)
+	}
+}
+
 func main() {
 	code := cli.Run(rootCmd)
 	os.Exit(code)
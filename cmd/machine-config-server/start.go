@@ -19,8 +19,10 @@ var (
 	}
 
 	startOpts struct {
-		kubeconfig   string
-		apiserverURL string
+		kubeconfig           string
+		apiserverURL         string
+		requireIgnitionToken bool
+		promMetricsURL       string
 	}
 )
 
@@ -28,6 +30,8 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.apiserverURL, "apiserver-url", "", "URL for apiserver; Used to generate kubeconfig")
+	startCmd.PersistentFlags().BoolVar(&startOpts.requireIgnitionToken, "require-ignition-token", false, "Only serve configs to requests with a valid single-use Ignition token")
+	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsURL, "metrics-url", server.MetricsBindAddress, "URL for prometheus metrics listener")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -47,10 +51,19 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	}
 
 	apiHandler := server.NewServerAPIHandler(cs)
+	if startOpts.requireIgnitionToken {
+		auth, err := server.NewSecretTokenAuthenticator(startOpts.kubeconfig)
+		if err != nil {
+			ctrlcommon.WriteTerminationError(err)
+		}
+		apiHandler = server.NewAuthenticatedServerAPIHandler(cs, auth)
+	}
+	configureAPIHandler(apiHandler)
 	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key)
 	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "")
 
 	stopCh := make(chan struct{})
+	go ctrlcommon.StartMetricsListener(startOpts.promMetricsURL, stopCh, server.RegisterMCSMetrics)
 	go secureServer.Serve()
 	go insecureServer.Serve()
 	<-stopCh
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenCmd = &cobra.Command{
+		Use:   "token",
+		Short: "Create a single-use Ignition token and print the pointer config of a pool using it",
+		Long:  "",
+		Run:   runTokenCmd,
+	}
+
+	tokenOpts struct {
+		kubeconfig string
+		pool       string
+		ttl        time.Duration
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.PersistentFlags().StringVar(&tokenOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access the cluster, $KUBECONFIG or the in-cluster config if empty")
+	tokenCmd.PersistentFlags().StringVar(&tokenOpts.pool, "pool", "worker", "pool the token fetches the config of")
+	tokenCmd.PersistentFlags().DurationVar(&tokenOpts.ttl, "ttl", time.Hour, "time after which the token expires")
+}
+
+func runTokenCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	clientsBuilder, err := clients.NewBuilder(tokenOpts.kubeconfig)
+	if err != nil {
+		glog.Exitf("Failed to create Kubernetes rest client: %v", err)
+	}
+	kubeClient := clientsBuilder.KubeClientOrDie("machine-config-server-token")
+
+	pointerConfig, tokenID, err := server.NewIgnitionTokenPointerConfig(context.TODO(), kubeClient, tokenOpts.pool, tokenOpts.ttl)
+	if err != nil {
+		glog.Exitf("Failed to create Ignition token: %v", err)
+	}
+	glog.Infof("Created Ignition token %s for pool %s, expiring in %v", tokenID, tokenOpts.pool, tokenOpts.ttl)
+	fmt.Println(string(pointerConfig))
+}
@@ -8,6 +8,7 @@ import (
 	operatorclientset "github.com/openshift/client-go/operator/clientset/versioned"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	apiext "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -56,6 +57,11 @@ func (cb *Builder) APIExtClientOrDie(name string) apiext.Interface {
 	return apiext.NewForConfigOrDie(rest.AddUserAgent(cb.config, name))
 }
 
+// DynamicClientOrDie returns the dynamic client interface for resources without a typed client.
+func (cb *Builder) DynamicClientOrDie(name string) dynamic.Interface {
+	return dynamic.NewForConfigOrDie(rest.AddUserAgent(cb.config, name))
+}
+
 // GetBuilderConfig returns a copy of the builders *rest.Config
 func (cb *Builder) GetBuilderConfig() *rest.Config {
 	return rest.CopyConfig(cb.config)
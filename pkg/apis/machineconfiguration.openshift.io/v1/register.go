@@ -34,6 +34,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MachineConfigList{},
 		&MachineConfigPool{},
 		&MachineConfigPoolList{},
+		&NodeConfigOverlay{},
+		&NodeConfigOverlayList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
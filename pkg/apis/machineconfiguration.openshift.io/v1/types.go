@@ -229,6 +229,49 @@ type MachineConfigList struct {
 	Items []MachineConfig `json:"items"`
 }
 
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeConfigOverlay is an Ignition config fragment the Machine Config Server merges on top of a
+// pool's rendered config when serving it to the hosts the overlay selects. Overlays live in the
+// openshift-machine-config-operator namespace and select hosts by name: an overlay named after a
+// host, or labelled with NodeConfigOverlayNodeLabelKey set to the host's name, applies to it, as
+// does one labelled with NodeConfigOverlayMACAddressLabelKey set to one of the host's MAC addresses.
+type NodeConfigOverlay struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeConfigOverlaySpec `json:"spec"`
+}
+
+// NodeConfigOverlaySpec is the spec for NodeConfigOverlay
+type NodeConfigOverlaySpec struct {
+	// config is the Ignition config fragment merged on top of the rendered config, e.g. the
+	// host's hostname and NetworkManager connection files. It may not replace files or units
+	// of the rendered config.
+	Config runtime.RawExtension `json:"config"`
+}
+
+const (
+	// NodeConfigOverlayNodeLabelKey selects the host a NodeConfigOverlay applies to by name.
+	NodeConfigOverlayNodeLabelKey = "machineconfiguration.openshift.io/node"
+
+	// NodeConfigOverlayMACAddressLabelKey selects the host a NodeConfigOverlay applies to by MAC
+	// address, written in lower case with dashes, e.g. 52-54-00-a1-b2-c3.
+	NodeConfigOverlayMACAddressLabelKey = "machineconfiguration.openshift.io/mac-address"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeConfigOverlayList is a list of NodeConfigOverlay resources
+type NodeConfigOverlayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeConfigOverlay `json:"items"`
+}
+
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -272,8 +315,368 @@ type MachineConfigPoolSpec struct {
 
 	// The targeted MachineConfig object for the machine config pool.
 	Configuration MachineConfigPoolStatusConfiguration `json:"configuration"`
+
+	// postConfigChangeActions maps files changed by an update to the action the
+	// MachineConfigDaemon takes once the change has been written, instead of rebooting.
+	// Changed paths are matched in order against each rule's path glob and the first
+	// matching rule wins. Changes to paths matched by no rule reboot the node.
+	// When several rules match the changed files, all their actions are taken in
+	// the order of the rules; a single Reboot overrides every other action.
+	// +optional
+	PostConfigChangeActions []PostConfigChangeActionRule `json:"postConfigChangeActions,omitempty"`
+
+	// unitsRequiringReboot lists systemd units whose changes always reboot the node, in
+	// addition to the services the MachineConfigDaemon never restarts in place, such as
+	// kubelet.service and crio.service. Entries are unit names or globs, e.g. "db-*.service";
+	// the pattern syntax is that of Go's path.Match.
+	// +optional
+	UnitsRequiringReboot []string `json:"unitsRequiringReboot,omitempty"`
+
+	// kernelArgumentsRebootPolicy controls whether kernel argument changes reboot the node
+	// right away. With Deferred, changes limited to kernel arguments the MachineConfigDaemon
+	// knows to be safe to defer are staged for the next boot without a reboot, and the node
+	// is annotated as pending a reboot. Defaults to Immediate.
+	// +optional
+	KernelArgumentsRebootPolicy KernelArgumentsRebootPolicy `json:"kernelArgumentsRebootPolicy,omitempty"`
+
+	// maintenanceWindows restricts when nodes in the pool start updating to a new configuration.
+	// When set, the node controller only starts updating nodes while one of the windows is open;
+	// updates already in progress are allowed to finish after the window closes.
+	// When empty, nodes are updated at any time.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// rolloutStrategy stages updates of the pool's nodes. When set, a canary set of nodes
+	// is updated first and the rollout only continues to the rest of the pool once the
+	// canaries have been updated and Ready for the soak duration. maxUnavailable still
+	// limits how many nodes update at once within each phase.
+	// When unset, nodes are updated as allowed by maxUnavailable.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// rollbackPolicy enables automatic rollback of the pool's nodes to the last configuration
+	// that was fully rolled out when the targeted configuration makes nodes go Degraded.
+	// While rolled back, nodes are targeted at the previous configuration until the pool's
+	// configuration changes again. When unset, the pool is never rolled back.
+	// +optional
+	RollbackPolicy *RollbackPolicy `json:"rollbackPolicy,omitempty"`
+
+	// updateHooks are executables or systemd units the MachineConfigDaemon runs on each
+	// of the pool's nodes at phases of an update to a new configuration. Hooks of the same
+	// phase run in order; a failing hook fails the update and leaves the node Degraded.
+	// The executables and units themselves are typically shipped in a MachineConfig.
+	// +optional
+	UpdateHooks []UpdateHook `json:"updateHooks,omitempty"`
+
+	// drainPolicy configures how the pool's nodes are drained before they are updated.
+	// When unset, pods are evicted with their own termination grace period, respecting
+	// PodDisruptionBudgets, and failing drains are retried until they succeed.
+	// +optional
+	DrainPolicy *DrainPolicy `json:"drainPolicy,omitempty"`
+
+	// reprovisionPolicy allows configurations that change the pool's disks, filesystems or
+	// RAID arrays. Such changes can't be applied to a running node, so instead of being
+	// reported as Unreconcilable the node is cordoned, drained and flagged for reprovisioning
+	// with the machineconfiguration.openshift.io/reprovision annotation, and is then replaced
+	// by a new machine that boots with the new configuration.
+	// When unset, such changes leave the node Unreconcilable.
+	// +optional
+	ReprovisionPolicy *ReprovisionPolicy `json:"reprovisionPolicy,omitempty"`
+
+	// configDriftPolicy configures what the MachineConfigDaemon does when the files, directories,
+	// links or systemd units on the pool's nodes drift from their current configuration.
+	// When unset, drifted nodes go Degraded.
+	// +optional
+	ConfigDriftPolicy *ConfigDriftPolicy `json:"configDriftPolicy,omitempty"`
+
+	// healthGates are checks that must pass on each of the pool's nodes once it rebooted into
+	// a new configuration, before the node is marked Done and uncordoned. A node whose gates
+	// don't pass in time goes Degraded and halts the rollout of the configuration to the rest
+	// of the pool. When unset, nodes are marked Done as soon as their on-disk state is validated.
+	// +optional
+	HealthGates *HealthGates `json:"healthGates,omitempty"`
+}
+
+// ConfigDriftPolicy configures the handling of config drift on a pool's nodes.
+type ConfigDriftPolicy struct {
+	// action is what happens once config drift is detected on a node. With Report the drift
+	// is only reported in an event. With Degrade the node goes Degraded until the drift is
+	// reverted. With Remediate the MachineConfigDaemon restores the drifted files, directories,
+	// links and units from the current configuration and runs the post config change actions
+	// they need; drift that can't be remediated without a reboot leaves the node Degraded.
+	// Defaults to Degrade.
+	// +optional
+	Action ConfigDriftAction `json:"action,omitempty"`
+}
+
+// ConfigDriftAction is what happens once config drift is detected on a node.
+type ConfigDriftAction string
+
+const (
+	// ConfigDriftActionReport only reports the drift.
+	ConfigDriftActionReport ConfigDriftAction = "Report"
+
+	// ConfigDriftActionDegrade marks the node Degraded.
+	ConfigDriftActionDegrade ConfigDriftAction = "Degrade"
+
+	// ConfigDriftActionRemediate restores the drifted content.
+	ConfigDriftActionRemediate ConfigDriftAction = "Remediate"
+)
+
+// ReprovisionPolicy configures how nodes flagged for reprovisioning are replaced.
+type ReprovisionPolicy struct {
+	// action is how a node flagged for reprovisioning is replaced. With DeleteMachine the
+	// MachineConfigController deletes the node's Machine API Machine, named by the node's
+	// machine.openshift.io/machine annotation, so that its MachineSet creates a new one.
+	// Control plane nodes are never deleted, since their Machines have no MachineSet and
+	// carry etcd members; they are reported with a warning event and must be replaced manually.
+	// With Hook the MachineConfigDaemon runs the pool's Reprovision update hooks on the node,
+	// which are expected to arrange for the node to be reinstalled.
+	Action ReprovisionAction `json:"action"`
+}
+
+// ReprovisionAction is how a node flagged for reprovisioning is replaced.
+type ReprovisionAction string
+
+const (
+	// ReprovisionActionDeleteMachine deletes the node's Machine.
+	ReprovisionActionDeleteMachine ReprovisionAction = "DeleteMachine"
+
+	// ReprovisionActionHook runs the pool's Reprovision update hooks on the node.
+	ReprovisionActionHook ReprovisionAction = "Hook"
+)
+
+// DrainPolicy configures the drain of a pool's nodes.
+type DrainPolicy struct {
+	// timeout is how long a drain may take before it is reported as failed and the node
+	// goes Degraded. Defaults to 1h.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// timeoutAction is what happens once a drain has timed out. With Retry the drain keeps
+	// being retried. With Fail the drain is stopped and the node stays cordoned and Degraded
+	// until its machineconfiguration.openshift.io/drainStatus annotation is removed, which
+	// restarts the drain. Defaults to Retry.
+	// +optional
+	TimeoutAction DrainTimeoutAction `json:"timeoutAction,omitempty"`
+
+	// gracePeriodSeconds overrides the termination grace period of the drained pods.
+	// Defaults to the grace period of each pod.
+	// +optional
+	GracePeriodSeconds *int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// skipPodSelector selects pods left running on the node during the drain.
+	// +optional
+	SkipPodSelector *metav1.LabelSelector `json:"skipPodSelector,omitempty"`
+
+	// deletePodSelector selects pods deleted outright instead of evicted, ignoring their
+	// PodDisruptionBudgets.
+	// +optional
+	DeletePodSelector *metav1.LabelSelector `json:"deletePodSelector,omitempty"`
+
+	// podDisruptionBudgetOverrideAfter deletes the pods left on the node instead of evicting
+	// them, ignoring their PodDisruptionBudgets, once the drain has been running for this long.
+	// When unset, PodDisruptionBudgets are always respected.
+	// +optional
+	PodDisruptionBudgetOverrideAfter *metav1.Duration `json:"podDisruptionBudgetOverrideAfter,omitempty"`
+}
+
+// DrainTimeoutAction is what happens once a drain has timed out.
+type DrainTimeoutAction string
+
+const (
+	// DrainTimeoutActionRetry keeps retrying the drain.
+	DrainTimeoutActionRetry DrainTimeoutAction = "Retry"
+
+	// DrainTimeoutActionFail stops the drain.
+	DrainTimeoutActionFail DrainTimeoutAction = "Fail"
+)
+
+// UpdateHook is an executable or systemd unit run by the MachineConfigDaemon during an update.
+type UpdateHook struct {
+	// name identifies the hook in logs, events and the node's degraded reason.
+	Name string `json:"name"`
+
+	// phase is when the hook runs, one of PreDrain, PreReboot, PostBoot or Reprovision.
+	Phase UpdateHookPhase `json:"phase"`
+
+	// command is the absolute path of an executable on the node followed by its arguments.
+	// It runs with MCD_HOOK_PHASE and MCD_CONFIG, the name of the configuration being
+	// updated to, set in its environment. Exactly one of command and unit must be set.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// unit is a systemd unit started for the hook, typically of Type=oneshot so that
+	// starting it waits for it to finish. Exactly one of command and unit must be set.
+	// +optional
+	Unit string `json:"unit,omitempty"`
+
+	// timeout is how long the hook may run before it is considered failed. Defaults to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// UpdateHookPhase is the phase of an update at which an UpdateHook runs.
+type UpdateHookPhase string
+
+const (
+	// UpdateHookPreDrain hooks run before the node is drained.
+	UpdateHookPreDrain UpdateHookPhase = "PreDrain"
+
+	// UpdateHookPreReboot hooks run once the new configuration is written, right before the node reboots.
+	UpdateHookPreReboot UpdateHookPhase = "PreReboot"
+
+	// UpdateHookPostBoot hooks run after the node booted into the new configuration, before it is marked Done.
+	UpdateHookPostBoot UpdateHookPhase = "PostBoot"
+
+	// UpdateHookReprovision hooks run once a node flagged for reprovisioning by the pool's
+	// reprovisionPolicy has been drained, when its action is Hook.
+	UpdateHookReprovision UpdateHookPhase = "Reprovision"
+)
+
+// HealthGates are the checks a node must pass after rebooting into a new configuration.
+type HealthGates struct {
+	// timeout is how long the gates have to pass, polling them every 10s. Defaults to 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// kubelet requires the kubelet's healthz endpoint to report ok.
+	// +optional
+	Kubelet bool `json:"kubelet,omitempty"`
+
+	// units are systemd units that must be active, e.g. crio.service.
+	// +optional
+	Units []string `json:"units,omitempty"`
+
+	// probes are HTTP or exec probes that must succeed.
+	// +optional
+	Probes []HealthProbe `json:"probes,omitempty"`
+}
+
+// HealthProbe is an HTTP or exec check run on a node by the MachineConfigDaemon.
+type HealthProbe struct {
+	// name identifies the probe in logs, events and the node's degraded reason.
+	Name string `json:"name"`
+
+	// httpGet is a URL that must respond with a 2xx status, e.g. http://localhost:10256/healthz.
+	// Exactly one of httpGet and command must be set.
+	// +optional
+	HTTPGet string `json:"httpGet,omitempty"`
+
+	// command is the absolute path of an executable on the node followed by its arguments,
+	// which must exit with status 0. Exactly one of httpGet and command must be set.
+	// +optional
+	Command []string `json:"command,omitempty"`
+}
+
+// RollbackPolicy configures when a pool is rolled back to its previous configuration.
+type RollbackPolicy struct {
+	// degradedMachineThreshold is the number of nodes that must be Degraded or Unreconcilable
+	// on the targeted configuration for the pool to be rolled back. Defaults to 1 unless
+	// degradedTimeout is set.
+	// +optional
+	DegradedMachineThreshold int32 `json:"degradedMachineThreshold,omitempty"`
+
+	// degradedTimeout rolls the pool back once nodes have been Degraded or Unreconcilable
+	// on the targeted configuration for this long, however many they are.
+	// +optional
+	DegradedTimeout *metav1.Duration `json:"degradedTimeout,omitempty"`
+}
+
+// RolloutStrategy configures a staged rollout of a pool's nodes to a new configuration.
+type RolloutStrategy struct {
+	// canary selects the nodes updated first.
+	Canary CanaryRollout `json:"canary"`
+
+	// steps are cumulative percentages of the pool's nodes allowed to be updated
+	// once the canaries have soaked, e.g. [25, 50]. Each step starts once all nodes
+	// allowed by the previous step are updated and Ready. The rollout continues to
+	// 100% after the last step.
+	// +optional
+	Steps []int32 `json:"steps,omitempty"`
+}
+
+// CanaryRollout selects the canary nodes of a rollout and how long they must soak.
+type CanaryRollout struct {
+	// count is the number of nodes in the canary set. It is ignored when nodeSelector is set.
+	// Defaults to 1.
+	// +optional
+	Count int32 `json:"count,omitempty"`
+
+	// nodeSelector selects the pool's canary nodes by label. The rollout is paused while it
+	// matches none of the pool's nodes.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// soakDuration is how long all canaries must be updated and Ready before the
+	// rollout continues to the rest of the pool.
+	// +optional
+	SoakDuration metav1.Duration `json:"soakDuration,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time range during which nodes may start updating.
+type MaintenanceWindow struct {
+	// days are the days of the week the window opens on, e.g. Monday. When empty the window
+	// opens every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+
+	// startTime is the time of day the window opens, in 24 hour HH:MM format.
+	StartTime string `json:"startTime"`
+
+	// endTime is the time of day the window closes, in 24 hour HH:MM format. A window whose
+	// endTime is not after its startTime closes on the following day.
+	EndTime string `json:"endTime"`
+
+	// timeZone is the IANA time zone startTime and endTime are in, e.g. Europe/Berlin.
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// KernelArgumentsRebootPolicy is how the MachineConfigDaemon reboots for kernel argument changes.
+type KernelArgumentsRebootPolicy string
+
+const (
+	// KernelArgumentsRebootImmediate reboots the node as soon as kernel arguments change.
+	KernelArgumentsRebootImmediate KernelArgumentsRebootPolicy = "Immediate"
+
+	// KernelArgumentsRebootDeferred stages deferrable kernel argument changes for the next boot.
+	KernelArgumentsRebootDeferred KernelArgumentsRebootPolicy = "Deferred"
+)
+
+// PostConfigChangeActionRule maps changed file paths to a post config change action.
+type PostConfigChangeActionRule struct {
+	// path is a glob matched against the absolute path of each changed file,
+	// e.g. /etc/chrony.d/*.conf. The pattern syntax is that of Go's path.Match.
+	Path string `json:"path"`
+
+	// type is the action to take, one of None, Reload, Restart or Reboot.
+	Type PostConfigChangeActionType `json:"type"`
+
+	// unit is the systemd unit to reload or restart; it is required for the Reload and
+	// Restart types and ignored otherwise.
+	// +optional
+	Unit string `json:"unit,omitempty"`
 }
 
+// PostConfigChangeActionType is the action taken by the MachineConfigDaemon after writing a config change.
+type PostConfigChangeActionType string
+
+const (
+	// PostConfigChangeActionNone applies the change without any further action.
+	PostConfigChangeActionNone PostConfigChangeActionType = "None"
+
+	// PostConfigChangeActionReload runs "systemctl reload" on the rule's unit.
+	PostConfigChangeActionReload PostConfigChangeActionType = "Reload"
+
+	// PostConfigChangeActionRestart runs "systemctl restart" on the rule's unit.
+	PostConfigChangeActionRestart PostConfigChangeActionType = "Restart"
+
+	// PostConfigChangeActionReboot drains and reboots the node.
+	PostConfigChangeActionReboot PostConfigChangeActionType = "Reboot"
+)
+
 // MachineConfigPoolStatus is the status for MachineConfigPool resource.
 type MachineConfigPoolStatus struct {
 	// observedGeneration represents the generation observed by the controller.
@@ -303,8 +706,73 @@ type MachineConfigPoolStatus struct {
 	// conditions represents the latest available observations of current state.
 	// +optional
 	Conditions []MachineConfigPoolCondition `json:"conditions"`
+
+	// rollout represents the progress of the pool's rollout strategy, if any.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// rolledBackFrom is the name of the MachineConfig the pool was rolled back from by its
+	// rollback policy, while the pool's nodes are targeted at the previous configuration.
+	// +optional
+	RolledBackFrom string `json:"rolledBackFrom,omitempty"`
+
+	// reprovisioningMachineCount represents the total number of machines flagged for
+	// reprovisioning by the pool's reprovisionPolicy that haven't been replaced yet.
+	// +optional
+	ReprovisioningMachineCount int32 `json:"reprovisioningMachineCount,omitempty"`
+
+	// reprovisioningNodes are the names of the nodes flagged for reprovisioning that
+	// haven't been replaced yet.
+	// +optional
+	ReprovisioningNodes []string `json:"reprovisioningNodes,omitempty"`
 }
 
+// RolloutStatus is the progress of a pool's rollout strategy towards a configuration.
+type RolloutStatus struct {
+	// configuration is the name of the MachineConfig being rolled out.
+	Configuration string `json:"configuration"`
+
+	// phase is the phase the rollout is in.
+	Phase RolloutPhase `json:"phase"`
+
+	// step is the index, starting at 1, of the step the rollout is at while Progressing.
+	// +optional
+	Step int32 `json:"step,omitempty"`
+
+	// canaryNodes are the names of the nodes in the canary set.
+	// +optional
+	CanaryNodes []string `json:"canaryNodes,omitempty"`
+
+	// soakStartTime is when all canaries were last found updated and Ready.
+	// +optional
+	SoakStartTime *metav1.Time `json:"soakStartTime,omitempty"`
+
+	// message is a human readable description of the rollout's progress.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutPhase is the phase of a pool's rollout.
+type RolloutPhase string
+
+const (
+	// RolloutPhaseCanary means the canary nodes are being updated.
+	RolloutPhaseCanary RolloutPhase = "Canary"
+
+	// RolloutPhaseSoaking means the canaries are updated and the rollout waits for the soak duration.
+	RolloutPhaseSoaking RolloutPhase = "Soaking"
+
+	// RolloutPhaseProgressing means the rest of the pool is being updated in steps.
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+
+	// RolloutPhasePaused means a canary is degraded, or no canary was selected, and no further
+	// nodes are updated.
+	RolloutPhasePaused RolloutPhase = "Paused"
+
+	// RolloutPhaseCompleted means all of the pool's nodes are updated.
+	RolloutPhaseCompleted RolloutPhase = "Completed"
+)
+
 // MachineConfigPoolStatusConfiguration stores the current configuration for the pool, and
 // optionally also stores the list of MachineConfig objects used to generate the configuration.
 type MachineConfigPoolStatusConfiguration struct {
@@ -358,6 +826,12 @@ const (
 
 	// MachineConfigPoolDegraded is the overall status of the pool based, today, on whether we fail with NodeDegraded or RenderDegraded
 	MachineConfigPoolDegraded MachineConfigPoolConditionType = "Degraded"
+
+	// MachineConfigPoolWaitingForMaintenanceWindow means nodes need updating but none of the pool's maintenance windows is open
+	MachineConfigPoolWaitingForMaintenanceWindow MachineConfigPoolConditionType = "WaitingForMaintenanceWindow"
+
+	// MachineConfigPoolRolledBack means the pool's nodes were rolled back to the previous configuration after failing to update
+	MachineConfigPoolRolledBack MachineConfigPoolConditionType = "RolledBack"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -41,4 +41,20 @@ const (
 	MachineConfigPoolMaster = "master"
 	// MachineConfigPoolWorker is the MachineConfigPool name given to the worker
 	MachineConfigPoolWorker = "worker"
+
+	// RenderedConfigRetentionRevisionsAnnotationKey is set on a MachineConfigPool to override how many of its most recent
+	// rendered MachineConfigs are always kept by the render controller's garbage collection.
+	RenderedConfigRetentionRevisionsAnnotationKey = "machineconfiguration.openshift.io/rendered-config-retention-revisions"
+
+	// RenderedConfigRetentionMaxAgeAnnotationKey is set on a MachineConfigPool to a duration (e.g. "720h"). Unreferenced rendered
+	// MachineConfigs older than this are garbage collected even if they are within the retained revisions.
+	RenderedConfigRetentionMaxAgeAnnotationKey = "machineconfiguration.openshift.io/rendered-config-retention-max-age"
+
+	// PinnedRenderedConfigsAnnotationKey is set on a MachineConfigPool to a comma separated list of rendered MachineConfig
+	// names that must never be garbage collected.
+	PinnedRenderedConfigsAnnotationKey = "machineconfiguration.openshift.io/pinned-rendered-configs"
+
+	// DefaultRenderedConfigRetentionRevisions is the number of most recent rendered MachineConfigs kept per pool
+	// when the pool does not set RenderedConfigRetentionRevisionsAnnotationKey.
+	DefaultRenderedConfigRetentionRevisions = 5
 )
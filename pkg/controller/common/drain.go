@@ -0,0 +1,71 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DrainStatus is the progress of a node's drain/uncordon request. The drain controller keeps it
+// in the node's DrainStatusAnnotationKey annotation so that drains resume where they left off
+// when the controller restarts or moves to another node.
+type DrainStatus struct {
+	// Request is the DesiredDrainerAnnotationKey value this status is for, e.g. drain-rendered-worker-1234.
+	Request string `json:"request"`
+	// StartTime is when the controller started working on the request.
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is when the request was completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Attempts is the number of failed drain attempts.
+	Attempts int32 `json:"attempts,omitempty"`
+	// LastAttemptTime is when the latest drain attempt failed.
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// LastError is the error of the latest failed drain attempt.
+	LastError string `json:"lastError,omitempty"`
+	// Failed is set once the drain timed out and the pool's drain policy stops retrying it.
+	Failed bool `json:"failed,omitempty"`
+	// BlockingPods are the pods left on the node after the latest failed drain attempt.
+	BlockingPods []BlockingPod `json:"blockingPods,omitempty"`
+}
+
+// BlockingPod is a pod left on a node after a failed drain attempt.
+type BlockingPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// DisruptionBudget is the name of the PodDisruptionBudget in the pod's namespace that
+	// allows no disruption of the pod, if any.
+	DisruptionBudget string `json:"disruptionBudget,omitempty"`
+}
+
+// String returns namespace/name of the pod, followed by its blocking PodDisruptionBudget if any.
+func (p BlockingPod) String() string {
+	if p.DisruptionBudget == "" {
+		return p.Namespace + "/" + p.Name
+	}
+	return fmt.Sprintf("%s/%s (PodDisruptionBudget %s)", p.Namespace, p.Name, p.DisruptionBudget)
+}
+
+// GetDrainStatus returns the drain status of node, or nil if it has none.
+func GetDrainStatus(node *corev1.Node) (*DrainStatus, error) {
+	value, ok := node.Annotations[daemonconsts.DrainStatusAnnotationKey]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	status := &DrainStatus{}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		return nil, fmt.Errorf("node %s: invalid %s annotation: %w", node.Name, daemonconsts.DrainStatusAnnotationKey, err)
+	}
+	return status, nil
+}
+
+// EncodeDrainStatus returns status as the value of the DrainStatusAnnotationKey annotation.
+func EncodeDrainStatus(status *DrainStatus) (string, error) {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDrainStatus(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{}}}
+
+	status, err := GetDrainStatus(node)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+
+	lastAttempt := metav1.NewTime(time.Date(2021, time.March, 1, 12, 5, 0, 0, time.UTC).Local())
+	expected := &DrainStatus{
+		Request:         "drain-rendered-worker-1",
+		StartTime:       metav1.NewTime(time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC).Local()),
+		Attempts:        2,
+		LastAttemptTime: &lastAttempt,
+		LastError:       "Cannot evict pod as it would violate the pod's disruption budget.",
+		BlockingPods:    []BlockingPod{{Namespace: "db", Name: "postgres-0", DisruptionBudget: "postgres"}},
+	}
+	value, err := EncodeDrainStatus(expected)
+	require.NoError(t, err)
+	node.Annotations[daemonconsts.DrainStatusAnnotationKey] = value
+
+	status, err = GetDrainStatus(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, status)
+
+	assert.Equal(t, "db/postgres-0 (PodDisruptionBudget postgres)", status.BlockingPods[0].String())
+
+	node.Annotations[daemonconsts.DrainStatusAnnotationKey] = "drain-rendered-worker-1"
+	_, err = GetDrainStatus(node)
+	assert.Error(t, err)
+}
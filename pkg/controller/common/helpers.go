@@ -239,6 +239,18 @@ func PointerConfig(ignitionHost string, rootCA []byte) (ign3types.Config, error)
 	}, nil
 }
 
+// SetPointerConfigToken makes a pointer config authenticate to the MachineConfigServer with
+// token, for servers requiring Ignition tokens.
+func SetPointerConfigToken(conf *ign3types.Config, token string) {
+	authorization := "Bearer " + token
+	for i := range conf.Ignition.Config.Merge {
+		conf.Ignition.Config.Merge[i].HTTPHeaders = append(conf.Ignition.Config.Merge[i].HTTPHeaders, ign3types.HTTPHeader{
+			Name:  "Authorization",
+			Value: &authorization,
+		})
+	}
+}
+
 // NewIgnConfig returns an empty ignition config with version set as latest version
 func NewIgnConfig() ign3types.Config {
 	return ign3types.Config{
@@ -964,18 +976,12 @@ func dedupePasswdUserSSHKeys(passwdUser ign2types.PasswdUser) ign2types.PasswdUs
 	return passwdUser
 }
 
-// CalculateConfigFileDiffs compares the files present in two ignition configurations and returns the list of files
-// that are different between them
+// CalculateConfigFileDiffs compares the files, directories and links present in two ignition configurations and
+// returns the list of paths that are different between them
 func CalculateConfigFileDiffs(oldIgnConfig, newIgnConfig *ign3types.Config) []string {
 	// Go through the files and see what is new or different
-	oldFileSet := make(map[string]ign3types.File)
-	for _, f := range oldIgnConfig.Storage.Files {
-		oldFileSet[f.Path] = f
-	}
-	newFileSet := make(map[string]ign3types.File)
-	for _, f := range newIgnConfig.Storage.Files {
-		newFileSet[f.Path] = f
-	}
+	oldFileSet := storageNodesByPath(oldIgnConfig)
+	newFileSet := storageNodesByPath(newIgnConfig)
 	diffFileSet := []string{}
 
 	// First check if any files were removed
@@ -997,13 +1003,28 @@ func CalculateConfigFileDiffs(oldIgnConfig, newIgnConfig *ign3types.Config) []st
 			diffFileSet = append(diffFileSet, path)
 		} else if !reflect.DeepEqual(oldFile, newFile) {
 			// debug: remove
-			glog.Infof("File diff: detected change to %v", newFile.Path)
+			glog.Infof("File diff: detected change to %v", path)
 			diffFileSet = append(diffFileSet, path)
 		}
 	}
 	return diffFileSet
 }
 
+// storageNodesByPath returns the files, directories and links of an ignition configuration by path.
+func storageNodesByPath(ignConfig *ign3types.Config) map[string]interface{} {
+	nodes := make(map[string]interface{})
+	for _, f := range ignConfig.Storage.Files {
+		nodes[f.Path] = f
+	}
+	for _, d := range ignConfig.Storage.Directories {
+		nodes[d.Path] = d
+	}
+	for _, l := range ignConfig.Storage.Links {
+		nodes[l.Path] = l
+	}
+	return nodes
+}
+
 // NewIgnFile returns a simple ignition3 file from just path and file contents.
 // It also ensures the compression field is set to the empty string, which is
 // currently required for ensuring child configs that may be merged layer
@@ -653,6 +653,16 @@ func TestCalculateConfigFileDiffs(t *testing.T) {
 	if !reflect.DeepEqual(unchangedDiffFileset, []string{}) {
 		t.Errorf("File changes detected where there should have been none: %s", unchangedDiffFileset)
 	}
+
+	// Directories and links are diffed by path too, including a file turned into a link
+	testIgn3ConfigNew.Storage.Files = nil
+	testIgn3ConfigNew.Storage.Links = []ign3types.Link{{
+		Node:          ign3types.Node{Path: "/etc/kubernetes/kubelet-ca.crt"},
+		LinkEmbedded1: ign3types.LinkEmbedded1{Target: "/etc/pki/ca.crt"},
+	}}
+	testIgn3ConfigNew.Storage.Directories = []ign3types.Directory{{Node: ign3types.Node{Path: "/etc/kubernetes/static-pod-resources"}}}
+	actualDiffFileSet = CalculateConfigFileDiffs(&testIgn3ConfigOld, &testIgn3ConfigNew)
+	assert.ElementsMatch(t, []string{"/etc/kubernetes/kubelet-ca.crt", "/etc/kubernetes/static-pod-resources"}, actualDiffFileSet)
 }
 
 func TestParseAndConvertGzippedConfig(t *testing.T) {
@@ -693,3 +703,15 @@ func TestParseAndConvertGzippedConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPointerConfigToken(t *testing.T) {
+	conf, err := PointerConfig("api-int.example.com:22623", []byte("ca"))
+	require.Nil(t, err)
+	SetPointerConfigToken(&conf, "abcdef.0123456789abcdef")
+	require.Len(t, conf.Ignition.Config.Merge, 1)
+	headers := conf.Ignition.Config.Merge[0].HTTPHeaders
+	require.Len(t, headers, 1)
+	assert.Equal(t, "Authorization", headers[0].Name)
+	assert.Equal(t, "Bearer abcdef.0123456789abcdef", *headers[0].Value)
+	require.Nil(t, ValidateIgnition(conf))
+}
@@ -30,19 +30,33 @@ var (
 			Name: "mcc_drain_err",
 			Help: "logs failed drain",
 		}, []string{"node"})
+	// MCCDrainBlockingPods counts the pods left on a node by a failed drain, by the PodDisruptionBudget preventing their eviction
+	MCCDrainBlockingPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_drain_blocking_pods",
+			Help: "pods blocking the drain of a node, by namespace and PodDisruptionBudget",
+		}, []string{"node", "namespace", "pdb"})
 	// MCCPoolAlert logs when the pool configuration changes in a way the user should know.
 	MCCPoolAlert = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "mcc_pool_alert",
 			Help: "pool status alert",
 		}, []string{"pool", "alert"})
+	// MCCRenderedConfigsGarbageCollected counts the rendered MachineConfigs deleted by garbage collection
+	MCCRenderedConfigsGarbageCollected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcc_rendered_configs_garbage_collected_total",
+			Help: "total number of rendered machineconfigs deleted by garbage collection",
+		}, []string{"pool"})
 )
 
 func RegisterMCCMetrics() error {
 	err := RegisterMetrics([]prometheus.Collector{
 		OSImageURLOverride,
 		MCCDrainErr,
+		MCCDrainBlockingPods,
 		MCCPoolAlert,
+		MCCRenderedConfigsGarbageCollected,
 	})
 
 	if err != nil {
@@ -50,6 +64,7 @@ func RegisterMCCMetrics() error {
 	}
 
 	MCCDrainErr.Reset()
+	MCCDrainBlockingPods.Reset()
 
 	return nil
 }
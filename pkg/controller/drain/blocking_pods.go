@@ -0,0 +1,78 @@
+package drain
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeErrs "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// reportBlockingPods returns the pods a failed drain left on node, with the PodDisruptionBudgets
+// preventing their eviction. It emits a warning event on node for each pod and updates the
+// mcc_drain_blocking_pods metric of node.
+func (ctrl *Controller) reportBlockingPods(node *corev1.Node, drainer *drain.Helper) []ctrlcommon.BlockingPod {
+	list, errs := drainer.GetPodsForDeletion(node.Name)
+	if list == nil {
+		glog.Warningf("node %s: failed to list pods left to drain: %v", node.Name, kubeErrs.NewAggregate(errs))
+		return nil
+	}
+
+	pdbs := map[string][]policyv1.PodDisruptionBudget{}
+	type budget struct{ namespace, pdb string }
+	counts := map[budget]float64{}
+	var blocking []ctrlcommon.BlockingPod
+	for i := range list.Pods() {
+		pod := &list.Pods()[i]
+		if _, ok := pdbs[pod.Namespace]; !ok {
+			pdbList, err := ctrl.kubeClient.PolicyV1().PodDisruptionBudgets(pod.Namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				glog.Warningf("Failed to list PodDisruptionBudgets of namespace %s: %v", pod.Namespace, err)
+			} else {
+				pdbs[pod.Namespace] = pdbList.Items
+			}
+		}
+
+		blockingPod := ctrlcommon.BlockingPod{Namespace: pod.Namespace, Name: pod.Name, DisruptionBudget: blockingDisruptionBudget(pod, pdbs[pod.Namespace])}
+		blocking = append(blocking, blockingPod)
+		// The events go on the node: the controller can't write events in the namespaces of workloads.
+		if blockingPod.DisruptionBudget != "" {
+			ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "DrainBlocked", "Pod %s/%s blocks the drain: PodDisruptionBudget %s allows no disruption", pod.Namespace, pod.Name, blockingPod.DisruptionBudget)
+		} else {
+			ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "DrainBlocked", "Pod %s/%s blocks the drain", pod.Namespace, pod.Name)
+		}
+		counts[budget{pod.Namespace, blockingPod.DisruptionBudget}]++
+	}
+
+	ctrlcommon.MCCDrainBlockingPods.DeletePartialMatch(prometheus.Labels{"node": node.Name})
+	for b, count := range counts {
+		ctrlcommon.MCCDrainBlockingPods.WithLabelValues(node.Name, b.namespace, b.pdb).Set(count)
+	}
+	return blocking
+}
+
+// blockingDisruptionBudget returns the name of the first of pdbs that selects pod and allows
+// no disruption, or "" if there is none.
+func blockingDisruptionBudget(pod *corev1.Pod, pdbs []policyv1.PodDisruptionBudget) string {
+	for i := range pdbs {
+		pdb := &pdbs[i]
+		if pdb.Spec.Selector == nil || pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			glog.Warningf("Invalid selector of PodDisruptionBudget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return pdb.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package drain
+
+import (
+	"context"
+	"testing"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+func TestReportBlockingPods(t *testing.T) {
+	newPod := func(namespace, name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: "node-0"},
+		}
+	}
+	newPDB := func(namespace, name string, labels map[string]string, allowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+	client := k8sfake.NewSimpleClientset(
+		newPod("db", "postgres-0", map[string]string{"app": "postgres"}),
+		newPod("db", "postgres-1", map[string]string{"app": "postgres"}),
+		newPod("web", "frontend-0", map[string]string{"app": "frontend"}),
+		newPod("web", "backend-0", map[string]string{"app": "backend"}),
+		newPDB("db", "postgres", map[string]string{"app": "postgres"}, 0),
+		newPDB("web", "frontend", map[string]string{"app": "frontend"}, 1),
+	)
+	recorder := record.NewFakeRecorder(10)
+	ctrl := &Controller{kubeClient: client, eventRecorder: recorder}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	drainer := &drain.Helper{Client: client, Force: true, Ctx: context.TODO()}
+
+	// A previous attempt's metrics are replaced
+	ctrlcommon.MCCDrainBlockingPods.WithLabelValues("node-0", "db", "old").Set(1)
+
+	assert.ElementsMatch(t, []ctrlcommon.BlockingPod{
+		{Namespace: "db", Name: "postgres-0", DisruptionBudget: "postgres"},
+		{Namespace: "db", Name: "postgres-1", DisruptionBudget: "postgres"},
+		{Namespace: "web", Name: "frontend-0"},
+		{Namespace: "web", Name: "backend-0"},
+	}, ctrl.reportBlockingPods(node, drainer))
+	assert.Len(t, recorder.Events, 4)
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Contains(t, events, "Warning DrainBlocked Pod db/postgres-0 blocks the drain: PodDisruptionBudget postgres allows no disruption")
+	assert.Contains(t, events, "Warning DrainBlocked Pod web/backend-0 blocks the drain")
+	assert.Equal(t, 2.0, testutil.ToFloat64(ctrlcommon.MCCDrainBlockingPods.WithLabelValues("node-0", "db", "postgres")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(ctrlcommon.MCCDrainBlockingPods.WithLabelValues("node-0", "web", "")))
+	assert.Equal(t, 2, testutil.CollectAndCount(ctrlcommon.MCCDrainBlockingPods))
+}
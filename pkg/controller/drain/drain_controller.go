@@ -8,10 +8,14 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -90,11 +94,15 @@ type Controller struct {
 	syncHandler func(node string) error
 	enqueueNode func(*corev1.Node)
 
-	nodeLister       corelisterv1.NodeLister
+	nodeLister corelisterv1.NodeLister
+	mcLister   mcfglistersv1.MachineConfigLister
+	mcpLister  mcfglistersv1.MachineConfigPoolLister
+
 	nodeListerSynced cache.InformerSynced
+	mcListerSynced   cache.InformerSynced
+	mcpListerSynced  cache.InformerSynced
 
-	queue         workqueue.RateLimitingInterface
-	ongoingDrains map[string]time.Time
+	queue workqueue.RateLimitingInterface
 
 	cfg Config
 }
@@ -103,6 +111,8 @@ type Controller struct {
 func New(
 	cfg Config,
 	nodeInformer coreinformersv1.NodeInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -127,7 +137,11 @@ func New(
 	ctrl.enqueueNode = ctrl.enqueueDefault
 
 	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.mcpLister = mcpInformer.Lister()
 	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
 
 	return ctrl
 }
@@ -148,13 +162,10 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.nodeListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.nodeListerSynced, ctrl.mcListerSynced, ctrl.mcpListerSynced) {
 		return
 	}
 
-	ongoingDrains := make(map[string]time.Time)
-	ctrl.ongoingDrains = ongoingDrains
-
 	glog.Info("Starting MachineConfigController-DrainController")
 	defer glog.Info("Shutting down MachineConfigController-DrainController")
 
@@ -298,6 +309,7 @@ func (ctrl *Controller) syncNode(key string) error {
 		Ctx:    context.TODO(),
 	}
 
+	var status *ctrlcommon.DrainStatus
 	desiredVerb := strings.Split(desiredState, "-")[0]
 	switch desiredVerb {
 	case daemonconsts.DrainerStateUncordon:
@@ -306,8 +318,14 @@ func (ctrl *Controller) syncNode(key string) error {
 		if err := ctrl.cordonOrUncordonNode(false, node, drainer); err != nil {
 			return fmt.Errorf("failed to uncordon node %v: %w", node.Name, err)
 		}
+		status = &ctrlcommon.DrainStatus{Request: desiredState, StartTime: metav1.NewTime(startTime)}
 	case daemonconsts.DrainerStateDrain:
-		if err := ctrl.drainNode(node, drainer); err != nil {
+		policy, err := ctrl.getDrainPolicy(desiredState)
+		if err != nil {
+			return err
+		}
+		status, err = ctrl.drainNode(node, drainer, desiredState, policy)
+		if err != nil {
 			// If we get an error from drainNode, that means the drain failed.
 			// However, we want to requeue and try again. So we need to return nil
 			// from here so that we can requeue.
@@ -319,8 +337,17 @@ func (ctrl *Controller) syncNode(key string) error {
 
 	ctrl.logNode(node, "operation successful; applying completion annotation")
 	// write annotation for either cordon+drain or uncordon success
+	completionTime := metav1.Now()
+	status.CompletionTime = &completionTime
+	status.LastError = ""
+	status.BlockingPods = nil
+	encodedStatus, err := ctrlcommon.EncodeDrainStatus(status)
+	if err != nil {
+		return err
+	}
 	annotations := map[string]string{
 		daemonconsts.LastAppliedDrainerAnnotationKey: desiredState,
+		daemonconsts.DrainStatusAnnotationKey:        encodedStatus,
 	}
 	if err := ctrl.setNodeAnnotations(node.Name, annotations); err != nil {
 		return fmt.Errorf("node %s: failed to set node uncordoned annotation: %w", node.Name, err)
@@ -329,69 +356,117 @@ func (ctrl *Controller) syncNode(key string) error {
 	return nil
 }
 
-func (ctrl *Controller) drainNode(node *corev1.Node, drainer *drain.Helper) error {
-	// First check if we have an ongoing drain
-	// This is currently stored in the object itself as a map but,
-	// Practically during upgrades the control plane node this controller
-	// pod is running on will also be terminated (the drainer will skip it).
-	// This is a bit problematic in practice since we don't really have a previous state.
-	// TODO (jerzhang) consider using a new CRD for coordination
-	isOngoingDrain := false
-	var duration time.Duration
-
-	for k, v := range ctrl.ongoingDrains {
-		if k != node.Name {
-			continue
-		}
-		isOngoingDrain = true
-		duration = time.Now().Sub(v)
-		glog.Infof("Previous node drain found. Drain has been going on for %v hours", duration.Hours())
-		if duration > ctrl.cfg.DrainTimeoutDuration {
-			glog.Errorf("node %s: drain exceeded timeout: %v. Will continue to retry.", node.Name, ctrl.cfg.DrainTimeoutDuration)
-			ctrlcommon.MCCDrainErr.WithLabelValues(node.Name).Set(1)
-		}
-		break
+// drainNode cordons and drains node for request. The progress of the drain is kept in the node's
+// drain status annotation, so that retries back off and the drain timeout is tracked across
+// controller restarts. It returns the status of the completed drain.
+func (ctrl *Controller) drainNode(node *corev1.Node, drainer *drain.Helper, request string, policy *mcfgv1.DrainPolicy) (*ctrlcommon.DrainStatus, error) {
+	now := time.Now()
+	status, err := ctrlcommon.GetDrainStatus(node)
+	if err != nil {
+		glog.Warningf("Ignoring drain status: %v", err)
 	}
 
-	if !isOngoingDrain {
+	if status == nil || status.Request != request {
 		ctrl.logNode(node, "cordoning")
 		// perform cordon
 		if err := ctrl.cordonOrUncordonNode(true, node, drainer); err != nil {
-			return fmt.Errorf("node %s: failed to cordon: %w", node.Name, err)
+			return nil, fmt.Errorf("node %s: failed to cordon: %w", node.Name, err)
+		}
+		status = &ctrlcommon.DrainStatus{Request: request, StartTime: metav1.NewTime(now)}
+		if err := ctrl.setDrainStatus(node.Name, status); err != nil {
+			return nil, err
+		}
+	} else {
+		if status.Failed {
+			return nil, fmt.Errorf("node %s: drain failed, not retrying: %s", node.Name, status.LastError)
+		}
+		duration := now.Sub(status.StartTime.Time)
+		glog.Infof("Previous node drain found. Drain has been going on for %v hours, %d attempts failed", duration.Hours(), status.Attempts)
+		if timeout := ctrl.drainTimeout(policy); duration > timeout {
+			glog.Errorf("node %s: drain exceeded timeout: %v. Will continue to retry.", node.Name, timeout)
+			ctrlcommon.MCCDrainErr.WithLabelValues(node.Name).Set(1)
+		}
+		// Node updates, including our own status updates, requeue the node before the retry is due.
+		if wait := ctrl.nextDrainAttempt(status, now); wait > 0 {
+			ctrl.enqueueAfter(node, wait)
+			return nil, fmt.Errorf("node %s: next drain attempt in %v", node.Name, wait)
 		}
-		ctrl.ongoingDrains[node.Name] = time.Now()
 	}
 
 	// Attempt drain
 	ctrl.logNode(node, "initiating drain")
-	if err := drain.RunNodeDrain(drainer, node.Name); err != nil {
+	if err := ctrl.runDrain(node, drainer, policy, now.Sub(status.StartTime.Time)); err != nil {
 		// To mimic our old daemon logic, we should probably have a more nuanced backoff.
 		// However since the controller is processing all drains, it is less deterministic how soon the next drain will retry,
-		// Anywhere between instant (if a node change happened) or up to hours (if there are many nodes competing for resources)
 		// For now, let's say if a node has been trying for a set amount of time, we make it less prioritized.
-		if duration > ctrl.cfg.DrainRequeueFailingThreshold {
+		lastAttemptTime := metav1.Now()
+		status.Attempts++
+		status.LastAttemptTime = &lastAttemptTime
+		status.LastError = err.Error()
+		status.BlockingPods = ctrl.reportBlockingPods(node, drainer)
+		timeout := ctrl.drainTimeout(policy)
+		if policy != nil && policy.TimeoutAction == mcfgv1.DrainTimeoutActionFail && lastAttemptTime.Sub(status.StartTime.Time) > timeout {
+			status.Failed = true
+		}
+		if err := ctrl.setDrainStatus(node.Name, status); err != nil {
+			glog.Warningf("Failed to record drain status: %v", err)
+		}
+		if status.Failed {
+			ctrl.logNode(node, "Drain failed for more than %v, not retrying. Error message from drain: %v", timeout, err)
+			ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "DrainFailed", "Drain failed for more than %v, not retrying: %v", timeout, err)
+			ctrlcommon.MCCDrainErr.WithLabelValues(node.Name).Set(1)
+			return nil, err
+		}
+
+		if lastAttemptTime.Sub(status.StartTime.Time) > ctrl.cfg.DrainRequeueFailingThreshold {
 			ctrl.logNode(node, "Drain failed. Drain has been failing for more than %v minutes. Waiting %v minutes then retrying. "+
 				"Error message from drain: %v", ctrl.cfg.DrainRequeueFailingThreshold.Minutes(), ctrl.cfg.DrainRequeueFailingDelay.Minutes(), err)
-			ctrl.enqueueAfter(node, ctrl.cfg.DrainRequeueFailingDelay)
 		} else {
 			ctrl.logNode(node, "Drain failed. Waiting %v minute then retrying. Error message from drain: %v",
 				ctrl.cfg.DrainRequeueDelay.Minutes(), err)
-			ctrl.enqueueAfter(node, ctrl.cfg.DrainRequeueDelay)
 		}
+		ctrl.enqueueAfter(node, ctrl.drainRetryDelay(status, lastAttemptTime.Time))
 
-		// Return early without deleting the ongoing drain.
-		return err
+		return nil, err
 	}
 
-	// Drain was successful. Delete the ongoing drain.
-	delete(ctrl.ongoingDrains, node.Name)
-
 	// Clear the MCCDrainErr, if any.
 	if ctrlcommon.MCCDrainErr.DeleteLabelValues(node.Name) {
 		glog.Infof("Cleaning up MCCDrain error for node(%s) as drain was completed", node.Name)
 	}
+	ctrlcommon.MCCDrainBlockingPods.DeletePartialMatch(prometheus.Labels{"node": node.Name})
 
-	return nil
+	return status, nil
+}
+
+// drainRetryDelay returns how long to wait after a failed drain attempt at attemptTime. Drains failing
+// for longer than DrainRequeueFailingThreshold are retried less often.
+func (ctrl *Controller) drainRetryDelay(status *ctrlcommon.DrainStatus, attemptTime time.Time) time.Duration {
+	if attemptTime.Sub(status.StartTime.Time) > ctrl.cfg.DrainRequeueFailingThreshold {
+		return ctrl.cfg.DrainRequeueFailingDelay
+	}
+	return ctrl.cfg.DrainRequeueDelay
+}
+
+// nextDrainAttempt returns how long until the next drain attempt is due, or 0 if it is due now.
+func (ctrl *Controller) nextDrainAttempt(status *ctrlcommon.DrainStatus, now time.Time) time.Duration {
+	if status.LastAttemptTime == nil {
+		return 0
+	}
+	next := status.LastAttemptTime.Add(ctrl.drainRetryDelay(status, status.LastAttemptTime.Time))
+	if !now.Before(next) {
+		return 0
+	}
+	return next.Sub(now)
+}
+
+// setDrainStatus records status in the drain status annotation of nodeName.
+func (ctrl *Controller) setDrainStatus(nodeName string, status *ctrlcommon.DrainStatus) error {
+	value, err := ctrlcommon.EncodeDrainStatus(status)
+	if err != nil {
+		return err
+	}
+	return ctrl.setNodeAnnotations(nodeName, map[string]string{daemonconsts.DrainStatusAnnotationKey: value})
 }
 
 func (ctrl *Controller) setNodeAnnotations(nodeName string, annotations map[string]string) error {
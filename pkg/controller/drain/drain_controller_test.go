@@ -0,0 +1,51 @@
+package drain
+
+import (
+	"testing"
+	"time"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNextDrainAttempt(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	ctrl := &Controller{cfg: DefaultConfig()}
+
+	tests := []struct {
+		name        string
+		lastAttempt time.Duration
+		now         time.Duration
+		wait        time.Duration
+	}{{
+		name: "no failed attempt",
+		now:  time.Hour,
+	}, {
+		name:        "retry pending",
+		lastAttempt: 2 * time.Minute,
+		now:         2*time.Minute + 20*time.Second,
+		wait:        40 * time.Second,
+	}, {
+		name:        "retry due",
+		lastAttempt: 2 * time.Minute,
+		now:         3 * time.Minute,
+	}, {
+		name:        "failing drain retries less often",
+		lastAttempt: 20 * time.Minute,
+		now:         21 * time.Minute,
+		wait:        4 * time.Minute,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := &ctrlcommon.DrainStatus{Request: "drain-rendered-worker-1", StartTime: metav1.NewTime(start)}
+			if test.lastAttempt != 0 {
+				lastAttempt := metav1.NewTime(start.Add(test.lastAttempt))
+				status.LastAttemptTime = &lastAttempt
+				status.Attempts = 1
+			}
+			assert.Equal(t, test.wait, ctrl.nextDrainAttempt(status, start.Add(test.now)))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package drain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// getDrainPolicy returns the drain policy of the pool that rendered the config a drain request
+// is for, as recorded in the config's controller owner reference. It returns nil if the config
+// isn't owned by a pool or the pool has no drain policy.
+func (ctrl *Controller) getDrainPolicy(request string) (*mcfgv1.DrainPolicy, error) {
+	configName := strings.TrimPrefix(request, daemonconsts.DrainerStateDrain+"-")
+	config, err := ctrl.mcLister.Get(configName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting MachineConfig %s: %w", configName, err)
+	}
+	ref := metav1.GetControllerOf(config)
+	if ref == nil || ref.Kind != "MachineConfigPool" {
+		return nil, nil
+	}
+	pool, err := ctrl.mcpLister.Get(ref.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting pool %s: %w", ref.Name, err)
+	}
+	return pool.Spec.DrainPolicy, nil
+}
+
+// drainTimeout returns how long a drain may take under policy before it is reported as failed.
+func (ctrl *Controller) drainTimeout(policy *mcfgv1.DrainPolicy) time.Duration {
+	if policy != nil && policy.Timeout != nil && policy.Timeout.Duration > 0 {
+		return policy.Timeout.Duration
+	}
+	return ctrl.cfg.DrainTimeoutDuration
+}
+
+// runDrain evicts the pods of node as configured by policy, for a drain that has been running
+// for elapsed. Pods selected by the policy's deletePodSelector are deleted first.
+func (ctrl *Controller) runDrain(node *corev1.Node, drainer *drain.Helper, policy *mcfgv1.DrainPolicy, elapsed time.Duration) error {
+	if policy == nil {
+		return drain.RunNodeDrain(drainer, node.Name)
+	}
+
+	if policy.GracePeriodSeconds != nil {
+		drainer.GracePeriodSeconds = int(*policy.GracePeriodSeconds)
+	}
+	if policy.SkipPodSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.SkipPodSelector)
+		if err != nil {
+			return fmt.Errorf("invalid skipPodSelector: %w", err)
+		}
+		drainer.AdditionalFilters = append(drainer.AdditionalFilters, func(pod corev1.Pod) drain.PodDeleteStatus {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				return drain.MakePodDeleteStatusSkip()
+			}
+			return drain.MakePodDeleteStatusOkay()
+		})
+	}
+	if policy.DeletePodSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.DeletePodSelector)
+		if err != nil {
+			return fmt.Errorf("invalid deletePodSelector: %w", err)
+		}
+		deleter := *drainer
+		deleter.DisableEviction = true
+		deleter.PodSelector = selector.String()
+		ctrl.logNode(node, "deleting pods matching %s", selector)
+		if err := drain.RunNodeDrain(&deleter, node.Name); err != nil {
+			return fmt.Errorf("deleting pods matching %s: %w", selector, err)
+		}
+	}
+	if override := policy.PodDisruptionBudgetOverrideAfter; override != nil && elapsed >= override.Duration {
+		ctrl.logNode(node, "drain running for more than %v, deleting pods regardless of PodDisruptionBudgets", override.Duration)
+		drainer.DisableEviction = true
+	}
+	return drain.RunNodeDrain(drainer, node.Name)
+}
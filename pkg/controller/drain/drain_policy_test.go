@@ -0,0 +1,128 @@
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+func TestGetDrainPolicy(t *testing.T) {
+	policy := &mcfgv1.DrainPolicy{Timeout: &metav1.Duration{Duration: 3 * time.Hour}}
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	pool.Spec.DrainPolicy = policy
+
+	owned := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	owned.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(pool, mcfgv1.SchemeGroupVersion.WithKind("MachineConfigPool"))}
+	orphan := helpers.NewMachineConfig("rendered-worker-0", nil, "", nil)
+	poolGone := helpers.NewMachineConfig("rendered-infra-1", nil, "", nil)
+	poolGone.OwnerReferences = []metav1.OwnerReference{{APIVersion: "machineconfiguration.openshift.io/v1", Kind: "MachineConfigPool", Name: "infra", Controller: helpers.BoolToPtr(true)}}
+
+	mcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	mcpIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, mc := range []*mcfgv1.MachineConfig{owned, orphan, poolGone} {
+		require.NoError(t, mcIndexer.Add(mc))
+	}
+	require.NoError(t, mcpIndexer.Add(pool))
+	ctrl := &Controller{
+		mcLister:  mcfglistersv1.NewMachineConfigLister(mcIndexer),
+		mcpLister: mcfglistersv1.NewMachineConfigPoolLister(mcpIndexer),
+	}
+
+	for request, expected := range map[string]*mcfgv1.DrainPolicy{
+		"drain-rendered-worker-1": policy,
+		"drain-rendered-worker-0": nil,
+		"drain-rendered-infra-1":  nil,
+		"drain-rendered-worker-2": nil,
+	} {
+		got, err := ctrl.getDrainPolicy(request)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, got, request)
+	}
+}
+
+func TestRunDrain(t *testing.T) {
+	newPod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: "node-0"},
+		}
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	gracePeriod := int32(600)
+
+	tests := []struct {
+		name      string
+		policy    *mcfgv1.DrainPolicy
+		remaining []string
+	}{{
+		name: "no policy",
+	}, {
+		name: "skipped pods",
+		policy: &mcfgv1.DrainPolicy{
+			SkipPodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"workload": "batch"}},
+		},
+		remaining: []string{"batch"},
+	}, {
+		name: "deleted and skipped pods",
+		policy: &mcfgv1.DrainPolicy{
+			GracePeriodSeconds: &gracePeriod,
+			SkipPodSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"workload": "batch"}},
+			DeletePodSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"workload": "cache"}},
+		},
+		remaining: []string{"batch"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := k8sfake.NewSimpleClientset(
+				newPod("batch", map[string]string{"workload": "batch"}),
+				newPod("cache", map[string]string{"workload": "cache"}),
+				newPod("web", nil),
+			)
+			// The fake client doesn't support eviction
+			drainer := &drain.Helper{
+				Client:             client,
+				DisableEviction:    true,
+				Force:              true,
+				GracePeriodSeconds: -1,
+				Timeout:            time.Second,
+				Out:                writer{t.Log},
+				ErrOut:             writer{t.Log},
+				Ctx:                context.TODO(),
+			}
+			ctrl := &Controller{cfg: DefaultConfig()}
+			require.NoError(t, ctrl.runDrain(node, drainer, test.policy, 0))
+
+			pods, err := client.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{})
+			require.NoError(t, err)
+			var remaining []string
+			for _, pod := range pods.Items {
+				remaining = append(remaining, pod.Name)
+			}
+			assert.Equal(t, test.remaining, remaining)
+		})
+	}
+}
+
+func TestRunDrainOverridesDisruptionBudgets(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}
+	policy := &mcfgv1.DrainPolicy{PodDisruptionBudgetOverrideAfter: &metav1.Duration{Duration: time.Hour}}
+	ctrl := &Controller{cfg: DefaultConfig()}
+
+	for elapsed, disableEviction := range map[time.Duration]bool{30 * time.Minute: false, 2 * time.Hour: true} {
+		drainer := &drain.Helper{Client: k8sfake.NewSimpleClientset(), Ctx: context.TODO(), Out: writer{t.Log}, ErrOut: writer{t.Log}}
+		require.NoError(t, ctrl.runDrain(node, drainer, policy, elapsed))
+		assert.Equal(t, disableEviction, drainer.DisableEviction, elapsed)
+	}
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// etcdLeaderTimeout bounds how long the node controller waits for the etcd leader
+	// before it gives up and updates the control plane without deferring the leader.
+	etcdLeaderTimeout = 10 * time.Second
+)
+
+// EtcdLeaderSource reports which control plane node currently hosts the etcd leader.
+type EtcdLeaderSource interface {
+	// GetEtcdLeader returns the etcd member name of the current leader. In OpenShift
+	// etcd members are named after the node they run on.
+	GetEtcdLeader(ctx context.Context) (string, error)
+}
+
+// etcdMemberStatusLeaderSource looks up the etcd leader via the JSON gRPC gateway
+// of an etcd endpoint (or a local stand-in serving the same two endpoints).
+type etcdMemberStatusLeaderSource struct {
+	endpoint string
+	client   *http.Client
+}
+
+// etcdStatusResponse is the subset of the etcd /v3/maintenance/status response we need.
+// The gateway encodes uint64 IDs as strings.
+type etcdStatusResponse struct {
+	Leader string `json:"leader"`
+}
+
+// etcdMemberListResponse is the subset of the etcd /v3/cluster/member/list response we need.
+type etcdMemberListResponse struct {
+	Members []struct {
+		ID   string `json:"ID"`
+		Name string `json:"name"`
+	} `json:"members"`
+}
+
+// NewEtcdMemberStatusLeaderSource returns an EtcdLeaderSource querying the etcd member status at endpoint.
+// The optional CA, certificate and key files are used to authenticate against etcd.
+func NewEtcdMemberStatusLeaderSource(endpoint, caFile, certFile, keyFile string) (EtcdLeaderSource, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		caData, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading etcd CA %s: %w", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in etcd CA %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return nil, fmt.Errorf("loading etcd client certificate: %w", err)
+		}
+		// The certificate is loaded for every connection, to pick up rotations.
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("loading etcd client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+
+	return &etcdMemberStatusLeaderSource{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client: &http.Client{
+			Timeout:   etcdLeaderTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+func (s *etcdMemberStatusLeaderSource) GetEtcdLeader(ctx context.Context) (string, error) {
+	status := etcdStatusResponse{}
+	if err := s.post(ctx, "/v3/maintenance/status", &status); err != nil {
+		return "", err
+	}
+	if status.Leader == "" || status.Leader == "0" {
+		return "", fmt.Errorf("etcd at %s reports no leader", s.endpoint)
+	}
+
+	members := etcdMemberListResponse{}
+	if err := s.post(ctx, "/v3/cluster/member/list", &members); err != nil {
+		return "", err
+	}
+	for _, member := range members.Members {
+		if member.ID == status.Leader {
+			return member.Name, nil
+		}
+	}
+	return "", fmt.Errorf("etcd leader %s not found in member list", status.Leader)
+}
+
+func (s *etcdMemberStatusLeaderSource) post(ctx context.Context, path string, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewBufferString("{}"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("querying etcd %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading etcd %s response: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("querying etcd %s: unexpected status %d: %s", path, resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("decoding etcd %s response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEtcdStandIn(t *testing.T, status, members string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		switch r.URL.Path {
+		case "/v3/maintenance/status":
+			w.Write([]byte(status))
+		case "/v3/cluster/member/list":
+			w.Write([]byte(members))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestEtcdMemberStatusLeaderSource(t *testing.T) {
+	members := `{"header":{"cluster_id":"1"},"members":[{"ID":"111","name":"master-0"},{"ID":"222","name":"master-1"},{"ID":"333","name":"master-2"}]}`
+
+	tests := []struct {
+		name     string
+		status   string
+		expected string
+		errors   bool
+	}{{
+		name:     "leader found",
+		status:   `{"header":{"member_id":"111"},"version":"3.5.6","leader":"222"}`,
+		expected: "master-1",
+	}, {
+		name:   "no leader",
+		status: `{"header":{"member_id":"111"},"version":"3.5.6","leader":"0"}`,
+		errors: true,
+	}, {
+		name:   "leader not a member",
+		status: `{"header":{"member_id":"111"},"version":"3.5.6","leader":"444"}`,
+		errors: true,
+	}, {
+		name:   "malformed response",
+		status: `not json`,
+		errors: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newEtcdStandIn(t, test.status, members)
+			defer server.Close()
+
+			source, err := NewEtcdMemberStatusLeaderSource(server.URL+"/", "", "", "")
+			require.Nil(t, err)
+
+			leader, err := source.GetEtcdLeader(context.TODO())
+			if test.errors {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, leader)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package node
+
+import (
+	"sort"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// getHealthGateFailedNodes returns the sorted names of the nodes that failed the health gates of
+// pool after rebooting into its targeted configuration and still target it.
+func getHealthGateFailedNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []string {
+	if pool.Spec.HealthGates == nil {
+		return nil
+	}
+	targetConfig := pool.Spec.Configuration.Name
+	var failed []string
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.HealthGateFailedAnnotationKey] != targetConfig {
+			continue
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != targetConfig {
+			continue
+		}
+		failed = append(failed, node.Name)
+	}
+	sort.Strings(failed)
+	return failed
+}
@@ -0,0 +1,31 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetHealthGateFailedNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-2", "v0", "v1"),
+		newNode("node-1", "v0", "v1"),
+		// Failed the gates of an older config.
+		newNode("node-3", "v0", "v1"),
+		// Rolled back since.
+		newNode("node-4", "v0", "v0"),
+		newNode("node-5", "v0", "v1"),
+	}
+	for i, failedConfig := range []string{"v1", "v1", "v0", "v1"} {
+		addNodeAnnotations(nodes[i], map[string]string{daemonconsts.HealthGateFailedAnnotationKey: failedConfig})
+	}
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	assert.Empty(t, getHealthGateFailedNodes(pool, nodes))
+
+	pool.Spec.HealthGates = &mcfgv1.HealthGates{Kubelet: true}
+	assert.Equal(t, []string{"node-1", "node-2"}, getHealthGateFailedNodes(pool, nodes))
+}
@@ -0,0 +1,136 @@
+package node
+
+import (
+	"fmt"
+	"time"
+	// Embed the time zone database, the controller image may not ship one.
+	_ "time/tzdata"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// maintenanceWindowTimeLayout is the layout of a maintenance window's start and end times.
+	maintenanceWindowTimeLayout = "15:04"
+
+	// maintenanceWindowClosedReason is the condition reason while waiting for a window to open.
+	maintenanceWindowClosedReason = "MaintenanceWindowClosed"
+	// maintenanceWindowInvalidReason is the condition reason for windows that can't be parsed.
+	maintenanceWindowInvalidReason = "InvalidMaintenanceWindow"
+)
+
+var maintenanceWindowDays = map[string]time.Weekday{
+	time.Sunday.String():    time.Sunday,
+	time.Monday.String():    time.Monday,
+	time.Tuesday.String():   time.Tuesday,
+	time.Wednesday.String(): time.Wednesday,
+	time.Thursday.String():  time.Thursday,
+	time.Friday.String():    time.Friday,
+	time.Saturday.String():  time.Saturday,
+}
+
+// waitForMaintenanceWindow returns true if the pool has maintenance windows and none of them is
+// open at now. In that case the pool is marked as waiting and requeued for when the next window opens.
+func (ctrl *Controller) waitForMaintenanceWindow(pool *mcfgv1.MachineConfigPool, now time.Time) bool {
+	if len(pool.Spec.MaintenanceWindows) == 0 {
+		setMaintenanceWindowCondition(pool, corev1.ConditionFalse, "", "")
+		return false
+	}
+
+	open, next, err := nextMaintenanceWindow(pool.Spec.MaintenanceWindows, now)
+	if err != nil {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, maintenanceWindowInvalidReason, "Not updating nodes: %v", err)
+		setMaintenanceWindowCondition(pool, corev1.ConditionTrue, maintenanceWindowInvalidReason, fmt.Sprintf("Invalid maintenance window, not updating nodes: %v", err))
+		return true
+	}
+	if open {
+		setMaintenanceWindowCondition(pool, corev1.ConditionFalse, "", "")
+		return false
+	}
+
+	ctrl.logPool(pool, "Waiting for maintenance window opening at %s", next.Format(time.RFC3339))
+	setMaintenanceWindowCondition(pool, corev1.ConditionTrue, maintenanceWindowClosedReason,
+		fmt.Sprintf("Waiting for the maintenance window opening at %s to update nodes to %s", next.Format(time.RFC3339), pool.Spec.Configuration.Name))
+	ctrl.enqueueAfter(pool, next.Sub(now))
+	return true
+}
+
+// setMaintenanceWindowCondition sets the pool's WaitingForMaintenanceWindow condition. The condition
+// is only added to pools which have maintenance windows or had the condition before.
+func setMaintenanceWindowCondition(pool *mcfgv1.MachineConfigPool, status corev1.ConditionStatus, reason, message string) {
+	if len(pool.Spec.MaintenanceWindows) == 0 && mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolWaitingForMaintenanceWindow) == nil {
+		return
+	}
+	condition := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolWaitingForMaintenanceWindow, status, reason, message)
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *condition)
+}
+
+// nextMaintenanceWindow returns true if one of windows is open at now. Otherwise it returns
+// the time the next window opens.
+func nextMaintenanceWindow(windows []mcfgv1.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	var next time.Time
+	for i := range windows {
+		open, opens, err := checkMaintenanceWindow(&windows[i], now)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if open {
+			return true, time.Time{}, nil
+		}
+		if next.IsZero() || opens.Before(next) {
+			next = opens
+		}
+	}
+	return false, next, nil
+}
+
+// checkMaintenanceWindow returns true if window is open at now, along with the time the current
+// (if open) or next (if closed) occurrence of the window opens.
+func checkMaintenanceWindow(window *mcfgv1.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	loc := time.UTC
+	if window.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(window.TimeZone); err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid maintenance window time zone %q: %w", window.TimeZone, err)
+		}
+	}
+	start, err := time.Parse(maintenanceWindowTimeLayout, window.StartTime)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid maintenance window start time %q: %w", window.StartTime, err)
+	}
+	end, err := time.Parse(maintenanceWindowTimeLayout, window.EndTime)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid maintenance window end time %q: %w", window.EndTime, err)
+	}
+	days := make(map[time.Weekday]bool, len(window.Days))
+	for _, day := range window.Days {
+		weekday, ok := maintenanceWindowDays[day]
+		if !ok {
+			return false, time.Time{}, fmt.Errorf("invalid maintenance window day %q", day)
+		}
+		days[weekday] = true
+	}
+
+	local := now.In(loc)
+	// Start a day early since yesterday's window may still be open past midnight.
+	for offset := -1; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		opens := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		closes := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+		if !closes.After(opens) {
+			closes = closes.AddDate(0, 0, 1)
+		}
+		if !now.Before(opens) && now.Before(closes) {
+			return true, opens, nil
+		}
+		if opens.After(now) {
+			return false, opens, nil
+		}
+	}
+	// Unreachable with at least one valid day, every window opens within a week.
+	return false, time.Time{}, fmt.Errorf("maintenance window never opens")
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextMaintenanceWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	weekdayNights := mcfgv1.MaintenanceWindow{
+		Days:      []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"},
+		StartTime: "01:00",
+		EndTime:   "05:00",
+		TimeZone:  "Europe/Berlin",
+	}
+	overnight := mcfgv1.MaintenanceWindow{
+		Days:      []string{"Saturday"},
+		StartTime: "22:00",
+		EndTime:   "02:00",
+	}
+
+	tests := []struct {
+		name     string
+		windows  []mcfgv1.MaintenanceWindow
+		now      time.Time
+		open     bool
+		nextOpen time.Time
+		err      bool
+	}{
+		{
+			name:    "inside weekday window",
+			windows: []mcfgv1.MaintenanceWindow{weekdayNights},
+			// Wednesday
+			now:  time.Date(2023, time.March, 1, 2, 30, 0, 0, berlin),
+			open: true,
+		},
+		{
+			name:     "after weekday window",
+			windows:  []mcfgv1.MaintenanceWindow{weekdayNights},
+			now:      time.Date(2023, time.March, 1, 5, 0, 0, 0, berlin),
+			nextOpen: time.Date(2023, time.March, 2, 1, 0, 0, 0, berlin),
+		},
+		{
+			name:    "window is in its own time zone",
+			windows: []mcfgv1.MaintenanceWindow{weekdayNights},
+			// 02:30 in Berlin
+			now:  time.Date(2023, time.March, 1, 1, 30, 0, 0, time.UTC),
+			open: true,
+		},
+		{
+			name:     "weekend skips to monday",
+			windows:  []mcfgv1.MaintenanceWindow{weekdayNights},
+			now:      time.Date(2023, time.March, 4, 2, 0, 0, 0, berlin),
+			nextOpen: time.Date(2023, time.March, 6, 1, 0, 0, 0, berlin),
+		},
+		{
+			name:    "window crossing midnight is open the next day",
+			windows: []mcfgv1.MaintenanceWindow{overnight},
+			// Sunday
+			now:  time.Date(2023, time.March, 5, 1, 0, 0, 0, time.UTC),
+			open: true,
+		},
+		{
+			name:     "earliest of several windows",
+			windows:  []mcfgv1.MaintenanceWindow{weekdayNights, overnight},
+			now:      time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+			nextOpen: time.Date(2023, time.March, 4, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "every day when no days are set",
+			windows: []mcfgv1.MaintenanceWindow{{StartTime: "00:00", EndTime: "00:00"}},
+			now:     time.Date(2023, time.March, 3, 12, 0, 0, 0, time.UTC),
+			open:    true,
+		},
+		{
+			name:    "invalid day",
+			windows: []mcfgv1.MaintenanceWindow{{Days: []string{"Funday"}, StartTime: "01:00", EndTime: "02:00"}},
+			err:     true,
+		},
+		{
+			name:    "invalid time",
+			windows: []mcfgv1.MaintenanceWindow{{StartTime: "1am", EndTime: "02:00"}},
+			err:     true,
+		},
+		{
+			name:    "invalid time zone",
+			windows: []mcfgv1.MaintenanceWindow{{StartTime: "01:00", EndTime: "02:00", TimeZone: "Mars/Olympus_Mons"}},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			open, nextOpen, err := nextMaintenanceWindow(test.windows, test.now)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.open, open)
+			if !test.open {
+				assert.True(t, test.nextOpen.Equal(nextOpen), "expected next window at %s, got %s", test.nextOpen, nextOpen)
+			}
+		})
+	}
+}
@@ -89,6 +89,9 @@ type Controller struct {
 	schedulerList         cligolistersv1.SchedulerLister
 	schedulerListerSynced cache.InformerSynced
 
+	etcdLeaderSource EtcdLeaderSource
+	machineDeleter   MachineDeleter
+
 	queue workqueue.RateLimitingInterface
 }
 
@@ -805,12 +808,15 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 	if err := ctrl.setClusterConfigAnnotation(nodes); err != nil {
 		return fmt.Errorf("error setting clusterConfig Annotation for node in pool %q, error: %w", pool.Name, err)
 	}
+	// While the pool is rolled back, nodes are targeted at its previous configuration.
+	target := ctrl.reconcileRollback(pool, nodes, time.Now())
+	rolledBack := target != pool
 	// Taint all the nodes in the node pool, irrespective of their upgrade status.
 	ctx := context.TODO()
 	for _, node := range nodes {
 		// All the nodes that need to be upgraded should have `NodeUpdateInProgressTaint` so that they're less likely
 		// to be chosen during the scheduling cycle.
-		targetConfig := pool.Spec.Configuration.Name
+		targetConfig := target.Spec.Configuration.Name
 		hasInProgressTaint := checkIfNodeHasInProgressTaint(node)
 		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
 			if hasInProgressTaint {
@@ -828,7 +834,30 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 			}
 		}
 	}
-	candidates, capacity := getAllCandidateMachines(pool, nodes, maxunavail)
+	ctrl.reprovisionNodes(pool, nodes)
+	candidates, capacity := getAllCandidateMachines(target, nodes, maxunavail)
+	// Rollbacks skip the rollout strategy and maintenance windows to recover degraded nodes right away.
+	if !rolledBack {
+		candidates, capacity, err = ctrl.applyRolloutStrategy(pool, nodes, candidates, capacity, time.Now())
+		if err != nil {
+			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+				errs := kubeErrs.NewAggregate([]error{syncErr, err})
+				return fmt.Errorf("error applying rollout strategy for pool %q, sync error: %w", pool.Name, errs)
+			}
+			return err
+		}
+		// Nodes failing the health gates of the targeted config halt its rollout.
+		if failed := getHealthGateFailedNodes(pool, nodes); len(failed) > 0 && len(candidates) > 0 {
+			ctrl.logPool(pool, "Rollout of %s halted, nodes failed its health gates: %v", pool.Spec.Configuration.Name, failed)
+			candidates, capacity = nil, 0
+		}
+	}
+	if len(candidates) == 0 || rolledBack {
+		setMaintenanceWindowCondition(pool, corev1.ConditionFalse, "", "")
+	} else if ctrl.waitForMaintenanceWindow(pool, time.Now()) {
+		// Nodes which already started updating carry on, new ones wait for the window.
+		return ctrl.syncStatusOnly(pool)
+	}
 	if len(candidates) > 0 {
 		zones := make(map[string]bool)
 		for _, candidate := range candidates {
@@ -838,13 +867,22 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 			}
 		}
 		ctrl.logPool(pool, "%d candidate nodes in %d zones for update, capacity: %d", len(candidates), len(zones), capacity)
-		if err := ctrl.updateCandidateMachines(pool, candidates, capacity); err != nil {
+		if err := ctrl.updateCandidateMachines(target, candidates, capacity); err != nil {
 			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
 				errs := kubeErrs.NewAggregate([]error{syncErr, err})
 				return fmt.Errorf("error setting desired machine config annotation for pool %q, sync error: %w", pool.Name, errs)
 			}
 			return err
 		}
+	} else if !rolledBack {
+		// Nodes are rebooted for staged kernel arguments once the pool has no updates to roll out.
+		if err := ctrl.rebootPendingNodes(pool, nodes, maxunavail, time.Now()); err != nil {
+			if syncErr := ctrl.syncStatusOnly(pool); syncErr != nil {
+				errs := kubeErrs.NewAggregate([]error{syncErr, err})
+				return fmt.Errorf("error requesting reboot of nodes for pool %q, sync error: %w", pool.Name, errs)
+			}
+			return err
+		}
 	}
 	return ctrl.syncStatusOnly(pool)
 }
@@ -987,8 +1025,32 @@ func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.
 	return nodes[:capacity]
 }
 
-// getCurrentEtcdLeader is not yet implemented
+// SetEtcdLeaderSource configures where the controller looks up the current etcd leader.
+// Without a source the control plane is updated without regard to etcd leadership.
+func (ctrl *Controller) SetEtcdLeaderSource(source EtcdLeaderSource) {
+	ctrl.etcdLeaderSource = source
+}
+
+// getCurrentEtcdLeader returns the candidate node hosting the current etcd leader, or nil
+// if no leader source is configured or the leader is not among the candidates.
 func (ctrl *Controller) getCurrentEtcdLeader(candidates []*corev1.Node) (*corev1.Node, error) {
+	if ctrl.etcdLeaderSource == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), etcdLeaderTimeout)
+	defer cancel()
+	leader, err := ctrl.etcdLeaderSource.GetEtcdLeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range candidates {
+		if node.Name == leader {
+			return node, nil
+		}
+	}
+	glog.V(4).Infof("etcd leader %s is not a candidate for update", leader)
 	return nil, nil
 }
 
@@ -1007,7 +1069,7 @@ func (ctrl *Controller) filterControlPlaneCandidateNodes(pool *mcfgv1.MachineCon
 	for _, node := range candidates {
 		if node == etcdLeader {
 			// For now make this an event so we know it's working, even though it's more of a non-event
-			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "DeferringEtcdLeaderUpdate", "Deferring update of etcd leader %s", node.Name)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "DeferringEtcdLeaderUpdate", "Deferring update of etcd leader %s until the other %d candidate(s) are updated", node.Name, len(candidates)-1)
 			glog.Infof("Deferring update of etcd leader: %s", node.Name)
 			continue
 		}
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -34,6 +35,7 @@ import (
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 )
 
@@ -101,6 +103,9 @@ func (f *fixture) newController() *Controller {
 	for _, c := range f.mcpLister {
 		i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(c)
 	}
+	for _, c := range f.mcLister {
+		i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(c)
+	}
 
 	for _, m := range f.nodeLister {
 		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(m)
@@ -954,6 +959,45 @@ func TestPaused(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
+func TestMaintenanceWindowClosed(t *testing.T) {
+	f := newFixture(t)
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
+	mcp := helpers.NewMachineConfigPool("test-cluster-infra", nil, helpers.InfraSelector, "v1")
+	mcpWorker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	mcp.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(1))
+	// A short window half a day away can't be open right now
+	opens := time.Now().UTC().Add(12 * time.Hour)
+	mcp.Spec.MaintenanceWindows = []mcfgv1.MaintenanceWindow{{
+		StartTime: opens.Format(maintenanceWindowTimeLayout),
+		EndTime:   opens.Add(time.Minute).Format(maintenanceWindowTimeLayout),
+	}}
+	node := newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": "", "node-role/infra": ""})
+	node.Spec.Taints = []corev1.Taint{*constants.NodeUpdateInProgressTaint}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+		node,
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcpWorker)
+	f.objects = append(f.objects, mcp, mcpWorker)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	// No node is told to update, only the pool status changes
+	_, next, err := nextMaintenanceWindow(mcp.Spec.MaintenanceWindows, time.Now())
+	require.NoError(t, err)
+	expMcp := mcp.DeepCopy()
+	setMaintenanceWindowCondition(expMcp, corev1.ConditionTrue, maintenanceWindowClosedReason,
+		fmt.Sprintf("Waiting for the maintenance window opening at %s to update nodes to v1", next.Format(time.RFC3339)))
+	expMcp.Status = calculateStatus(expMcp, nodes)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
 func TestShouldUpdateStatusOnlyUpdated(t *testing.T) {
 	f := newFixture(t)
 	cc := newControllerConfig(ctrlcommon.ControllerConfigName, configv1.TopologyMode(""))
@@ -1152,3 +1196,79 @@ func filterLastTransitionTime(obj runtime.Object) runtime.Object {
 	}
 	return o
 }
+
+type fakeEtcdLeaderSource struct {
+	leader string
+	err    error
+}
+
+func (f *fakeEtcdLeaderSource) GetEtcdLeader(ctx context.Context) (string, error) {
+	return f.leader, f.err
+}
+
+func TestFilterControlPlaneCandidateNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   EtcdLeaderSource
+		nodes    []string
+		expected []string
+		deferred bool
+	}{{
+		name:     "no leader source",
+		nodes:    []string{"node-0", "node-1", "node-2"},
+		expected: []string{"node-0", "node-1", "node-2"},
+	}, {
+		name:     "leader deferred",
+		source:   &fakeEtcdLeaderSource{leader: "node-1"},
+		nodes:    []string{"node-0", "node-1", "node-2"},
+		expected: []string{"node-0", "node-2"},
+		deferred: true,
+	}, {
+		name:     "leader not a candidate",
+		source:   &fakeEtcdLeaderSource{leader: "node-3"},
+		nodes:    []string{"node-0", "node-1"},
+		expected: []string{"node-0", "node-1"},
+	}, {
+		name:     "leader is the last candidate",
+		source:   &fakeEtcdLeaderSource{leader: "node-1"},
+		nodes:    []string{"node-1"},
+		expected: []string{"node-1"},
+	}, {
+		name:     "leader lookup fails",
+		source:   &fakeEtcdLeaderSource{err: fmt.Errorf("etcd unavailable")},
+		nodes:    []string{"node-0", "node-1"},
+		expected: []string{"node-0", "node-1"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			c := f.newController()
+			recorder := record.NewFakeRecorder(10)
+			c.eventRecorder = recorder
+			c.SetEtcdLeaderSource(test.source)
+
+			pool := helpers.NewMachineConfigPool(ctrlcommon.MachineConfigPoolMaster, nil, helpers.MasterSelector, "v1")
+			var candidates []*corev1.Node
+			for _, name := range test.nodes {
+				candidates = append(candidates, newNode(name, "v0", "v0"))
+			}
+
+			filtered, capacity, err := c.filterControlPlaneCandidateNodes(pool, candidates, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, uint(1), capacity)
+			var names []string
+			for _, node := range filtered {
+				names = append(names, node.Name)
+			}
+			assert.Equal(t, test.expected, names)
+
+			if test.deferred {
+				assert.Len(t, recorder.Events, 1)
+				assert.Contains(t, <-recorder.Events, "DeferringEtcdLeaderUpdate")
+			} else {
+				assert.Len(t, recorder.Events, 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"sort"
+	"time"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isRebootRequested returns true if node was asked to reboot to apply its staged kernel arguments
+// and hasn't rebooted yet.
+func isRebootRequested(node *corev1.Node) bool {
+	requested := node.Annotations[daemonconsts.RebootRequestedAnnotationKey]
+	return requested != "" && requested == node.Annotations[daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey]
+}
+
+// getPendingRebootNodes returns the nodes that are done updating but still need a reboot to apply
+// staged kernel arguments, and haven't been asked to reboot yet.
+func getPendingRebootNodes(nodes []*corev1.Node) []*corev1.Node {
+	var pending []*corev1.Node
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey] == "" || isRebootRequested(node) {
+			continue
+		}
+		if !isNodeDone(node) || !isNodeReady(node) {
+			continue
+		}
+		pending = append(pending, node)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+	return pending
+}
+
+// rebootPendingNodes asks the daemons of the pool's nodes with staged kernel arguments to reboot
+// while one of the pool's maintenance windows is open, keeping at most maxUnavailable nodes
+// unavailable. Pools without maintenance windows leave the reboot to the administrator or a
+// later update. While no window is open the pool is requeued for when the next one opens.
+func (ctrl *Controller) rebootPendingNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, maxUnavailable int, now time.Time) error {
+	if len(pool.Spec.MaintenanceWindows) == 0 {
+		return nil
+	}
+	pending := getPendingRebootNodes(nodes)
+	if len(pending) == 0 {
+		return nil
+	}
+	open, next, err := nextMaintenanceWindow(pool.Spec.MaintenanceWindows, now)
+	if err != nil {
+		// Reported by waitForMaintenanceWindow with the next update.
+		ctrl.logPool(pool, "Not rebooting nodes with staged kernel arguments: %v", err)
+		return nil
+	}
+	if !open {
+		ctrl.logPool(pool, "Waiting for maintenance window opening at %s to reboot %d nodes with staged kernel arguments", next.Format(time.RFC3339), len(pending))
+		ctrl.enqueueAfter(pool, next.Sub(now))
+		return nil
+	}
+
+	capacity := maxUnavailable - len(getUnavailableMachines(nodes))
+	for i := 0; i < capacity && i < len(pending); i++ {
+		node := pending[i]
+		config := node.Annotations[daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey]
+		ctrl.logPool(pool, "Requesting reboot of node %s to apply kernel arguments staged for %s", node.Name, config)
+		if _, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			node.Annotations[daemonconsts.RebootRequestedAnnotationKey] = config
+		}); err != nil {
+			return err
+		}
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RebootRequested", "Requested reboot of node %s to apply kernel arguments staged for %s", node.Name, config)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetPendingRebootNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-2", "v1", "v1"),
+		newNode("node-1", "v1", "v1"),
+		// Already asked to reboot.
+		newNode("node-3", "v1", "v1"),
+		// Asked to reboot for kernel arguments it has since superseded.
+		newNode("node-4", "v1", "v1"),
+		// Still updating.
+		newNode("node-5", "v0", "v1"),
+		newNode("node-6", "v1", "v1"),
+	}
+	for i, pending := range []string{"v1", "v1", "v1", "v1", "v0"} {
+		addNodeAnnotations(nodes[i], map[string]string{daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey: pending})
+	}
+	addNodeAnnotations(nodes[2], map[string]string{daemonconsts.RebootRequestedAnnotationKey: "v1"})
+	addNodeAnnotations(nodes[3], map[string]string{daemonconsts.RebootRequestedAnnotationKey: "v0"})
+
+	var names []string
+	for _, node := range getPendingRebootNodes(nodes) {
+		names = append(names, node.Name)
+	}
+	assert.Equal(t, []string{"node-1", "node-2", "node-4"}, names)
+	assert.True(t, isNodeUnavailable(nodes[2]))
+	assert.False(t, isNodeUnavailable(nodes[3]))
+}
+
+func TestRebootPendingNodes(t *testing.T) {
+	// Wednesday
+	inWindow := time.Date(2023, time.March, 1, 2, 0, 0, 0, time.UTC)
+	outsideWindow := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		windows   []mcfgv1.MaintenanceWindow
+		now       time.Time
+		requested []string
+	}{
+		{
+			name: "no maintenance windows",
+			now:  inWindow,
+		},
+		{
+			name:    "window closed",
+			windows: []mcfgv1.MaintenanceWindow{{StartTime: "01:00", EndTime: "05:00"}},
+			now:     outsideWindow,
+		},
+		{
+			name:      "window open",
+			windows:   []mcfgv1.MaintenanceWindow{{StartTime: "01:00", EndTime: "05:00"}},
+			now:       inWindow,
+			requested: []string{"node-0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes := []*corev1.Node{
+				newNode("node-0", "v1", "v1"),
+				newNode("node-1", "v1", "v1"),
+				newNode("node-2", "v1", "v1"),
+			}
+			for _, node := range nodes[:2] {
+				addNodeAnnotations(node, map[string]string{daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey: "v1"})
+			}
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.MaintenanceWindows = test.windows
+
+			f := newFixture(t)
+			for _, node := range nodes {
+				f.nodeLister = append(f.nodeLister, node)
+				f.kubeobjects = append(f.kubeobjects, node)
+			}
+			ctrl := f.newController()
+			recorder := record.NewFakeRecorder(10)
+			ctrl.eventRecorder = recorder
+
+			// With a single unavailable node allowed, nodes are rebooted one at a time.
+			require.NoError(t, ctrl.rebootPendingNodes(pool, nodes, 1, test.now))
+
+			var requested []string
+			for _, node := range nodes {
+				n, err := f.kubeclient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				if n.Annotations[daemonconsts.RebootRequestedAnnotationKey] == "v1" {
+					requested = append(requested, n.Name)
+				}
+			}
+			assert.Equal(t, test.requested, requested)
+			assert.Len(t, recorder.Events, len(test.requested))
+		})
+	}
+}
+
+func TestRebootPendingNodesRespectsMaxUnavailable(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v1", "v1"),
+	}
+	for _, node := range nodes {
+		addNodeAnnotations(node, map[string]string{daemonconsts.MachineConfigDaemonPendingRebootAnnotationKey: "v1"})
+	}
+	addNodeAnnotations(nodes[0], map[string]string{daemonconsts.RebootRequestedAnnotationKey: "v1"})
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.MaintenanceWindows = []mcfgv1.MaintenanceWindow{{StartTime: "00:00", EndTime: "00:00"}}
+
+	f := newFixture(t)
+	f.kubeobjects = []runtime.Object{nodes[0], nodes[1]}
+	ctrl := f.newController()
+
+	// node-0 is still rebooting.
+	require.NoError(t, ctrl.rebootPendingNodes(pool, nodes, 1, time.Now()))
+	for _, action := range f.kubeclient.Actions() {
+		assert.NotEqual(t, "patch", action.GetVerb())
+	}
+}
@@ -0,0 +1,142 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// machineAnnotationKey is set by the Machine API on nodes to the namespace/name of their Machine.
+	machineAnnotationKey = "machine.openshift.io/machine"
+	// controlPlaneLabel is set on control plane nodes, along with or instead of ctrlcommon.MasterLabel.
+	controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+)
+
+// machineResource is the Machine API resource the nodes' Machines are deleted through.
+var machineResource = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machines"}
+
+// MachineDeleter deletes the Machines of nodes flagged for reprovisioning so they get replaced.
+type MachineDeleter interface {
+	// DeleteMachine deletes the Machine namespace/name. It returns false if the Machine is
+	// already gone or being deleted.
+	DeleteMachine(ctx context.Context, namespace, name string) (bool, error)
+}
+
+// machineAPIDeleter deletes Machine API Machines through a dynamic client, so that the
+// MachineSet owning them creates replacements.
+type machineAPIDeleter struct {
+	client dynamic.Interface
+}
+
+// NewMachineAPIDeleter returns a MachineDeleter deleting Machine API Machines with client.
+func NewMachineAPIDeleter(client dynamic.Interface) MachineDeleter {
+	return &machineAPIDeleter{client: client}
+}
+
+func (d *machineAPIDeleter) DeleteMachine(ctx context.Context, namespace, name string) (bool, error) {
+	machines := d.client.Resource(machineResource).Namespace(namespace)
+	machine, err := machines.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if machine.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+	err = machines.Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetMachineDeleter configures how the controller replaces nodes flagged for reprovisioning
+// by pools with the DeleteMachine reprovision action. Without a deleter such nodes are only
+// reported in the pool's status.
+func (ctrl *Controller) SetMachineDeleter(deleter MachineDeleter) {
+	ctrl.machineDeleter = deleter
+}
+
+// getReprovisioningNodes returns the nodes flagged for reprovisioning with the config they are
+// targeted with that haven't been replaced yet.
+func getReprovisioningNodes(nodes []*corev1.Node) []*corev1.Node {
+	var reprovisioning []*corev1.Node
+	for _, node := range nodes {
+		flagged := node.Annotations[daemonconsts.ReprovisionAnnotationKey]
+		if flagged == "" || flagged != node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] {
+			continue
+		}
+		if flagged == node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+			continue
+		}
+		reprovisioning = append(reprovisioning, node)
+	}
+	return reprovisioning
+}
+
+// getReprovisioningNodeNames returns the sorted names of the nodes returned by getReprovisioningNodes.
+func getReprovisioningNodeNames(nodes []*corev1.Node) []string {
+	var names []string
+	for _, node := range getReprovisioningNodes(nodes) {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reprovisionNodes deletes the Machines of the pool's nodes flagged for reprovisioning, if the
+// pool's reprovision policy replaces nodes by deleting their Machine.
+func (ctrl *Controller) reprovisionNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) {
+	if pool.Spec.ReprovisionPolicy == nil || pool.Spec.ReprovisionPolicy.Action != mcfgv1.ReprovisionActionDeleteMachine {
+		return
+	}
+	for _, node := range getReprovisioningNodes(nodes) {
+		// Control plane Machines aren't owned by a MachineSet and deleting them removes etcd members.
+		if isControlPlaneNode(node) {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ReprovisionSkipped", "Not deleting the Machine of control plane node %s to reprovision it, the node must be replaced manually", node.Name)
+			continue
+		}
+		if ctrl.machineDeleter == nil {
+			glog.Warningf("Pool %s: node %s is flagged for reprovisioning but no Machine API client is configured", pool.Name, node.Name)
+			continue
+		}
+		machine := node.Annotations[machineAnnotationKey]
+		namespace, name, err := cache.SplitMetaNamespaceKey(machine)
+		if machine == "" || err != nil || namespace == "" {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ReprovisionFailed", "Node %s is flagged for reprovisioning but has no valid %s annotation: %q", node.Name, machineAnnotationKey, machine)
+			continue
+		}
+		deleted, err := ctrl.machineDeleter.DeleteMachine(context.TODO(), namespace, name)
+		if err != nil {
+			err = fmt.Errorf("failed deleting Machine %s to reprovision node %s: %w", machine, node.Name, err)
+			glog.Error(err)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ReprovisionFailed", err.Error())
+			continue
+		}
+		if deleted {
+			ctrl.logPoolNode(pool, node, "Deleted Machine %s to reprovision node with %s", machine, node.Annotations[daemonconsts.ReprovisionAnnotationKey])
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "Reprovision", "Deleted Machine %s to reprovision node %s", machine, node.Name)
+		}
+	}
+}
+
+// isControlPlaneNode returns true if node is part of the control plane.
+func isControlPlaneNode(node *corev1.Node) bool {
+	_, master := node.Labels[ctrlcommon.MasterLabel]
+	_, controlPlane := node.Labels[controlPlaneLabel]
+	return master || controlPlane
+}
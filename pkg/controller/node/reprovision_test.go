@@ -0,0 +1,116 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+)
+
+type fakeMachineDeleter struct {
+	deleted []string
+}
+
+func (d *fakeMachineDeleter) DeleteMachine(ctx context.Context, namespace, name string) (bool, error) {
+	d.deleted = append(d.deleted, namespace+"/"+name)
+	return true, nil
+}
+
+func TestGetReprovisioningNodeNames(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-2", "v0", "v1"),
+		newNode("node-1", "v0", "v1"),
+		// Flagged for a config the node is no longer targeted with.
+		newNode("node-3", "v0", "v2"),
+		// Replaced in place, e.g. by a hook reinstalling the node.
+		newNode("node-4", "v1", "v1"),
+		newNode("node-5", "v0", "v1"),
+	}
+	for _, node := range nodes[:4] {
+		addNodeAnnotations(node, map[string]string{daemonconsts.ReprovisionAnnotationKey: "v1"})
+	}
+	assert.Equal(t, []string{"node-1", "node-2"}, getReprovisioningNodeNames(nodes))
+
+	status := calculateStatus(helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1"), nodes)
+	assert.Equal(t, int32(2), status.ReprovisioningMachineCount)
+	assert.Equal(t, []string{"node-1", "node-2"}, status.ReprovisioningNodes)
+}
+
+func TestReprovisionNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-0", "v0", "v1"),
+		newNode("node-1", "v0", "v1"),
+		newNode("node-2", "v0", "v1"),
+		newNodeWithLabel("master-0", "v0", "v1", map[string]string{controlPlaneLabel: ""}),
+	}
+	for i, machine := range map[int]string{0: "openshift-machine-api/worker-a", 1: "", 3: "openshift-machine-api/master-0"} {
+		addNodeAnnotations(nodes[i], map[string]string{daemonconsts.ReprovisionAnnotationKey: "v1", machineAnnotationKey: machine})
+	}
+
+	for action, expected := range map[mcfgv1.ReprovisionAction][]string{
+		mcfgv1.ReprovisionActionDeleteMachine: {"openshift-machine-api/worker-a"},
+		mcfgv1.ReprovisionActionHook:          nil,
+	} {
+		pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+		pool.Spec.ReprovisionPolicy = &mcfgv1.ReprovisionPolicy{Action: action}
+		deleter := &fakeMachineDeleter{}
+		recorder := record.NewFakeRecorder(10)
+		ctrl := &Controller{eventRecorder: recorder, machineDeleter: deleter}
+
+		ctrl.reprovisionNodes(pool, nodes)
+		assert.Equal(t, expected, deleter.deleted, action)
+		if action == mcfgv1.ReprovisionActionDeleteMachine {
+			require.Len(t, recorder.Events, 3)
+			assert.Contains(t, <-recorder.Events, "Deleted Machine openshift-machine-api/worker-a to reprovision node node-0")
+			assert.Contains(t, <-recorder.Events, "Node node-1 is flagged for reprovisioning but has no valid machine.openshift.io/machine annotation")
+			assert.Contains(t, <-recorder.Events, "Not deleting the Machine of control plane node master-0 to reprovision it")
+		}
+	}
+}
+
+func TestMachineAPIDeleter(t *testing.T) {
+	deleting := map[string]bool{"worker-a": false, "worker-b": true}
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/apis/machine.openshift.io/v1beta1/namespaces/openshift-machine-api/machines/")
+		isDeleting, ok := deleting[name]
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": http.StatusNotFound})
+			return
+		}
+		machine := map[string]interface{}{"kind": "Machine", "apiVersion": "machine.openshift.io/v1beta1", "metadata": map[string]interface{}{"name": name, "namespace": "openshift-machine-api"}}
+		if isDeleting {
+			machine["metadata"].(map[string]interface{})["deletionTimestamp"] = "2021-03-01T12:00:00Z"
+		}
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, name)
+		}
+		json.NewEncoder(w).Encode(machine)
+	}))
+	defer server.Close()
+
+	client, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	deleter := NewMachineAPIDeleter(client)
+
+	for name, expected := range map[string]bool{"worker-a": true, "worker-b": false, "worker-c": false} {
+		ok, err := deleter.DeleteMachine(context.TODO(), "openshift-machine-api", name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, ok, name)
+	}
+	assert.Equal(t, []string{"worker-a"}, deleted)
+}
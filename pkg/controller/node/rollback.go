@@ -0,0 +1,147 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// rolledBackDegradedMachinesReason is the condition reason for rollbacks triggered by the degraded machine threshold.
+	rolledBackDegradedMachinesReason = "DegradedMachines"
+	// rolledBackDegradedTimeoutReason is the condition reason for rollbacks triggered by the degraded timeout.
+	rolledBackDegradedTimeoutReason = "DegradedTimeout"
+)
+
+// reconcileRollback applies the pool's rollback policy and returns the pool nodes should be targeted
+// with: the pool itself, or while it is rolled back a copy of it targeting the last configuration
+// that was fully rolled out. The rollback state is recorded in pool's status.
+func (ctrl *Controller) reconcileRollback(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, now time.Time) *mcfgv1.MachineConfigPool {
+	targetConfig := pool.Spec.Configuration.Name
+	previousConfig := pool.Status.Configuration.Name
+
+	if from := pool.Status.RolledBackFrom; from != "" && (from != targetConfig || pool.Spec.RollbackPolicy == nil) {
+		ctrl.logPool(pool, "Ending rollback from %s, now targeting %s", from, targetConfig)
+		pool.Status.RolledBackFrom = ""
+		setRolledBackCondition(pool, corev1.ConditionFalse, "", "")
+	}
+
+	if pool.Status.RolledBackFrom == "" {
+		if pool.Spec.RollbackPolicy == nil || previousConfig == "" || previousConfig == targetConfig {
+			setRolledBackCondition(pool, corev1.ConditionFalse, "", "")
+			return pool
+		}
+		reason, message, wait := shouldRollback(pool, nodes, now)
+		if reason == "" {
+			if wait > 0 {
+				ctrl.enqueueAfter(pool, wait)
+			}
+			setRolledBackCondition(pool, corev1.ConditionFalse, "", "")
+			return pool
+		}
+		if changed := ctrl.getChangedMachineConfigs(pool); len(changed) > 0 {
+			message = fmt.Sprintf("%s; MachineConfigs added or changed: %s", message, strings.Join(changed, ", "))
+		}
+		ctrl.logPool(pool, "Rolling back from %s to %s: %s", targetConfig, previousConfig, message)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "RolledBack", "Rolling back from %s to %s: %s", targetConfig, previousConfig, message)
+		pool.Status.RolledBackFrom = targetConfig
+		setRolledBackCondition(pool, corev1.ConditionTrue, reason, fmt.Sprintf("Rolled back from %s to %s: %s", targetConfig, previousConfig, message))
+	}
+
+	rolledBack := pool.DeepCopy()
+	rolledBack.Spec.Configuration = pool.Status.Configuration
+	return rolledBack
+}
+
+// getChangedMachineConfigs returns the MachineConfigs of pool's targeted configuration that were added
+// since its previous configuration, or changed after the latter was rendered.
+func (ctrl *Controller) getChangedMachineConfigs(pool *mcfgv1.MachineConfigPool) []string {
+	previous := sets.NewString()
+	for _, ref := range pool.Status.Configuration.Source {
+		previous.Insert(ref.Name)
+	}
+	var renderedAt time.Time
+	if rendered, err := ctrl.mcLister.Get(pool.Status.Configuration.Name); err == nil {
+		renderedAt = rendered.CreationTimestamp.Time
+	}
+
+	var changed []string
+	for _, ref := range pool.Spec.Configuration.Source {
+		if !previous.Has(ref.Name) {
+			changed = append(changed, ref.Name)
+			continue
+		}
+		if renderedAt.IsZero() {
+			continue
+		}
+		if mc, err := ctrl.mcLister.Get(ref.Name); err == nil && lastModified(mc).After(renderedAt) {
+			changed = append(changed, ref.Name)
+		}
+	}
+	return changed
+}
+
+// lastModified returns when mc was last written according to its managed fields, or its creation time.
+func lastModified(mc *mcfgv1.MachineConfig) time.Time {
+	modified := mc.CreationTimestamp.Time
+	for _, entry := range mc.ManagedFields {
+		if entry.Time != nil && entry.Time.After(modified) {
+			modified = entry.Time.Time
+		}
+	}
+	return modified
+}
+
+// shouldRollback returns the reason and a message if the pool's rollback policy calls for rolling it
+// back. Otherwise it returns how long until the degraded timeout expires, if it is running.
+func shouldRollback(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, now time.Time) (string, string, time.Duration) {
+	policy := pool.Spec.RollbackPolicy
+	targetConfig := pool.Spec.Configuration.Name
+
+	var degraded []string
+	for _, node := range getDegradedMachines(nodes) {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
+			degraded = append(degraded, node.Name)
+		}
+	}
+	if len(degraded) == 0 {
+		return "", "", 0
+	}
+	message := fmt.Sprintf("%s caused %d nodes to degrade: %s", targetConfig, len(degraded), strings.Join(degraded, ", "))
+
+	threshold := int(policy.DegradedMachineThreshold)
+	if threshold == 0 && policy.DegradedTimeout == nil {
+		threshold = 1
+	}
+	if threshold > 0 && len(degraded) >= threshold {
+		return rolledBackDegradedMachinesReason, message, 0
+	}
+
+	if policy.DegradedTimeout == nil {
+		return "", "", 0
+	}
+	since := now
+	if cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolNodeDegraded); cond != nil && cond.Status == corev1.ConditionTrue {
+		since = cond.LastTransitionTime.Time
+	}
+	deadline := since.Add(policy.DegradedTimeout.Duration)
+	if !now.Before(deadline) {
+		return rolledBackDegradedTimeoutReason, fmt.Sprintf("%s for more than %s", message, policy.DegradedTimeout.Duration), 0
+	}
+	return "", "", deadline.Sub(now)
+}
+
+// setRolledBackCondition sets the pool's RolledBack condition. The condition is only added to pools
+// which have a rollback policy or had the condition before.
+func setRolledBackCondition(pool *mcfgv1.MachineConfigPool, status corev1.ConditionStatus, reason, message string) {
+	if pool.Spec.RollbackPolicy == nil && mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRolledBack) == nil {
+		return
+	}
+	condition := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolledBack, status, reason, message)
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *condition)
+}
@@ -0,0 +1,172 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestShouldRollback(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	done := daemonconsts.MachineConfigDaemonStateDone
+	degraded := daemonconsts.MachineConfigDaemonStateDegraded
+	unreconcilable := daemonconsts.MachineConfigDaemonStateUnreconcilable
+
+	tests := []struct {
+		name        string
+		policy      mcfgv1.RollbackPolicy
+		degradedFor time.Duration
+		nodes       []*corev1.Node
+		reason      string
+		message     string
+		wait        time.Duration
+	}{{
+		name:   "no degraded nodes",
+		policy: mcfgv1.RollbackPolicy{},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, done),
+			newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, done),
+		},
+	}, {
+		name:   "nodes degraded on another config are ignored",
+		policy: mcfgv1.RollbackPolicy{},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v2", corev1.ConditionTrue, degraded),
+		},
+	}, {
+		name:   "one degraded node by default",
+		policy: mcfgv1.RollbackPolicy{},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+			newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, done),
+		},
+		reason:  rolledBackDegradedMachinesReason,
+		message: "v1 caused 1 nodes to degrade: node-0",
+	}, {
+		name:   "below the threshold",
+		policy: mcfgv1.RollbackPolicy{DegradedMachineThreshold: 2},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+			newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, done),
+		},
+	}, {
+		name:   "at the threshold",
+		policy: mcfgv1.RollbackPolicy{DegradedMachineThreshold: 2},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+			newNodeWithReadyAndDaemonState("node-1", "v0", "v1", corev1.ConditionTrue, unreconcilable),
+		},
+		reason:  rolledBackDegradedMachinesReason,
+		message: "v1 caused 2 nodes to degrade: node-0, node-1",
+	}, {
+		name:        "degraded timeout running",
+		policy:      mcfgv1.RollbackPolicy{DegradedTimeout: &metav1.Duration{Duration: time.Hour}},
+		degradedFor: 20 * time.Minute,
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+		},
+		wait: 40 * time.Minute,
+	}, {
+		name:   "degraded timeout starts with the pool degraded",
+		policy: mcfgv1.RollbackPolicy{DegradedTimeout: &metav1.Duration{Duration: time.Hour}},
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+		},
+		wait: time.Hour,
+	}, {
+		name:        "degraded timeout expired",
+		policy:      mcfgv1.RollbackPolicy{DegradedTimeout: &metav1.Duration{Duration: time.Hour}},
+		degradedFor: 2 * time.Hour,
+		nodes: []*corev1.Node{
+			newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, degraded),
+		},
+		reason:  rolledBackDegradedTimeoutReason,
+		message: "v1 caused 1 nodes to degrade: node-0 for more than 1h0m0s",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.RollbackPolicy = &test.policy
+			if test.degradedFor != 0 {
+				cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolNodeDegraded, corev1.ConditionTrue, "", "")
+				cond.LastTransitionTime = metav1.NewTime(now.Add(-test.degradedFor))
+				mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *cond)
+			}
+
+			reason, message, wait := shouldRollback(pool, test.nodes, now)
+			assert.Equal(t, test.reason, reason)
+			assert.Equal(t, test.message, message)
+			assert.Equal(t, test.wait, wait)
+		})
+	}
+}
+
+func TestReconcileRollback(t *testing.T) {
+	now := time.Now()
+	nodes := []*corev1.Node{
+		newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded),
+		newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+	}
+
+	// v1 adds 99-new and picks up changes to 99-changed made after v0 was rendered.
+	rendered := helpers.NewMachineConfig("v0", nil, "", nil)
+	rendered.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	base := helpers.NewMachineConfig("00-base", nil, "", nil)
+	base.CreationTimestamp = metav1.NewTime(now.Add(-3 * time.Hour))
+	changed := helpers.NewMachineConfig("99-changed", nil, "", nil)
+	changed.CreationTimestamp = metav1.NewTime(now.Add(-3 * time.Hour))
+	changed.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "oc", Time: &metav1.Time{Time: now.Add(-time.Hour)}}}
+
+	f := newFixture(t)
+	f.mcLister = []*mcfgv1.MachineConfig{rendered, base, changed}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	// Without a policy the pool keeps targeting v1
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Status.Configuration.Name = "v0"
+	for _, name := range []string{"00-base", "99-changed", "99-new"} {
+		pool.Spec.Configuration.Source = append(pool.Spec.Configuration.Source, corev1.ObjectReference{Kind: "MachineConfig", Name: name})
+	}
+	pool.Status.Configuration.Source = pool.Spec.Configuration.Source[:2]
+	assert.Same(t, pool, c.reconcileRollback(pool, nodes, now))
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRolledBack))
+
+	// The degraded node rolls the pool back to v0
+	pool.Spec.RollbackPolicy = &mcfgv1.RollbackPolicy{}
+	target := c.reconcileRollback(pool, nodes, now)
+	assert.Equal(t, "v0", target.Spec.Configuration.Name)
+	assert.Equal(t, "v1", pool.Spec.Configuration.Name)
+	assert.Equal(t, "v1", pool.Status.RolledBackFrom)
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRolledBack)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, rolledBackDegradedMachinesReason, cond.Reason)
+	assert.Equal(t, "Rolled back from v1 to v0: v1 caused 1 nodes to degrade: node-0; MachineConfigs added or changed: 99-changed, 99-new", cond.Message)
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "MachineConfigs added or changed: 99-changed, 99-new")
+
+	// Once nodes recover the pool stays rolled back
+	recovered := []*corev1.Node{
+		newNodeWithReadyAndDaemonState("node-0", "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+		newNodeWithReadyAndDaemonState("node-1", "v0", "v0", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDone),
+	}
+	target = c.reconcileRollback(pool, recovered, now)
+	assert.Equal(t, "v0", target.Spec.Configuration.Name)
+	assert.Empty(t, recorder.Events)
+
+	// A new configuration ends the rollback
+	pool.Spec.Configuration.Name = "v2"
+	assert.Same(t, pool, c.reconcileRollback(pool, recovered, now))
+	assert.Equal(t, "", pool.Status.RolledBackFrom)
+	cond = mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRolledBack)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
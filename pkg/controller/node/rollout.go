@@ -0,0 +1,188 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// rolloutPausedReason is the event reason for a rollout paused by a degraded canary or the lack of canaries.
+const rolloutPausedReason = "RolloutPaused"
+
+// applyRolloutStrategy restricts candidates to the nodes the pool's rollout strategy allows to start
+// updating now and returns the capacity left for them. The rollout's progress is recorded in
+// pool.Status.Rollout. Pools without a rollout strategy are returned unchanged.
+func (ctrl *Controller) applyRolloutStrategy(pool *mcfgv1.MachineConfigPool, nodes, candidates []*corev1.Node, capacity uint, now time.Time) ([]*corev1.Node, uint, error) {
+	strategy := pool.Spec.RolloutStrategy
+	if strategy == nil {
+		pool.Status.Rollout = nil
+		return candidates, capacity, nil
+	}
+
+	targetConfig := pool.Spec.Configuration.Name
+	rollout := pool.Status.Rollout
+	// Canaries are selected again while none were found, so that the rollout starts once the
+	// canary node selector matches a node.
+	if rollout == nil || rollout.Configuration != targetConfig || len(rollout.CanaryNodes) == 0 {
+		canaries, err := selectCanaryNodes(&strategy.Canary, nodes, targetConfig)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Without canaries the rest of the pool would be updated unvetted.
+		if len(canaries) == 0 && len(nodes) > 0 {
+			if rollout == nil || rollout.Configuration != targetConfig || rollout.Phase != mcfgv1.RolloutPhasePaused {
+				ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, rolloutPausedReason, "Paused rollout of %s: the canary node selector matches none of the pool's nodes", targetConfig)
+			}
+			pool.Status.Rollout = &mcfgv1.RolloutStatus{
+				Configuration: targetConfig,
+				Phase:         mcfgv1.RolloutPhasePaused,
+				Message:       "The canary node selector matches none of the pool's nodes",
+			}
+			return nil, 0, nil
+		}
+		rollout = &mcfgv1.RolloutStatus{
+			Configuration: targetConfig,
+			Phase:         mcfgv1.RolloutPhaseCanary,
+			CanaryNodes:   canaries,
+		}
+		pool.Status.Rollout = rollout
+	}
+
+	if len(getUpdatedMachines(targetConfig, nodes)) == len(nodes) {
+		rollout.Phase = mcfgv1.RolloutPhaseCompleted
+		rollout.Step = 0
+		rollout.Message = fmt.Sprintf("All nodes are updated to %s", targetConfig)
+		return candidates, capacity, nil
+	}
+
+	canaryNames := sets.NewString(rollout.CanaryNodes...)
+	var canaries []*corev1.Node
+	for _, node := range nodes {
+		if canaryNames.Has(node.Name) {
+			canaries = append(canaries, node)
+		}
+	}
+
+	// Degraded canaries pause the rollout; it resumes once none of them is degraded.
+	for _, degraded := range getDegradedMachines(canaries) {
+		if degraded.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != targetConfig {
+			continue
+		}
+		if rollout.Phase != mcfgv1.RolloutPhasePaused {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, rolloutPausedReason, "Paused rollout of %s: canary node %s is degraded", targetConfig, degraded.Name)
+		}
+		rollout.Phase = mcfgv1.RolloutPhasePaused
+		rollout.Step = 0
+		rollout.SoakStartTime = nil
+		rollout.Message = fmt.Sprintf("Canary node %s is degraded", degraded.Name)
+		return nil, 0, nil
+	}
+
+	readyCanaries := getReadyMachines(targetConfig, canaries)
+	if len(readyCanaries) < len(canaries) {
+		rollout.Phase = mcfgv1.RolloutPhaseCanary
+		rollout.Step = 0
+		rollout.SoakStartTime = nil
+		rollout.Message = fmt.Sprintf("%d of %d canary nodes are updated and ready", len(readyCanaries), len(canaries))
+		var canaryCandidates []*corev1.Node
+		for _, node := range candidates {
+			if canaryNames.Has(node.Name) {
+				canaryCandidates = append(canaryCandidates, node)
+			}
+		}
+		return canaryCandidates, capacity, nil
+	}
+
+	if rollout.SoakStartTime == nil {
+		rollout.SoakStartTime = &metav1.Time{Time: now}
+	}
+	soakEnd := rollout.SoakStartTime.Add(strategy.Canary.SoakDuration.Duration)
+	if now.Before(soakEnd) {
+		rollout.Phase = mcfgv1.RolloutPhaseSoaking
+		rollout.Step = 0
+		rollout.Message = fmt.Sprintf("Canary nodes are soaking until %s", soakEnd.UTC().Format(time.RFC3339))
+		ctrl.enqueueAfter(pool, soakEnd.Sub(now))
+		return nil, 0, nil
+	}
+
+	step, limit := currentRolloutStep(strategy.Steps, len(nodes), len(getReadyMachines(targetConfig, nodes)))
+	rollout.Phase = mcfgv1.RolloutPhaseProgressing
+	rollout.Step = int32(step)
+	rollout.Message = fmt.Sprintf("Updating up to %d of %d nodes", limit, len(nodes))
+
+	targeted := 0
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
+			targeted++
+		}
+	}
+	if targeted >= limit {
+		return nil, 0, nil
+	}
+	if room := uint(limit - targeted); room < capacity {
+		capacity = room
+	}
+	return candidates, capacity, nil
+}
+
+// selectCanaryNodes returns the names of the canary nodes for a rollout to targetConfig. Without a
+// node selector, nodes already targeting targetConfig are picked first, then nodes in the
+// order they would otherwise be updated in.
+func selectCanaryNodes(canary *mcfgv1.CanaryRollout, nodes []*corev1.Node, targetConfig string) ([]string, error) {
+	var canaries []string
+	if canary.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(canary.NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid canary node selector: %w", err)
+		}
+		for _, node := range nodes {
+			if selector.Matches(labels.Set(node.Labels)) {
+				canaries = append(canaries, node.Name)
+			}
+		}
+		sort.Strings(canaries)
+		return canaries, nil
+	}
+
+	count := int(canary.Count)
+	if count == 0 {
+		count = 1
+	}
+	var rest []*corev1.Node
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
+			canaries = append(canaries, node.Name)
+		} else {
+			rest = append(rest, node)
+		}
+	}
+	sort.Strings(canaries)
+	for _, node := range sortNodeList(rest) {
+		canaries = append(canaries, node.Name)
+	}
+	if len(canaries) > count {
+		canaries = canaries[:count]
+	}
+	return canaries, nil
+}
+
+// currentRolloutStep returns the index, starting at 1, of the step a rollout is at given the
+// number of nodes already updated and ready, and how many nodes that step allows to be updated.
+// Steps are cumulative percentages of the pool; a final 100% step is implied.
+func currentRolloutStep(steps []int32, total, ready int) (int, int) {
+	for i, percent := range steps {
+		// Round up so that small pools still make progress on small steps.
+		limit := (int(percent)*total + 99) / 100
+		if ready < limit {
+			return i + 1, limit
+		}
+	}
+	return len(steps) + 1, total
+}
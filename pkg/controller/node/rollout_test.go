@@ -0,0 +1,276 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newRolloutNode(name string, age time.Duration, currentConfig, desiredConfig, dstate string) *corev1.Node {
+	node := newNodeWithReadyAndDaemonState(name, currentConfig, desiredConfig, corev1.ConditionTrue, dstate)
+	node.CreationTimestamp = metav1.NewTime(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-age))
+	return node
+}
+
+func TestApplyRolloutStrategy(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	done := daemonconsts.MachineConfigDaemonStateDone
+	degraded := daemonconsts.MachineConfigDaemonStateDegraded
+	strategy := &mcfgv1.RolloutStrategy{
+		Canary: mcfgv1.CanaryRollout{SoakDuration: metav1.Duration{Duration: time.Hour}},
+		Steps:  []int32{50},
+	}
+	selectorStrategy := &mcfgv1.RolloutStrategy{
+		Canary: mcfgv1.CanaryRollout{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"canary": ""}}},
+	}
+
+	tests := []struct {
+		name       string
+		strategy   *mcfgv1.RolloutStrategy
+		rollout    *mcfgv1.RolloutStatus
+		nodes      []*corev1.Node
+		expected   *mcfgv1.RolloutStatus
+		candidates []string
+		capacity   uint
+		paused     bool
+	}{{
+		name: "no rollout strategy",
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v0", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+		},
+		candidates: []string{"node-0", "node-1"},
+		capacity:   2,
+	}, {
+		name:     "rollout starts with the oldest node as canary",
+		strategy: strategy,
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v0", done),
+			newRolloutNode("node-1", time.Hour, "v0", "v0", done),
+			newRolloutNode("node-2", 0, "v0", "v0", done),
+			newRolloutNode("node-3", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseCanary,
+			CanaryNodes:   []string{"node-1"},
+			Message:       "0 of 1 canary nodes are updated and ready",
+		},
+		candidates: []string{"node-1"},
+		capacity:   2,
+	}, {
+		name:     "canaries selected by label",
+		strategy: selectorStrategy,
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v0", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+			newNodeWithLabel("node-2", "v0", "v0", map[string]string{"canary": ""}),
+			newNodeWithLabel("node-3", "v0", "v0", map[string]string{"canary": ""}),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseCanary,
+			CanaryNodes:   []string{"node-2", "node-3"},
+			Message:       "0 of 2 canary nodes are updated and ready",
+		},
+		candidates: []string{"node-2", "node-3"},
+		capacity:   2,
+	}, {
+		name:     "updated canaries start soaking",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhaseCanary, CanaryNodes: []string{"node-0"}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v1", "v1", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+			newRolloutNode("node-2", 0, "v0", "v0", done),
+			newRolloutNode("node-3", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseSoaking,
+			CanaryNodes:   []string{"node-0"},
+			SoakStartTime: &metav1.Time{Time: now},
+			Message:       "Canary nodes are soaking until 2021-03-01T13:00:00Z",
+		},
+	}, {
+		name:     "soaked canaries continue to the first step",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhaseSoaking, CanaryNodes: []string{"node-0"}, SoakStartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v1", "v1", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+			newRolloutNode("node-2", 0, "v0", "v0", done),
+			newRolloutNode("node-3", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseProgressing,
+			Step:          1,
+			CanaryNodes:   []string{"node-0"},
+			SoakStartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)},
+			Message:       "Updating up to 2 of 4 nodes",
+		},
+		candidates: []string{"node-1", "node-2", "node-3"},
+		capacity:   1,
+	}, {
+		name:     "completed step moves to the next one",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhaseProgressing, Step: 1, CanaryNodes: []string{"node-0"}, SoakStartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v1", "v1", done),
+			newRolloutNode("node-1", 0, "v1", "v1", done),
+			newRolloutNode("node-2", 0, "v0", "v0", done),
+			newRolloutNode("node-3", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseProgressing,
+			Step:          2,
+			CanaryNodes:   []string{"node-0"},
+			SoakStartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)},
+			Message:       "Updating up to 4 of 4 nodes",
+		},
+		candidates: []string{"node-2", "node-3"},
+		capacity:   2,
+	}, {
+		name:     "degraded canary pauses the rollout",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhaseProgressing, Step: 1, CanaryNodes: []string{"node-0"}, SoakStartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v1", "v1", degraded),
+			newRolloutNode("node-1", 0, "v0", "v1", done),
+			newRolloutNode("node-2", 0, "v0", "v0", done),
+			newRolloutNode("node-3", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhasePaused,
+			CanaryNodes:   []string{"node-0"},
+			Message:       "Canary node node-0 is degraded",
+		},
+		paused: true,
+	}, {
+		name:     "canary node selector matching no nodes pauses the rollout",
+		strategy: selectorStrategy,
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v0", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhasePaused,
+			Message:       "The canary node selector matches none of the pool's nodes",
+		},
+		paused: true,
+	}, {
+		name:     "canary node selector matching a node resumes the rollout",
+		strategy: selectorStrategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhasePaused},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v0", done),
+			newNodeWithLabel("node-1", "v0", "v0", map[string]string{"canary": ""}),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseCanary,
+			CanaryNodes:   []string{"node-1"},
+			Message:       "0 of 1 canary nodes are updated and ready",
+		},
+		candidates: []string{"node-1"},
+		capacity:   2,
+	}, {
+		name:     "new configuration restarts the rollout",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v0", Phase: mcfgv1.RolloutPhaseCompleted, CanaryNodes: []string{"node-1"}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v0", "v1", done),
+			newRolloutNode("node-1", 0, "v0", "v0", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseCanary,
+			CanaryNodes:   []string{"node-0"},
+			Message:       "0 of 1 canary nodes are updated and ready",
+		},
+		capacity: 2,
+	}, {
+		name:     "all nodes updated",
+		strategy: strategy,
+		rollout:  &mcfgv1.RolloutStatus{Configuration: "v1", Phase: mcfgv1.RolloutPhaseProgressing, Step: 2, CanaryNodes: []string{"node-0"}},
+		nodes: []*corev1.Node{
+			newRolloutNode("node-0", 0, "v1", "v1", done),
+			newRolloutNode("node-1", 0, "v1", "v1", done),
+		},
+		expected: &mcfgv1.RolloutStatus{
+			Configuration: "v1",
+			Phase:         mcfgv1.RolloutPhaseCompleted,
+			CanaryNodes:   []string{"node-0"},
+			Message:       "All nodes are updated to v1",
+		},
+		capacity: 2,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			c := f.newController()
+			recorder := record.NewFakeRecorder(10)
+			c.eventRecorder = recorder
+
+			pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+			pool.Spec.RolloutStrategy = test.strategy
+			pool.Status.Rollout = test.rollout
+			var candidates []*corev1.Node
+			for _, node := range test.nodes {
+				if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "v1" {
+					candidates = append(candidates, node)
+				}
+			}
+
+			filtered, capacity, err := c.applyRolloutStrategy(pool, test.nodes, candidates, 2, now)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, pool.Status.Rollout)
+			assert.Equal(t, test.capacity, capacity)
+			var names []string
+			for _, node := range filtered {
+				names = append(names, node.Name)
+			}
+			assert.Equal(t, test.candidates, names)
+			if test.paused {
+				assert.Len(t, recorder.Events, 1)
+			} else {
+				assert.Len(t, recorder.Events, 0)
+			}
+		})
+	}
+}
+
+func TestCurrentRolloutStep(t *testing.T) {
+	tests := []struct {
+		steps []int32
+		total int
+		ready int
+		step  int
+		limit int
+	}{
+		{steps: nil, total: 5, ready: 1, step: 1, limit: 5},
+		{steps: []int32{25, 50}, total: 5, ready: 1, step: 1, limit: 2},
+		{steps: []int32{25, 50}, total: 5, ready: 2, step: 2, limit: 3},
+		{steps: []int32{25, 50}, total: 5, ready: 3, step: 3, limit: 5},
+		{steps: []int32{10}, total: 3, ready: 0, step: 1, limit: 1},
+	}
+
+	for _, test := range tests {
+		step, limit := currentRolloutStep(test.steps, test.total, test.ready)
+		assert.Equal(t, test.step, step, "steps %v with %d of %d nodes ready", test.steps, test.ready, test.total)
+		assert.Equal(t, test.limit, limit, "steps %v with %d of %d nodes ready", test.steps, test.ready, test.total)
+	}
+}
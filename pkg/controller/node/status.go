@@ -68,6 +68,10 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		DegradedMachineCount:    degradedMachineCount,
 	}
 	status.Configuration = pool.Status.Configuration
+	status.Rollout = pool.Status.Rollout
+	status.RolledBackFrom = pool.Status.RolledBackFrom
+	status.ReprovisioningNodes = getReprovisioningNodeNames(nodes)
+	status.ReprovisioningMachineCount = int32(len(status.ReprovisioningNodes))
 
 	conditions := pool.Status.Conditions
 	for i := range conditions {
@@ -96,6 +100,9 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		if pool.Spec.Paused {
 			supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionFalse, "", fmt.Sprintf("Pool is paused; will not update to %s", pool.Spec.Configuration.Name))
 			mcfgv1.SetMachineConfigPoolCondition(&status, *supdating)
+		} else if pool.Status.RolledBackFrom != "" {
+			supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionFalse, "", fmt.Sprintf("Pool is rolled back to %s; will not update to %s", pool.Status.Configuration.Name, pool.Spec.Configuration.Name))
+			mcfgv1.SetMachineConfigPoolCondition(&status, *supdating)
 		} else {
 			supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionTrue, "", fmt.Sprintf("All nodes are updating to %s", pool.Spec.Configuration.Name))
 			mcfgv1.SetMachineConfigPoolCondition(&status, *supdating)
@@ -249,6 +256,10 @@ func isNodeUnavailable(node *corev1.Node) bool {
 	if !isNodeReady(node) {
 		return true
 	}
+	// Nodes asked to reboot for staged kernel arguments are unavailable until they did
+	if isRebootRequested(node) {
+		return true
+	}
 	// Ready nodes are not unavailable
 	if isNodeDone(node) {
 		return false
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
@@ -22,9 +25,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -67,6 +73,9 @@ type Controller struct {
 	ccLister       mcfglistersv1.ControllerConfigLister
 	ccListerSynced cache.InformerSynced
 
+	nodeLister       corelisterv1.NodeLister
+	nodeListerSynced cache.InformerSynced
+
 	queue workqueue.RateLimitingInterface
 }
 
@@ -75,6 +84,7 @@ func New(
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	ccInformer mcfginformersv1.ControllerConfigInformer,
+	nodeInformer coreinformersv1.NodeInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 ) *Controller {
@@ -108,6 +118,8 @@ func New(
 	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
 	ctrl.ccLister = ccInformer.Lister()
 	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
+	ctrl.nodeLister = nodeInformer.Lister()
+	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
 
 	return ctrl
 }
@@ -117,7 +129,7 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced, ctrl.nodeListerSynced) {
 		return
 	}
 
@@ -462,16 +474,136 @@ func (ctrl *Controller) syncFailingStatus(pool *mcfgv1.MachineConfigPool, err er
 	return err
 }
 
-// This function will eventually contain a sane garbage collection policy for rendered MachineConfigs;
-// see https://github.com/openshift/machine-config-operator/issues/301
-// It will probably involve making sure we're only GCing a config after all nodes don't have it
-// in either desired or current config.
+// garbageCollectRenderedConfigs deletes rendered MachineConfigs owned by the pool that are no longer needed.
+// A rendered config is never deleted while it is referenced by a node's current or desired config annotation,
+// by the spec or status configuration of any pool, or while it is pinned via PinnedRenderedConfigsAnnotationKey.
+// Of the remaining configs, the most recent retention revisions are kept unless they are older than the
+// pool's optional maximum age; see https://github.com/openshift/machine-config-operator/issues/301
 func (ctrl *Controller) garbageCollectRenderedConfigs(pool *mcfgv1.MachineConfigPool) error {
-	// Temporarily until https://github.com/openshift/machine-config-operator/pull/318
-	// which depends on the strategy for https://github.com/openshift/machine-config-operator/issues/301
+	revisions, maxAge, err := getRenderedConfigRetention(pool)
+	if err != nil {
+		return err
+	}
+
+	inUse, err := ctrl.getRenderedConfigsInUse(pool)
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Split(pool.Annotations[ctrlcommon.PinnedRenderedConfigsAnnotationKey], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			inUse.Insert(name)
+		}
+	}
+
+	mcs, err := ctrl.mcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var owned []*mcfgv1.MachineConfig
+	for _, mc := range mcs {
+		controllerRef := metav1.GetControllerOf(mc)
+		if controllerRef == nil || controllerRef.Kind != controllerKind.Kind || controllerRef.UID != pool.UID {
+			continue
+		}
+		if !strings.HasPrefix(mc.Name, fmt.Sprintf("rendered-%s-", pool.Name)) || mc.DeletionTimestamp != nil {
+			continue
+		}
+		owned = append(owned, mc)
+	}
+
+	for _, mc := range selectRenderedConfigsForDeletion(owned, inUse, revisions, maxAge, time.Now()) {
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("could not garbage collect rendered config %s: %w", mc.Name, err)
+		}
+		glog.Infof("Pool %s: garbage collected rendered config %s (created %s)", pool.Name, mc.Name, mc.CreationTimestamp)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RenderedConfigGarbageCollected", "Deleted unused rendered config %s", mc.Name)
+		ctrlcommon.MCCRenderedConfigsGarbageCollected.WithLabelValues(pool.Name).Inc()
+	}
+
 	return nil
 }
 
+// getRenderedConfigsInUse returns the names of all MachineConfigs referenced by a node annotation or a pool configuration.
+// The configuration of pool is included explicitly since the lister may not have caught up with its latest update yet.
+func (ctrl *Controller) getRenderedConfigsInUse(pool *mcfgv1.MachineConfigPool) (sets.String, error) {
+	inUse := sets.NewString(pool.Spec.Configuration.Name, pool.Status.Configuration.Name)
+
+	nodes, err := ctrl.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		for _, key := range []string{daemonconsts.CurrentMachineConfigAnnotationKey, daemonconsts.DesiredMachineConfigAnnotationKey} {
+			if name := node.Annotations[key]; name != "" {
+				inUse.Insert(name)
+			}
+		}
+	}
+
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pools {
+		inUse.Insert(p.Spec.Configuration.Name, p.Status.Configuration.Name)
+	}
+
+	return inUse, nil
+}
+
+// getRenderedConfigRetention reads the retention policy from the pool annotations, falling back to the defaults.
+// A zero maxAge means rendered configs are never collected because of their age.
+func getRenderedConfigRetention(pool *mcfgv1.MachineConfigPool) (int, time.Duration, error) {
+	revisions := ctrlcommon.DefaultRenderedConfigRetentionRevisions
+	if val, ok := pool.Annotations[ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey]; ok {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("invalid value %q for annotation %s on pool %s: must be a non-negative integer", val, ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey, pool.Name)
+		}
+		revisions = parsed
+	}
+
+	var maxAge time.Duration
+	if val, ok := pool.Annotations[ctrlcommon.RenderedConfigRetentionMaxAgeAnnotationKey]; ok {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("invalid value %q for annotation %s on pool %s: must be a non-negative duration", val, ctrlcommon.RenderedConfigRetentionMaxAgeAnnotationKey, pool.Name)
+		}
+		maxAge = parsed
+	}
+
+	return revisions, maxAge, nil
+}
+
+// selectRenderedConfigsForDeletion returns the configs that are not in use and are either outside of
+// the newest revisions or older than maxAge.
+func selectRenderedConfigsForDeletion(configs []*mcfgv1.MachineConfig, inUse sets.String, revisions int, maxAge time.Duration, now time.Time) []*mcfgv1.MachineConfig {
+	sorted := append([]*mcfgv1.MachineConfig{}, configs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].Name > sorted[j].Name
+		}
+		return sorted[j].CreationTimestamp.Before(&sorted[i].CreationTimestamp)
+	})
+
+	var toDelete []*mcfgv1.MachineConfig
+	for idx, mc := range sorted {
+		if inUse.Has(mc.Name) {
+			continue
+		}
+		expired := maxAge > 0 && now.Sub(mc.CreationTimestamp.Time) > maxAge
+		if idx < revisions && !expired {
+			continue
+		}
+		toDelete = append(toDelete, mc)
+	}
+	return toDelete
+}
+
 func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig) error {
 	if len(configs) == 0 {
 		return nil
@@ -523,8 +655,11 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		if err != nil {
 			return err
 		}
-		_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
-		return err
+		if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		ctrl.garbageCollectRenderedConfigsOrWarn(pool)
+		return nil
 	}
 
 	newPool.Spec.Configuration.Name = generated.Name
@@ -535,13 +670,20 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 	}
 	glog.V(2).Infof("Pool %s: now targeting: %s", pool.Name, pool.Spec.Configuration.Name)
 
-	if err := ctrl.garbageCollectRenderedConfigs(pool); err != nil {
-		return err
-	}
+	ctrl.garbageCollectRenderedConfigsOrWarn(pool)
 
 	return nil
 }
 
+// garbageCollectRenderedConfigsOrWarn garbage collects the pool's rendered configs. Failures don't keep the
+// pool from targeting its rendered config, so they are only logged and reported as an event on the pool.
+func (ctrl *Controller) garbageCollectRenderedConfigsOrWarn(pool *mcfgv1.MachineConfigPool) {
+	if err := ctrl.garbageCollectRenderedConfigs(pool); err != nil {
+		glog.Warningf("Pool %s: failed to garbage collect rendered configs: %v", pool.Name, err)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "RenderedConfigGarbageCollectionFailed", "Failed to garbage collect rendered configs: %v", err)
+	}
+}
+
 // generateRenderedMachineConfig takes all MCs for a given pool and returns a single rendered MC. For ex master-XXXX or worker-XXXX
 func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) (*mcfgv1.MachineConfig, error) {
 	// Suppress rendered config generation until a corresponding new controller can roll out too.
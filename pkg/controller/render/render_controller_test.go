@@ -19,6 +19,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -47,6 +49,8 @@ type fixture struct {
 	mcLister  []*mcfgv1.MachineConfig
 	ccLister  []*mcfgv1.ControllerConfig
 
+	nodeLister []*corev1.Node
+
 	actions []core.Action
 
 	objects []runtime.Object
@@ -62,20 +66,26 @@ func newFixture(t *testing.T) *fixture {
 func (f *fixture) newController() *Controller {
 	f.client = fake.NewSimpleClientset(f.objects...)
 
+	kubeclient := k8sfake.NewSimpleClientset()
+
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(kubeclient, noResyncPeriodFunc())
 
 	c := New(i.Machineconfiguration().V1().MachineConfigPools(), i.Machineconfiguration().V1().MachineConfigs(),
-		i.Machineconfiguration().V1().ControllerConfigs(), k8sfake.NewSimpleClientset(), f.client)
+		i.Machineconfiguration().V1().ControllerConfigs(), k8sI.Core().V1().Nodes(), kubeclient, f.client)
 
 	c.mcpListerSynced = alwaysReady
 	c.mcListerSynced = alwaysReady
 	c.ccListerSynced = alwaysReady
+	c.nodeListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
 	i.Start(stopCh)
 	i.WaitForCacheSync(stopCh)
+	k8sI.Start(stopCh)
+	k8sI.WaitForCacheSync(stopCh)
 
 	for _, c := range f.ccLister {
 		i.Machineconfiguration().V1().ControllerConfigs().Informer().GetIndexer().Add(c)
@@ -91,6 +101,10 @@ func (f *fixture) newController() *Controller {
 		i.Machineconfiguration().V1().ControllerConfigs().Informer().GetIndexer().Add(m)
 	}
 
+	for _, n := range f.nodeLister {
+		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(n)
+	}
+
 	return c
 }
 
@@ -209,6 +223,10 @@ func (f *fixture) expectUpdateMachineConfigAction(config *mcfgv1.MachineConfig)
 	f.actions = append(f.actions, core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "machineconfigs"}, config))
 }
 
+func (f *fixture) expectDeleteMachineConfigAction(config *mcfgv1.MachineConfig) {
+	f.actions = append(f.actions, core.NewRootDeleteAction(schema.GroupVersionResource{Resource: "machineconfigs"}, config.Name))
+}
+
 func (f *fixture) expectUpdateMachineConfigPool(pool *mcfgv1.MachineConfigPool) {
 	f.actions = append(f.actions, core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "machineconfigpools"}, pool))
 }
@@ -471,6 +489,205 @@ func TestDoNothing(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
+func newRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, hash string, created time.Time) *mcfgv1.MachineConfig {
+	mc := helpers.NewMachineConfig(fmt.Sprintf("rendered-%s-%s", pool.Name, hash), nil, "dummy://", []ign3types.File{})
+	mc.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(pool, controllerKind)})
+	mc.CreationTimestamp = metav1.NewTime(created)
+	return mc
+}
+
+func TestGarbageCollectRenderedConfigs(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", []ign3types.File{}),
+	}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	mcp.Spec.Configuration.Name = gmc.Name
+	mcp.Status.Configuration.Name = gmc.Name
+
+	now := time.Now()
+	gmc.CreationTimestamp = metav1.NewTime(now)
+	old := []*mcfgv1.MachineConfig{
+		newRenderedMachineConfig(mcp, "pinned", now.Add(-1*time.Hour)),
+		newRenderedMachineConfig(mcp, "current", now.Add(-2*time.Hour)),
+		newRenderedMachineConfig(mcp, "unused-1", now.Add(-3*time.Hour)),
+		newRenderedMachineConfig(mcp, "unused-2", now.Add(-4*time.Hour)),
+	}
+	mcp.Annotations = map[string]string{
+		ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey: "1",
+		ctrlcommon.PinnedRenderedConfigsAnnotationKey:            old[0].Name,
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-0",
+			Annotations: map[string]string{
+				daemonconsts.CurrentMachineConfigAnnotationKey: old[1].Name,
+				daemonconsts.DesiredMachineConfigAnnotationKey: gmc.Name,
+			},
+		},
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.nodeLister = append(f.nodeLister, node)
+	f.mcLister = append(f.mcLister, mcs...)
+	f.mcLister = append(f.mcLister, gmc)
+	f.mcLister = append(f.mcLister, old...)
+	for _, mc := range append(append(mcs, gmc), old...) {
+		f.objects = append(f.objects, mc)
+	}
+
+	mcpNew := mcp.DeepCopy()
+	for _, mc := range mcs {
+		mcpNew.Spec.Configuration.Source = append(mcpNew.Spec.Configuration.Source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: mc.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
+	}
+
+	f.expectGetMachineConfigAction(gmc)
+	f.expectUpdateMachineConfigPool(mcpNew)
+	f.expectDeleteMachineConfigAction(old[2])
+	f.expectDeleteMachineConfigAction(old[3])
+
+	f.run(getKey(mcp, t))
+}
+
+func TestGarbageCollectRenderedConfigsFailureDoesNotFailSync(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", []ign3types.File{}),
+	}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	mcp.Spec.Configuration.Name = gmc.Name
+	mcp.Status.Configuration.Name = gmc.Name
+	unused := newRenderedMachineConfig(mcp, "unused", time.Now().Add(-1*time.Hour))
+	mcp.Annotations = map[string]string{
+		ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey: "-1",
+	}
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, mcs...)
+	f.mcLister = append(f.mcLister, gmc, unused)
+	for _, mc := range append(mcs, gmc, unused) {
+		f.objects = append(f.objects, mc)
+	}
+
+	mcpNew := mcp.DeepCopy()
+	for _, mc := range mcs {
+		mcpNew.Spec.Configuration.Source = append(mcpNew.Spec.Configuration.Source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: mc.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
+	}
+
+	// The invalid retention fails the garbage collection, which leaves the unused config in place.
+	f.expectGetMachineConfigAction(gmc)
+	f.expectUpdateMachineConfigPool(mcpNew)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestGetRenderedConfigsInUse(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "rendered-test-cluster-master-a")
+	mcp.Status.Configuration.Name = "rendered-test-cluster-master-a"
+	f.mcpLister = append(f.mcpLister, mcp)
+	ctrl := f.newController()
+
+	// The pool was just updated to a new config the lister hasn't seen yet.
+	updated := mcp.DeepCopy()
+	updated.Spec.Configuration.Name = "rendered-test-cluster-master-b"
+	inUse, err := ctrl.getRenderedConfigsInUse(updated)
+	require.Nil(t, err)
+	assert.True(t, inUse.HasAll("rendered-test-cluster-master-a", "rendered-test-cluster-master-b"))
+}
+
+func TestSelectRenderedConfigsForDeletion(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, nil, "")
+	now := time.Now()
+	configs := []*mcfgv1.MachineConfig{
+		newRenderedMachineConfig(pool, "a", now.Add(-4*time.Hour)),
+		newRenderedMachineConfig(pool, "b", now.Add(-3*time.Hour)),
+		newRenderedMachineConfig(pool, "c", now.Add(-2*time.Hour)),
+		newRenderedMachineConfig(pool, "d", now.Add(-1*time.Hour)),
+	}
+
+	names := func(mcs []*mcfgv1.MachineConfig) []string {
+		var out []string
+		for _, mc := range mcs {
+			out = append(out, mc.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		inUse     sets.String
+		revisions int
+		maxAge    time.Duration
+		expected  []string
+	}{
+		{
+			name:      "keeps newest revisions",
+			inUse:     sets.NewString(),
+			revisions: 2,
+			expected:  []string{"rendered-worker-b", "rendered-worker-a"},
+		},
+		{
+			name:      "never deletes configs in use",
+			inUse:     sets.NewString("rendered-worker-a"),
+			revisions: 2,
+			expected:  []string{"rendered-worker-b"},
+		},
+		{
+			name:      "deletes expired configs within revisions",
+			inUse:     sets.NewString("rendered-worker-d"),
+			revisions: 10,
+			maxAge:    150 * time.Minute,
+			expected:  []string{"rendered-worker-b", "rendered-worker-a"},
+		},
+		{
+			name:      "nothing to delete",
+			inUse:     sets.NewString(),
+			revisions: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, names(selectRenderedConfigsForDeletion(configs, test.inUse, test.revisions, test.maxAge, now)))
+		})
+	}
+}
+
+func TestGetRenderedConfigRetention(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, nil, "")
+
+	revisions, maxAge, err := getRenderedConfigRetention(pool)
+	require.Nil(t, err)
+	assert.Equal(t, ctrlcommon.DefaultRenderedConfigRetentionRevisions, revisions)
+	assert.Equal(t, time.Duration(0), maxAge)
+
+	pool.Annotations = map[string]string{
+		ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey: "3",
+		ctrlcommon.RenderedConfigRetentionMaxAgeAnnotationKey:    "720h",
+	}
+	revisions, maxAge, err = getRenderedConfigRetention(pool)
+	require.Nil(t, err)
+	assert.Equal(t, 3, revisions)
+	assert.Equal(t, 720*time.Hour, maxAge)
+
+	pool.Annotations[ctrlcommon.RenderedConfigRetentionRevisionsAnnotationKey] = "-1"
+	_, _, err = getRenderedConfigRetention(pool)
+	require.NotNil(t, err)
+}
+
 func TestGetMachineConfigsForPool(t *testing.T) {
 	masterPool := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
 	files := []ign3types.File{{
@@ -31,6 +31,11 @@ type unitConfigDriftErr struct {
 	error
 }
 
+// Error type for local user config drifts
+type userConfigDriftErr struct {
+	error
+}
+
 type ConfigDriftMonitor interface {
 	Start(ConfigDriftMonitorOpts) error
 	Done() <-chan struct{}
@@ -318,6 +323,19 @@ func getFilePathsFromIgn3Config(ignConfig ign3types.Config, systemdPath string)
 		}
 	}
 
+	// Get all the directory and link paths from the ignition config. Their
+	// parent directories are watched like the ones of files.
+	for _, ignDir := range ignConfig.Storage.Directories {
+		if _, err := os.Lstat(ignDir.Path); err == nil {
+			files.Insert(ignDir.Path)
+		}
+	}
+	for _, ignLink := range ignConfig.Storage.Links {
+		if _, err := os.Lstat(ignLink.Path); err == nil {
+			files.Insert(ignLink.Path)
+		}
+	}
+
 	// Get all the file paths for systemd dropins from the ignition config
 	for _, unit := range ignConfig.Systemd.Units {
 		unitPath := getIgn3SystemdUnitPath(systemdPath, unit)
@@ -14,6 +14,7 @@ import (
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
 	"k8s.io/apimachinery/pkg/util/uuid"
 )
 
@@ -53,6 +54,23 @@ func TestConfigDriftMonitor(t *testing.T) {
 		return os.Chmod(path, 0755)
 	}
 
+	appendToFile := func(path string) error {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.WriteString("appended")
+		return err
+	}
+
+	retargetLink := func(path string) error {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		return os.Symlink("/dev/null", path)
+	}
+
 	// The general idea for this test is as follows:
 	// 1. We create a temporary directory.
 	// 2. For each test case, we create an Ignition config as usual.
@@ -133,6 +151,49 @@ func TestConfigDriftMonitor(t *testing.T) {
 			expectedErr:          fileErr,
 			mutateCompressedFile: chmodFile,
 		},
+		// Appended Ignition File
+		// These target the file called /etc/an-appended-file defined by the
+		// test fixture.
+		{
+			name:               "ign appended file content drift",
+			expectedErr:        fileErr,
+			mutateAppendedFile: changeFileContent,
+		},
+		{
+			name:               "ign appended file touch",
+			mutateAppendedFile: touchFile,
+		},
+		{
+			name:               "ign appended file appended twice",
+			expectedErr:        fileErr,
+			mutateAppendedFile: appendToFile,
+		},
+		// Ignition Directory
+		// These target the directory called /etc/a-config-dir defined by the
+		// test fixture.
+		{
+			name:            "ign directory chmod",
+			expectedErr:     fileErr,
+			mutateDirectory: chmodFile,
+		},
+		{
+			name:            "ign directory delete",
+			expectedErr:     fileErr,
+			mutateDirectory: os.Remove,
+		},
+		// Ignition Link
+		// These target the symlink called /etc/a-config-link defined by the
+		// test fixture.
+		{
+			name:        "ign link retarget",
+			expectedErr: fileErr,
+			mutateLink:  retargetLink,
+		},
+		{
+			name:        "ign link delete",
+			expectedErr: fileErr,
+			mutateLink:  os.Remove,
+		},
 		// Systemd Unit tests
 		// These target the systemd unit files in the test fixture:
 		// /etc/systemd/system/unittest.service
@@ -245,6 +306,12 @@ type configDriftMonitorTestCase struct {
 	mutateUnit func(string) error
 	// The mutation to apply to the systemd dropin file
 	mutateDropin func(string) error
+	// The mutation to apply to the appended Ignition file
+	mutateAppendedFile func(string) error
+	// The mutation to apply to the Ignition directory
+	mutateDirectory func(string) error
+	// The mutation to apply to the Ignition link
+	mutateLink func(string) error
 	// Mutex to ensure that parallel tests do not stomp on one another
 	testMutex *sync.Mutex
 }
@@ -380,6 +447,9 @@ func (tc configDriftMonitorTestCase) getIgnConfig(t *testing.T) ign3types.Config
 	compressedFile, err := helpers.CreateGzippedIgn3File("/etc/a-compressed-file", "thefilecontents", int(defaultFilePermissions))
 	require.Nil(t, err)
 
+	appendedFile := helpers.CreateEncodedIgn3File("/etc/an-appended-file", "thefilecontents", int(defaultFilePermissions))
+	appendedFile.Append = []ign3types.Resource{{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte("appended")))}}
+
 	return ign3types.Config{
 		Ignition: ign3types.Ignition{
 			Version: ign3types.MaxVersion.String(),
@@ -388,6 +458,27 @@ func (tc configDriftMonitorTestCase) getIgnConfig(t *testing.T) ign3types.Config
 			Files: []ign3types.File{
 				setDefaultUIDandGID(helpers.CreateEncodedIgn3File("/etc/a-config-file", "thefilecontents", int(defaultFilePermissions))),
 				setDefaultUIDandGID(compressedFile),
+				setDefaultUIDandGID(appendedFile),
+			},
+			Directories: []ign3types.Directory{
+				{
+					Node: ign3types.Node{
+						Path:  "/etc/a-config-dir",
+						User:  ign3types.NodeUser{ID: helpers.IntToPtr(-1)},
+						Group: ign3types.NodeGroup{ID: helpers.IntToPtr(-1)},
+					},
+					DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o750)},
+				},
+			},
+			Links: []ign3types.Link{
+				{
+					Node: ign3types.Node{
+						Path:  "/etc/a-config-link",
+						User:  ign3types.NodeUser{ID: helpers.IntToPtr(-1)},
+						Group: ign3types.NodeGroup{ID: helpers.IntToPtr(-1)},
+					},
+					LinkEmbedded1: ign3types.LinkEmbedded1{Target: "/etc/a-config-file"},
+				},
 			},
 		},
 		Systemd: ign3types.Systemd{
@@ -427,6 +518,18 @@ func (tc configDriftMonitorTestCase) mutate(ignConfig ign3types.Config) error {
 		return tc.mutateCompressedFile(ignConfig.Storage.Files[1].Path)
 	}
 
+	if tc.mutateAppendedFile != nil {
+		return tc.mutateAppendedFile(ignConfig.Storage.Files[2].Path)
+	}
+
+	if tc.mutateDirectory != nil {
+		return tc.mutateDirectory(ignConfig.Storage.Directories[0].Path)
+	}
+
+	if tc.mutateLink != nil {
+		return tc.mutateLink(ignConfig.Storage.Links[0].Path)
+	}
+
 	if tc.mutateDropin != nil {
 		dropinPath := getIgn3SystemdDropinPath(tc.systemdPath, ignConfig.Systemd.Units[0], ignConfig.Systemd.Units[0].Dropins[0])
 		return tc.mutateDropin(dropinPath)
@@ -453,6 +556,17 @@ func (tc configDriftMonitorTestCase) getFixtures(t *testing.T) (ign3types.Config
 		ignConfig.Storage.Files[i] = file
 	}
 
+	for i, dir := range ignConfig.Storage.Directories {
+		dir.Path = filepath.Join(tc.tmpDir, dir.Path)
+		ignConfig.Storage.Directories[i] = dir
+	}
+
+	for i, link := range ignConfig.Storage.Links {
+		link.Path = filepath.Join(tc.tmpDir, link.Path)
+		link.Target = filepath.Join(tc.tmpDir, link.Target)
+		ignConfig.Storage.Links[i] = link
+	}
+
 	// Separate the disk write process so that we can be sure that the deferred
 	// functions are run even when we encounter an error.
 	require.NoError(t, tc.writeIgnitionConfig(t, ignConfig))
@@ -492,10 +606,18 @@ func (tc *configDriftMonitorTestCase) writeIgnitionConfig(t *testing.T, ignConfi
 	// Write files the same way the MCD does.
 	// NOTE: We manually handle the errors here because using require.Nil or
 	// require.NoError will skip the deferred functions, which is undesirable.
-	if err := writeFiles(ignConfig.Storage.Files, true); err != nil {
+	if err := writeDirectories(ignConfig.Storage.Directories); err != nil {
+		return fmt.Errorf("could not write ignition config directories: %w", err)
+	}
+
+	if err := writeFiles(nil, ignConfig.Storage.Files, true); err != nil {
 		return fmt.Errorf("could not write ignition config files: %w", err)
 	}
 
+	if err := writeLinks(ignConfig.Storage.Links); err != nil {
+		return fmt.Errorf("could not write ignition config links: %w", err)
+	}
+
 	// Write systemd units the same way the MCD does.
 	if err := writeUnits(ignConfig.Systemd.Units, tc.systemdPath, true); err != nil {
 		return fmt.Errorf("could not write systemd units: %w", err)
@@ -0,0 +1,251 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// configDrift is the part of a config whose on-disk state drifted, along with a summary of
+// how each drifted path differs.
+type configDrift struct {
+	config  ign3types.Config
+	summary []string
+}
+
+// getConfigDriftAction returns the config drift action of pool, defaulting to Degrade.
+func getConfigDriftAction(pool *mcfgv1.MachineConfigPool) mcfgv1.ConfigDriftAction {
+	if pool == nil || pool.Spec.ConfigDriftPolicy == nil || pool.Spec.ConfigDriftPolicy.Action == "" {
+		return mcfgv1.ConfigDriftActionDegrade
+	}
+	return pool.Spec.ConfigDriftPolicy.Action
+}
+
+// handleConfigDrift applies the config drift policy of the current config's pool to driftErr.
+// It returns the error the node should be reported Degraded with, if any.
+func (dn *Daemon) handleConfigDrift(driftErr error) error {
+	currentConfig, err := dn.getCurrentConfigOnDisk()
+	if err != nil {
+		glog.Errorf("Could not get on-disk config to handle config drift: %v", err)
+		return driftErr
+	}
+	pool, err := dn.getPoolForConfig(currentConfig)
+	if err != nil {
+		glog.Warningf("Could not get pool for config %s, ignoring its config drift policy: %v", currentConfig.GetName(), err)
+	}
+
+	switch action := getConfigDriftAction(pool); action {
+	case mcfgv1.ConfigDriftActionReport:
+		logSystem("Config drift against %s reported only, per the pool's config drift policy: %v", currentConfig.GetName(), driftErr)
+		return nil
+	case mcfgv1.ConfigDriftActionRemediate:
+		if err := dn.remediateConfigDrift(currentConfig, pool); err != nil {
+			mcdConfigDriftRemediations.WithLabelValues("failed").Inc()
+			dn.nodeWriter.Eventf(corev1.EventTypeWarning, "ConfigDriftRemediationFailed", "Could not remediate config drift against %s: %v", currentConfig.GetName(), err)
+			return &configDriftErr{fmt.Errorf("%v; could not remediate: %w", driftErr, err)}
+		}
+		mcdConfigDriftRemediations.WithLabelValues("succeeded").Inc()
+		return nil
+	case mcfgv1.ConfigDriftActionDegrade:
+		return driftErr
+	default:
+		glog.Warningf("Unknown config drift action %q, degrading", action)
+		return driftErr
+	}
+}
+
+// remediateConfigDrift restores the drifted files, directories, links and units of config and
+// runs the post config change actions they need. Drift that needs a reboot to be applied, or
+// that the MCD can't restore, is left alone and returned as an error.
+func (dn *Daemon) remediateConfigDrift(config *mcfgv1.MachineConfig, pool *mcfgv1.MachineConfigPool) error {
+	drift, err := getConfigDrift(config, pathSystemd)
+	if err != nil {
+		return err
+	}
+	if len(drift.summary) == 0 {
+		// Nothing we can restore drifted, e.g. local users or the OS.
+		if err := dn.validateOnDiskState(config); err != nil {
+			return fmt.Errorf("drift can't be remediated: %w", err)
+		}
+		return nil
+	}
+
+	actions, err := calculateConfigDriftActions(drift.config, pool)
+	if err != nil {
+		return err
+	}
+
+	logSystem("Remediating config drift against %s: %s", config.GetName(), strings.Join(drift.summary, "; "))
+	if err := writeDirectories(drift.config.Storage.Directories); err != nil {
+		return err
+	}
+	if err := dn.writeFiles(drift.config.Storage.Files, drift.config.Storage.Files, false); err != nil {
+		return err
+	}
+	if err := writeLinks(drift.config.Storage.Links); err != nil {
+		return err
+	}
+	if err := dn.writeUnits(drift.config.Systemd.Units); err != nil {
+		return err
+	}
+	if err := dn.runUnitPostConfigChangeActions(actions, config.GetName()); err != nil {
+		return err
+	}
+	if err := dn.validateOnDiskState(config); err != nil {
+		return fmt.Errorf("on-disk state still differs after remediation: %w", err)
+	}
+
+	dn.nodeWriter.Eventf(corev1.EventTypeNormal, "ConfigDriftRemediated", "Remediated config drift against %s: %s; post config change actions: %v",
+		config.GetName(), strings.Join(drift.summary, "; "), actions)
+	return nil
+}
+
+// calculateConfigDriftActions returns the post config change actions needed to apply the
+// restored content of drift, as for an update changing it. It errors if a reboot is needed.
+func calculateConfigDriftActions(drift ign3types.Config, pool *mcfgv1.MachineConfigPool) ([]string, error) {
+	var rules []mcfgv1.PostConfigChangeActionRule
+	var extraUnits []string
+	if pool != nil {
+		rules = pool.Spec.PostConfigChangeActions
+		extraUnits = pool.Spec.UnitsRequiringReboot
+	}
+	var paths []string
+	for _, f := range drift.Storage.Files {
+		paths = append(paths, f.Path)
+	}
+	for _, d := range drift.Storage.Directories {
+		paths = append(paths, d.Path)
+	}
+	for _, l := range drift.Storage.Links {
+		paths = append(paths, l.Path)
+	}
+
+	var actions []string
+	if len(drift.Systemd.Units) > 0 {
+		actions = append(actions, postConfigChangeActionDaemonReload)
+	}
+	for _, action := range calculatePostConfigChangeActionFromFileDiffs(paths, rules) {
+		if action == postConfigChangeActionReboot {
+			return nil, fmt.Errorf("restoring %v requires a reboot", paths)
+		}
+		if action != postConfigChangeActionNone {
+			actions = append(actions, action)
+		}
+	}
+	unitActions := calculateUnitPostConfigChangeActions(nil, drift.Systemd.Units, extraUnits)
+	if unitActions == nil {
+		return nil, fmt.Errorf("restoring units requires a reboot")
+	}
+	for _, action := range unitActions {
+		if !ctrlcommon.InSlice(action, actions) {
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) == 0 {
+		actions = []string{postConfigChangeActionNone}
+	}
+	return actions, nil
+}
+
+// getConfigDrift returns the files, directories, links and units of config whose on-disk state
+// differs from it. Drifted units are returned whole, including all their dropins.
+func getConfigDrift(config *mcfgv1.MachineConfig, systemdPath string) (*configDrift, error) {
+	ignConfigi, err := ctrlcommon.IgnParseWrapper(config.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Ignition for config drift remediation: %w", err)
+	}
+	ignConfig, ok := ignConfigi.(ign3types.Config)
+	if !ok {
+		return nil, fmt.Errorf("config drift remediation requires an Ignition spec 3 config")
+	}
+
+	drift := &configDrift{}
+	for _, f := range ignConfig.Storage.Files {
+		if err := checkV3Files([]ign3types.File{f}); err != nil {
+			drift.config.Storage.Files = append(drift.config.Storage.Files, f)
+			drift.summary = append(drift.summary, describeFileDrift(f))
+		}
+	}
+	for _, d := range ignConfig.Storage.Directories {
+		if err := checkV3Directories([]ign3types.Directory{d}); err != nil {
+			drift.config.Storage.Directories = append(drift.config.Storage.Directories, d)
+			drift.summary = append(drift.summary, fmt.Sprintf("%s: %v", d.Path, err))
+		}
+	}
+	for _, l := range ignConfig.Storage.Links {
+		if err := checkV3Links([]ign3types.Link{l}); err != nil {
+			drift.config.Storage.Links = append(drift.config.Storage.Links, l)
+			drift.summary = append(drift.summary, fmt.Sprintf("%s: %v", l.Path, err))
+		}
+	}
+	for _, u := range ignConfig.Systemd.Units {
+		if err := checkV3Unit(u, systemdPath); err != nil {
+			drift.config.Systemd.Units = append(drift.config.Systemd.Units, u)
+			drift.summary = append(drift.summary, fmt.Sprintf("%s: %v", u.Name, err))
+		}
+	}
+	return drift, nil
+}
+
+// describeFileDrift summarizes how the file on disk differs from f, e.g.
+// "/etc/foo: mode 0600, expected 0644; contents differ (+1/-2 lines)".
+func describeFileDrift(f ign3types.File) string {
+	fi, err := os.Lstat(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("%s: missing", f.Path)
+		}
+		return fmt.Sprintf("%s: %v", f.Path, err)
+	}
+	var diffs []string
+	mode := defaultFilePermissions
+	if f.Mode != nil {
+		mode = os.FileMode(*f.Mode)
+	}
+	if fi.Mode() != mode {
+		diffs = append(diffs, fmt.Sprintf("mode %#o, expected %#o", fi.Mode(), mode))
+	}
+	expected, err := fileContents(f)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", f.Path, err)
+	}
+	if actual, err := os.ReadFile(f.Path); err != nil {
+		diffs = append(diffs, err.Error())
+	} else if !bytes.Equal(actual, expected) {
+		added, removed := countChangedLines(expected, actual)
+		diffs = append(diffs, fmt.Sprintf("contents differ (+%d/-%d lines)", added, removed))
+	}
+	return fmt.Sprintf("%s: %s", f.Path, strings.Join(diffs, "; "))
+}
+
+// countChangedLines returns how many lines of actual aren't in expected and how many lines of
+// expected aren't in actual, ignoring their order.
+func countChangedLines(expected, actual []byte) (added, removed int) {
+	lines := make(map[string]int)
+	for _, line := range strings.SplitAfter(string(expected), "\n") {
+		if line != "" {
+			lines[line]++
+		}
+	}
+	for _, line := range strings.SplitAfter(string(actual), "\n") {
+		if line == "" {
+			continue
+		}
+		if lines[line] > 0 {
+			lines[line]--
+		} else {
+			added++
+		}
+	}
+	for _, n := range lines {
+		removed += n
+	}
+	return added, removed
+}
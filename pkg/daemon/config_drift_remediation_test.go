@@ -0,0 +1,139 @@
+package daemon
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateConfigDriftActions(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0")
+	pool.Spec.PostConfigChangeActions = []mcfgv1.PostConfigChangeActionRule{
+		{Path: "/etc/chrony.d/*.conf", Type: mcfgv1.PostConfigChangeActionRestart, Unit: "chronyd.service"},
+		{Path: "/etc/motd", Type: mcfgv1.PostConfigChangeActionNone},
+	}
+	chronyd := ign3types.Unit{Name: "chronyd.service", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Service]\n")}
+	mount := ign3types.Unit{Name: "var-lib-containers.mount", Contents: helpers.StrToPtr("[Mount]\n")}
+
+	tests := []struct {
+		name     string
+		drift    ign3types.Config
+		expected []string
+		err      string
+	}{{
+		name:     "file without action",
+		drift:    ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{ctrlcommon.NewIgnFile("/etc/motd", "")}}},
+		expected: []string{postConfigChangeActionNone},
+	}, {
+		name:     "file with a restart rule",
+		drift:    ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{ctrlcommon.NewIgnFile("/etc/chrony.d/servers.conf", "")}}},
+		expected: []string{"restart chronyd.service"},
+	}, {
+		name:     "crio file",
+		drift:    ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{ctrlcommon.NewIgnFile(constants.ContainerRegistryConfPath, "")}}},
+		expected: []string{postConfigChangeActionReloadCrio},
+	}, {
+		name:  "file requiring a reboot",
+		drift: ign3types.Config{Storage: ign3types.Storage{Files: []ign3types.File{ctrlcommon.NewIgnFile("/etc/kubernetes/kubelet.conf", "")}}},
+		err:   "restoring [/etc/kubernetes/kubelet.conf] requires a reboot",
+	}, {
+		name:     "service",
+		drift:    ign3types.Config{Systemd: ign3types.Systemd{Units: []ign3types.Unit{chronyd}}},
+		expected: []string{postConfigChangeActionDaemonReload, "restart chronyd.service"},
+	}, {
+		name:  "mount unit",
+		drift: ign3types.Config{Systemd: ign3types.Systemd{Units: []ign3types.Unit{mount}}},
+		err:   "restoring units requires a reboot",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actions, err := calculateConfigDriftActions(test.drift, pool)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actions)
+		})
+	}
+}
+
+func TestCountChangedLines(t *testing.T) {
+	added, removed := countChangedLines([]byte("a\nb\nc\n"), []byte("a\nc\nd\ne\n"))
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+
+	added, removed = countChangedLines([]byte("a\nb\n"), []byte("b\na\n"))
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 0, removed)
+}
+
+func TestRemediateConfigDrift(t *testing.T) {
+	testDir, cleanup := setupTempDirWithEtc(t)
+	defer cleanup()
+
+	// use current user so test doesn't try to chown to root
+	currentUser, err := user.Current()
+	require.NoError(t, err)
+	currentUID, err := strconv.Atoi(currentUser.Uid)
+	require.NoError(t, err)
+	currentGID, err := strconv.Atoi(currentUser.Gid)
+	require.NoError(t, err)
+
+	newFile := func(path, contents string) ign3types.File {
+		file := ctrlcommon.NewIgnFile(filepath.Join(testDir, path), contents)
+		file.User = ign3types.NodeUser{ID: &currentUID}
+		file.Group = ign3types.NodeGroup{ID: &currentGID}
+		file.Mode = helpers.IntToPtr(0o644)
+		return file
+	}
+	motd := newFile("etc/motd", "hello\nworld\n")
+	issue := newFile("etc/issue", "welcome\n")
+	ignConfig := ctrlcommon.NewIgnConfig()
+	ignConfig.Storage.Files = []ign3types.File{motd, issue}
+	config := helpers.CreateMachineConfigFromIgnition(ignConfig)
+	config.Name = "rendered-worker-0"
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0")
+	pool.Spec.PostConfigChangeActions = []mcfgv1.PostConfigChangeActionRule{
+		{Path: filepath.Join(testDir, "etc/motd"), Type: mcfgv1.PostConfigChangeActionNone},
+	}
+
+	d := newMockDaemon()
+	d.nodeWriter = &annotationRecorder{annotations: map[string]string{}}
+	require.NoError(t, d.writeFiles(nil, ignConfig.Storage.Files, false))
+
+	// Nothing drifted
+	drift, err := getConfigDrift(config, pathSystemd)
+	require.NoError(t, err)
+	assert.Empty(t, drift.summary)
+
+	require.NoError(t, os.WriteFile(motd.Path, []byte("hello\nthere\nfriend\n"), 0o600))
+	require.NoError(t, os.Chmod(motd.Path, 0o600))
+	drift, err = getConfigDrift(config, pathSystemd)
+	require.NoError(t, err)
+	assert.Equal(t, []string{motd.Path + ": mode 0600, expected 0644; contents differ (+2/-1 lines)"}, drift.summary)
+	assert.Equal(t, []ign3types.File{motd}, drift.config.Storage.Files)
+
+	require.NoError(t, d.remediateConfigDrift(config, pool))
+	contents, err := os.ReadFile(motd.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(contents))
+	assert.NoError(t, validateOnDiskState(config, pathSystemd))
+
+	// Restoring a file without a post config change action rule needs a reboot
+	require.NoError(t, os.Remove(issue.Path))
+	assert.EqualError(t, d.remediateConfigDrift(config, pool), "restoring ["+issue.Path+"] requires a reboot")
+	assert.NoFileExists(t, issue.Path)
+}
@@ -18,6 +18,8 @@ const (
 	DesiredDrainerAnnotationKey = "machineconfiguration.openshift.io/desiredDrain"
 	// LastAppliedDrainerAnnotationKey is set by the controller to indicate the last request applied
 	LastAppliedDrainerAnnotationKey = "machineconfiguration.openshift.io/lastAppliedDrain"
+	// DrainStatusAnnotationKey is set by the controller to the JSON encoded progress of the latest drain/uncordon request
+	DrainStatusAnnotationKey = "machineconfiguration.openshift.io/drainStatus"
 	// DrainerStateDrain is used for drainer annotation as a value to indicate needing a drain
 	DrainerStateDrain = "drain"
 	// DrainerStateUncordon is used for drainer annotation as a value to indicate needing an uncordon
@@ -46,6 +48,23 @@ const (
 	MachineConfigDaemonReasonAnnotationKey = "machineconfiguration.openshift.io/reason"
 	// MachineConfigDaemonFinalizeFailureAnnotationKey is set by the daemon when ostree fails to finalize
 	MachineConfigDaemonFinalizeFailureAnnotationKey = "machineconfiguration.openshift.io/ostree-finalize-staged-failure"
+	// MachineConfigDaemonPendingRebootAnnotationKey is set by the daemon to the rendered config whose kernel argument
+	// changes were staged without rebooting. It is cleared once the node has rebooted.
+	MachineConfigDaemonPendingRebootAnnotationKey = "machineconfiguration.openshift.io/pendingReboot"
+	// RebootRequestedAnnotationKey is set by the node controller to the pending reboot annotation of a node when one
+	// of its pool's maintenance windows is open, asking the daemon to reboot the node. It is cleared with the latter.
+	RebootRequestedAnnotationKey = "machineconfiguration.openshift.io/rebootRequested"
+	// ReprovisionAnnotationKey is set by the daemon to the rendered config a node must be reprovisioned with,
+	// once it has been drained because the config changes disks, filesystems or RAID arrays.
+	ReprovisionAnnotationKey = "machineconfiguration.openshift.io/reprovision"
+	// HealthGateFailedAnnotationKey is set by the daemon to the rendered config whose pool health gates the
+	// node failed after rebooting into it. The node controller halts the pool's rollout while it is set.
+	HealthGateFailedAnnotationKey = "machineconfiguration.openshift.io/healthGateFailed"
+	// PendingRebootFilePath records the boot in which the daemon deferred a reboot, so that the pending reboot
+	// annotation can be cleared once the node has booted again.
+	PendingRebootFilePath = "/etc/machine-config-daemon/pending-reboot.json"
+	// UpdateHistoryFilePath is where the daemon records the node's recent updates, most recent last.
+	UpdateHistoryFilePath = "/etc/machine-config-daemon/update-history.json"
 	// InitialNodeAnnotationsFilePath defines the path at which it will find the node annotations it needs to set on the node once it comes up for the first time.
 	// The Machine Config Server writes the node annotations to this path.
 	InitialNodeAnnotationsFilePath = "/etc/machine-config-daemon/node-annotations.json"
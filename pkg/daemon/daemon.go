@@ -81,6 +81,9 @@ type Daemon struct {
 	mcLister       mcfglistersv1.MachineConfigLister
 	mcListerSynced cache.InformerSynced
 
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
 	ccLister       mcfglistersv1.ControllerConfigLister
 	ccListerSynced cache.InformerSynced
 
@@ -309,6 +312,7 @@ func (dn *Daemon) ClusterConnect(
 	name string,
 	kubeClient kubernetes.Interface,
 	mcInformer mcfginformersv1.MachineConfigInformer,
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
 	nodeInformer coreinformersv1.NodeInformer,
 	ccInformer mcfginformersv1.ControllerConfigInformer,
 	kubeletHealthzEnabled bool,
@@ -332,6 +336,8 @@ func (dn *Daemon) ClusterConnect(
 	dn.nodeListerSynced = nodeInformer.Informer().HasSynced
 	dn.mcLister = mcInformer.Lister()
 	dn.mcListerSynced = mcInformer.Informer().HasSynced
+	dn.mcpLister = mcpInformer.Lister()
+	dn.mcpListerSynced = mcpInformer.Informer().HasSynced
 
 	dn.ccQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -638,6 +644,8 @@ func (dn *Daemon) syncNode(key string) error {
 		if err := dn.triggerUpdateWithMachineConfig(current, desired, true); err != nil {
 			return err
 		}
+	} else if rebooting, err := dn.rebootIfRequested(); err != nil || rebooting {
+		return err
 	}
 	glog.V(2).Infof("Node %s is already synced", node.Name)
 	return nil
@@ -663,7 +671,8 @@ func (dn *Daemon) runPreflightConfigDriftCheck() error {
 	if err := dn.validateOnDiskState(currentOnDisk); err != nil {
 		dn.nodeWriter.Eventf(corev1.EventTypeWarning, "PreflightConfigDriftCheckFailed", err.Error())
 		glog.Errorf("Preflight config drift check failed: %v", err)
-		return &configDriftErr{err}
+		// The pool's config drift policy may allow updating regardless, or remediate the drift.
+		return dn.handleConfigDrift(&configDriftErr{err})
 	}
 
 	glog.Infof("Preflight config drift check successful (took %s)", time.Since(start))
@@ -865,7 +874,7 @@ func (dn *Daemon) syncNodeHypershift(key string) error {
 		return fmt.Errorf("parsing new Ignition config failed: %w", err)
 	}
 	diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
-	actions, err := calculatePostConfigChangeAction(mcDiff, diffFileSet)
+	actions, err := calculatePostConfigChangeAction(mcDiff, diffFileSet, nil)
 	if err != nil {
 		return err
 	}
@@ -917,12 +926,8 @@ func (dn *Daemon) syncNodeHypershift(key string) error {
 		glog.Infof("Node has Desired Config %s, skipping reboot", desiredConfig.Name)
 	}
 
-	if ctrlcommon.InSlice(postConfigChangeActionReloadCrio, actions) {
-		serviceName := "crio"
-		if err := reloadService(serviceName); err != nil {
-			return fmt.Errorf("could not apply update: reloading %s configuration failed. Error: %w", serviceName, err)
-		}
-		glog.Infof("%s config reloaded successfully! Desired config %s has been applied, skipping reboot", serviceName, desiredConfig.Name)
+	if err := dn.runUnitPostConfigChangeActions(actions, desiredConfig.Name); err != nil {
+		return err
 	}
 
 	// We are here, which means reboot was not needed to apply the configuration.
@@ -1085,7 +1090,7 @@ func (dn *Daemon) Run(stopCh <-chan struct{}, exitCh <-chan error) error {
 	defer dn.queue.ShutDown()
 	defer dn.ccQueue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, dn.nodeListerSynced, dn.mcListerSynced, dn.ccListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, dn.nodeListerSynced, dn.mcListerSynced, dn.mcpListerSynced, dn.ccListerSynced) {
 		return fmt.Errorf("failed to sync initial listers cache")
 	}
 
@@ -1156,9 +1161,13 @@ func (dn *Daemon) applySSHAccessedAnnotation() error {
 }
 
 // Called whenever the on-disk config has drifted from the current machineconfig.
+// The pool's config drift policy decides whether the node goes degraded.
 func (dn *Daemon) onConfigDrift(err error) {
 	dn.nodeWriter.Eventf(corev1.EventTypeWarning, "ConfigDriftDetected", err.Error())
 	glog.Error(err)
+	if err = dn.handleConfigDrift(err); err == nil {
+		return
+	}
 	if err := dn.updateErrorState(err); err != nil {
 		glog.Errorf("Could not update annotation: %v", err)
 	}
@@ -1314,6 +1323,27 @@ func (dn *Daemon) syncFinalizationFailureAnnotation(finalizeError string) error
 	return err
 }
 
+// getPoolForConfig returns the MachineConfigPool that rendered config, as recorded in its
+// controller owner reference. It returns nil if the pool lister isn't set up (e.g. once-from
+// mode) or the config isn't owned by a pool.
+func (dn *Daemon) getPoolForConfig(config *mcfgv1.MachineConfig) (*mcfgv1.MachineConfigPool, error) {
+	if dn.mcpLister == nil || config == nil {
+		return nil, nil
+	}
+	ref := metav1.GetControllerOf(config)
+	if ref == nil || ref.Kind != "MachineConfigPool" {
+		return nil, nil
+	}
+	pool, err := dn.mcpLister.Get(ref.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting pool %s: %w", ref.Name, err)
+	}
+	return pool, nil
+}
+
 // stateAndConfigs is the "state" node annotation plus parsed machine configs
 // referenced by the currentConfig and desiredConfig annotations.  If we have
 // a "pending" config (we're coming up after a reboot attempting to apply a config),
@@ -1488,6 +1518,117 @@ func (dn *Daemon) writePendingConfig(desiredConfig *mcfgv1.MachineConfig) error
 	return writeFileAtomicallyWithDefaults(pendingConfigPath, b)
 }
 
+// pendingRebootState is stored in PendingRebootFilePath when the daemon defers a reboot.
+type pendingRebootState struct {
+	Config string `json:"config"`
+	BootID string `json:"bootID"`
+}
+
+// setPendingReboot records that changes staged for config still need a reboot, both on
+// disk and in the node's pending reboot annotation.
+func (dn *Daemon) setPendingReboot(configName string) error {
+	b, err := json.Marshal(&pendingRebootState{Config: configName, BootID: dn.bootID})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomicallyWithDefaults(constants.PendingRebootFilePath, b); err != nil {
+		return err
+	}
+	if dn.nodeWriter == nil {
+		return nil
+	}
+	_, err = dn.nodeWriter.SetAnnotations(map[string]string{
+		constants.MachineConfigDaemonPendingRebootAnnotationKey: configName,
+	})
+	return err
+}
+
+// getPendingReboot returns the deferred reboot recorded on disk, or nil if there is none.
+func getPendingReboot() (*pendingRebootState, error) {
+	b, err := os.ReadFile(constants.PendingRebootFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("loading pending reboot state: %w", err)
+	}
+	var p pendingRebootState
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("parsing pending reboot state: %w", err)
+	}
+	return &p, nil
+}
+
+// isRebootPending returns true if a reboot was deferred during the current boot.
+func (dn *Daemon) isRebootPending() (bool, error) {
+	pending, err := getPendingReboot()
+	if err != nil {
+		return false, err
+	}
+	return pending != nil && pending.BootID == dn.bootID, nil
+}
+
+// clearPendingRebootIfRebooted clears a deferred reboot once the node has booted again.
+func (dn *Daemon) clearPendingRebootIfRebooted() error {
+	pending, err := getPendingReboot()
+	if err != nil {
+		return err
+	}
+	if pending == nil || pending.BootID == dn.bootID {
+		return nil
+	}
+	glog.Infof("Node rebooted since kernel arguments were staged for config %s, clearing pending reboot", pending.Config)
+	if dn.nodeWriter != nil {
+		if _, err := dn.nodeWriter.SetAnnotations(map[string]string{
+			constants.MachineConfigDaemonPendingRebootAnnotationKey: "",
+			constants.RebootRequestedAnnotationKey:                  "",
+		}); err != nil {
+			return fmt.Errorf("clearing pending reboot annotation: %w", err)
+		}
+	}
+	if err := os.Remove(constants.PendingRebootFilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing pending reboot state: %w", err)
+	}
+	return nil
+}
+
+// rebootIfRequested drains and reboots the node into its current config if the node controller
+// requested the reboot deferred during this boot. It returns true if the node is rebooting.
+func (dn *Daemon) rebootIfRequested() (bool, error) {
+	requested := dn.node.Annotations[constants.RebootRequestedAnnotationKey]
+	if requested == "" || requested != dn.node.Annotations[constants.MachineConfigDaemonPendingRebootAnnotationKey] {
+		return false, nil
+	}
+	pending, err := dn.isRebootPending()
+	if err != nil || !pending {
+		return false, err
+	}
+	currentConfigName, err := getNodeAnnotation(dn.node, constants.CurrentMachineConfigAnnotationKey)
+	if err != nil {
+		return false, err
+	}
+	currentConfig, err := dn.mcLister.Get(currentConfigName)
+	if err != nil {
+		return false, err
+	}
+	pool, err := dn.getPoolForConfig(currentConfig)
+	if err != nil {
+		glog.Warningf("Could not get pool for config %s, ignoring its update settings: %v", currentConfigName, err)
+	}
+
+	logSystem("Reboot requested to apply kernel arguments staged for config %s", requested)
+	if err := dn.performDrain(pool); err != nil {
+		return false, err
+	}
+	if err := dn.runUpdateHooks(pool, mcfgv1.UpdateHookPreReboot, currentConfigName); err != nil {
+		return false, err
+	}
+	if err := dn.finalizeBeforeReboot(currentConfig); err != nil {
+		return false, err
+	}
+	return true, dn.reboot(fmt.Sprintf("Node will reboot to apply kernel arguments staged for config %s", requested))
+}
+
 // XXX: drop this
 // we need this compatibility layer for now
 func (dn *Daemon) getPendingConfig() (*pendingConfigState, error) {
@@ -1759,12 +1900,17 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	// take a stab at that and re-run the drain+reboot routine
 	if state.pendingConfig != nil && bootID == dn.bootID {
 		logSystem("drain interrupted, retrying")
-		if err := dn.performDrain(); err != nil {
+		pool, err := dn.getPoolForConfig(state.pendingConfig)
+		if err != nil {
+			glog.Warningf("Could not get pool for config %s, ignoring its drain policy: %v", state.pendingConfig.GetName(), err)
+		}
+		if err := dn.performDrain(pool); err != nil {
 			return err
 		}
 		if err := dn.finalizeBeforeReboot(state.pendingConfig); err != nil {
 			return err
 		}
+		dn.recordUpdateHistoryReboot(state.pendingConfig.GetName())
 		return dn.reboot(fmt.Sprintf("Node will reboot into config %v", state.pendingConfig.GetName()))
 	}
 
@@ -1776,6 +1922,10 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		return fmt.Errorf("Failed to remove rollback: %w", err)
 	}
 
+	if err := dn.clearPendingRebootIfRebooted(); err != nil {
+		return err
+	}
+
 	// Bootstrapping state is when we have the node annotations file
 	if state.bootstrapping {
 		targetOSImageURL := state.currentConfig.Spec.OSImageURL
@@ -1872,11 +2022,30 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	if err := dn.validateOnDiskState(expectedConfig); err != nil {
 		wErr := fmt.Errorf("unexpected on-disk state validating against %s: %w", expectedConfig.GetName(), err)
 		dn.nodeWriter.Eventf(corev1.EventTypeWarning, "OnDiskStateValidationFailed", wErr.Error())
+		if state.pendingConfig != nil {
+			dn.failUpdateHistory(state.pendingConfig.GetName(), wErr)
+		}
 		return wErr
 	}
 
 	logSystem("Validated on-disk state")
 
+	// We booted into the pending config, give hooks and health gates a chance to check the node before it's marked Done.
+	if state.pendingConfig != nil {
+		pool, err := dn.getPoolForConfig(state.pendingConfig)
+		if err != nil {
+			return fmt.Errorf("getting post-boot hooks for config %s: %w", state.pendingConfig.GetName(), err)
+		}
+		if err := dn.runUpdateHooks(pool, mcfgv1.UpdateHookPostBoot, state.pendingConfig.GetName()); err != nil {
+			dn.failUpdateHistory(state.pendingConfig.GetName(), err)
+			return err
+		}
+		if err := dn.runHealthGates(pool, state.pendingConfig.GetName()); err != nil {
+			dn.failUpdateHistory(state.pendingConfig.GetName(), err)
+			return err
+		}
+	}
+
 	// We've validated state. Now, ensure that node is in desired state
 	var inDesiredConfig bool
 	if inDesiredConfig, err = dn.updateConfigAndState(state); err != nil {
@@ -1931,6 +2100,7 @@ func (dn *Daemon) updateConfigAndState(state *stateAndConfigs) (bool, error) {
 			if out, err := dn.storePendingState(state.pendingConfig, 0); err != nil {
 				return true, fmt.Errorf("failed to reset pending config: %s: %w", string(out), err)
 			}
+			dn.completeUpdateHistory(state.pendingConfig.GetName())
 
 		}
 		// If we're degraded here, it means we got an error likely on startup and we retried.
@@ -1988,7 +2158,13 @@ func (dn *Daemon) runOnceFromMachineConfig(machineConfig mcfgv1.MachineConfig, c
 // runOnceFromIgnition executes MCD's subset of Ignition functionality in onceFrom mode
 func (dn *Daemon) runOnceFromIgnition(ignConfig ign3types.Config) error {
 	// Execute update without hitting the cluster
-	if err := dn.writeFiles(ignConfig.Storage.Files, false); err != nil {
+	if err := writeDirectories(ignConfig.Storage.Directories); err != nil {
+		return err
+	}
+	if err := dn.writeFiles(nil, ignConfig.Storage.Files, false); err != nil {
+		return err
+	}
+	if err := writeLinks(ignConfig.Storage.Links); err != nil {
 		return err
 	}
 	if err := dn.writeUnits(ignConfig.Systemd.Units); err != nil {
@@ -2174,10 +2350,19 @@ func (dn *Daemon) validateOnDiskStateImpl(currentConfig *mcfgv1.MachineConfig) e
 	}
 
 	if dn.os.IsCoreOSVariant() {
-		coreOSDaemon := CoreOSDaemon{dn}
-		if err := coreOSDaemon.validateKernelArguments(currentConfig); err != nil {
+		// Kernel arguments staged for a deferred reboot aren't in effect yet
+		rebootPending, err := dn.isRebootPending()
+		if err != nil {
 			return err
 		}
+		if rebootPending {
+			glog.Info("Skipping kernel arguments validation; reboot pending")
+		} else {
+			coreOSDaemon := CoreOSDaemon{dn}
+			if err := coreOSDaemon.validateKernelArguments(currentConfig); err != nil {
+				return err
+			}
+		}
 	}
 
 	return validateOnDiskState(currentConfig, pathSystemd)
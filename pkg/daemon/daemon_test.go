@@ -150,6 +150,7 @@ func (f *fixture) newController() *Daemon {
 	d.ClusterConnect("node_name_test",
 		f.kubeclient,
 		i.Machineconfiguration().V1().MachineConfigs(),
+		i.Machineconfiguration().V1().MachineConfigPools(),
 		k8sI.Core().V1().Nodes(),
 		i.Machineconfiguration().V1().ControllerConfigs(),
 		false,
@@ -157,6 +158,7 @@ func (f *fixture) newController() *Daemon {
 	)
 
 	d.mcListerSynced = alwaysReady
+	d.mcpListerSynced = alwaysReady
 	d.nodeListerSynced = alwaysReady
 
 	stopCh := make(chan struct{})
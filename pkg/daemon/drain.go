@@ -2,14 +2,17 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
@@ -26,7 +29,13 @@ func (dn *Daemon) drainRequired() bool {
 	return !isSingleNodeTopology(dn.getControlPlaneTopology())
 }
 
-func (dn *Daemon) performDrain() error {
+// defaultDrainTimeout is how long the daemon waits for the controller to drain the node
+// when the pool has no drain policy timeout.
+const defaultDrainTimeout = 1 * time.Hour
+
+// performDrain requests the controller to cordon and drain the node and waits for it
+// to do so, for as long as the drain policy of pool allows.
+func (dn *Daemon) performDrain(pool *mcfgv1.MachineConfigPool) error {
 	// Skip drain process when we're not cluster driven
 	if dn.kubeClient == nil {
 		return nil
@@ -71,19 +80,34 @@ func (dn *Daemon) performDrain() error {
 
 	ctx := context.TODO()
 
-	if err := wait.PollUntilContextTimeout(ctx, 10*time.Second, 1*time.Hour, false, func(ctx context.Context) (bool, error) {
+	timeout := defaultDrainTimeout
+	if pool != nil && pool.Spec.DrainPolicy != nil && pool.Spec.DrainPolicy.Timeout != nil && pool.Spec.DrainPolicy.Timeout.Duration > 0 {
+		timeout = pool.Spec.DrainPolicy.Timeout.Duration
+	}
+	var status *ctrlcommon.DrainStatus
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Second, timeout, false, func(ctx context.Context) (bool, error) {
 		node, err := dn.kubeClient.CoreV1().Nodes().Get(ctx, dn.name, metav1.GetOptions{})
 		if err != nil {
 			glog.Warningf("Failed to get node: %v", err)
 			return false, nil
 		}
 		if node.Annotations[constants.DesiredDrainerAnnotationKey] != node.Annotations[constants.LastAppliedDrainerAnnotationKey] {
+			status = dn.logDrainProgress(node, desiredDrainAnnotationValue, status)
+			if status != nil && status.Failed {
+				return false, errDrainFailed
+			}
 			return false, nil
 		}
 		return true, nil
 	}); err != nil {
-		if wait.Interrupted(err) {
-			failMsg := fmt.Sprintf("failed to drain node: %s after 1 hour. Please see machine-config-controller logs for more information", dn.node.Name)
+		if wait.Interrupted(err) || errors.Is(err, errDrainFailed) {
+			failMsg := fmt.Sprintf("failed to drain node: %s after %v. Please see machine-config-controller logs for more information", dn.node.Name, timeout)
+			if status != nil && status.LastError != "" {
+				failMsg = fmt.Sprintf("failed to drain node: %s after %v and %d attempts: %s", dn.node.Name, timeout, status.Attempts, status.LastError)
+				if len(status.BlockingPods) > 0 {
+					failMsg += "; pods left on the node: " + formatBlockingPods(status.BlockingPods)
+				}
+			}
 			dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedToDrain", failMsg)
 			return fmt.Errorf(failMsg)
 		}
@@ -97,6 +121,38 @@ func (dn *Daemon) performDrain() error {
 	return nil
 }
 
+// logDrainProgress logs the failed attempts of the drain for request recorded by the controller in
+// node's drain status since last, the previously seen status. It returns the current status.
+func (dn *Daemon) logDrainProgress(node *corev1.Node, request string, last *ctrlcommon.DrainStatus) *ctrlcommon.DrainStatus {
+	status, err := ctrlcommon.GetDrainStatus(node)
+	if err != nil {
+		glog.Warningf("Failed to get drain status: %v", err)
+		return last
+	}
+	if status == nil || status.Request != request {
+		return last
+	}
+	if status.Attempts > 0 && (last == nil || status.Attempts > last.Attempts) {
+		logSystem("Drain attempt %d failed: %s", status.Attempts, status.LastError)
+		if len(status.BlockingPods) > 0 {
+			glog.Infof("Pods left to drain: %s", formatBlockingPods(status.BlockingPods))
+		}
+	}
+	return status
+}
+
+// formatBlockingPods lists pods left on the node by the controller's drain attempts.
+func formatBlockingPods(pods []ctrlcommon.BlockingPod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// errDrainFailed is returned once the controller gave up on draining the node.
+var errDrainFailed = errors.New("drain failed")
+
 // isDrainRequired determines whether node drain is required or not to apply config changes.
 func isDrainRequired(actions, diffFileSet []string, oldIgnConfig, newIgnConfig ign3types.Config) (bool, error) {
 	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
@@ -114,11 +170,28 @@ func isDrainRequired(actions, diffFileSet []string, oldIgnConfig, newIgnConfig i
 		return false, nil
 	} else if ctrlcommon.InSlice(postConfigChangeActionNone, actions) {
 		return false, nil
+	} else if len(actions) > 0 && onlyRebootlessPostConfigChangeActions(actions) {
+		// Reloading, restarting or stopping single units and deferring a reboot is meant to avoid disruption
+		return false, nil
 	}
 	// For any unhandled cases, default to drain
 	return true, nil
 }
 
+// onlyRebootlessPostConfigChangeActions returns true if every action reloads, restarts or stops
+// a unit, or defers the reboot.
+func onlyRebootlessPostConfigChangeActions(actions []string) bool {
+	for _, action := range actions {
+		if action == postConfigChangeActionDaemonReload || action == postConfigChangeActionDeferReboot {
+			continue
+		}
+		if _, _, ok := parseUnitPostConfigChangeAction(action); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // isSafeContainerRegistryConfChanges looks inside old and new versions of registries.conf file.
 // It compares the content and determines whether changes made are safe or not. This will
 // help MCD to decide whether we can skip node drain for applied changes into container
@@ -356,6 +356,34 @@ location = "mirror.com/repo/test-img-14"
 			newConfig:      machineConfigs["mc1"],
 			expectedAction: true,
 		},
+		{
+			// skip drain: only unit reload and restart actions are present
+			actions:        []string{"reload sshd.service", "restart chronyd.service"},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: false,
+		},
+		{
+			// skip drain: only actions applying unit changes are present
+			actions:        []string{postConfigChangeActionDaemonReload, "try-restart foo.service", "stop bar.service"},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: false,
+		},
+		{
+			// skip drain: reboot is deferred
+			actions:        []string{postConfigChangeActionDeferReboot},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: false,
+		},
+		{
+			// perform drain: no actions
+			actions:        []string{},
+			oldConfig:      machineConfigs["mc1"],
+			newConfig:      machineConfigs["mc1"],
+			expectedAction: true,
+		},
 		// below tests are run when only crio reload action is present
 		{
 			// skip drain: no changes in registry config
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -54,10 +55,7 @@ func createOrigFile(fromPath, fpath string) error {
 		// create a noorig file that tells the MCD that the file wasn't present on disk before MCD
 		// took over so it can just remove it when deleting stale data, as opposed as restoring a file
 		// that was shipped _with_ the underlying OS (e.g. a default chrony config).
-		if makeErr := os.MkdirAll(filepath.Dir(noOrigFileStampName(fpath)), 0o755); makeErr != nil {
-			return fmt.Errorf("creating no orig parent dir: %w", makeErr)
-		}
-		return writeFileAtomicallyWithDefaults(noOrigFileStampName(fpath), nil)
+		return createNoOrigFileStamp(fpath)
 	}
 
 	// https://bugzilla.redhat.com/show_bug.cgi?id=1970959
@@ -80,6 +78,13 @@ func createOrigFile(fromPath, fpath string) error {
 	return nil
 }
 
+func createNoOrigFileStamp(fpath string) error {
+	if err := os.MkdirAll(filepath.Dir(noOrigFileStampName(fpath)), 0o755); err != nil {
+		return fmt.Errorf("creating no orig parent dir: %w", err)
+	}
+	return writeFileAtomicallyWithDefaults(noOrigFileStampName(fpath), nil)
+}
+
 func writeFileAtomicallyWithDefaults(fpath string, b []byte) error {
 	return writeFileAtomically(fpath, b, defaultDirectoryPermissions, defaultFilePermissions, -1, -1)
 }
@@ -153,7 +158,17 @@ func writeDropins(u ign3types.Unit, systemdRoot string, isCoreOSVariant bool) er
 
 // writeFiles writes the given files to disk.
 // it doesn't fetch remote files and expects a flattened config file.
-func writeFiles(files []ign3types.File, skipCertificateWrite bool) error {
+// oldFiles are the files of the config currently on disk, whose appends are stripped
+// from the files backed up by createOrigFile.
+func writeFiles(oldFiles, files []ign3types.File, skipCertificateWrite bool) error {
+	oldAppends := make(map[string][]byte, len(oldFiles))
+	for _, file := range oldFiles {
+		appended, err := fileAppends(file)
+		if err != nil {
+			return err
+		}
+		oldAppends[file.Path] = appended
+	}
 	for _, file := range files {
 		if skipCertificateWrite && file.Path == caBundleFilePath {
 			// TODO remove this special case once we have a better way to do this
@@ -162,28 +177,23 @@ func writeFiles(files []ign3types.File, skipCertificateWrite bool) error {
 		}
 		glog.Infof("Writing file %q", file.Path)
 
-		// We don't support appends in the file section, so instead of waiting to fail validation,
-		// let's explicitly fail here.
-		if len(file.Append) > 0 {
-			return fmt.Errorf("found an append section when writing files. Append is not supported")
-		}
-
-		decodedContents, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
-		if err != nil {
-			return fmt.Errorf("could not decode file %q: %w", file.Path, err)
-		}
-
 		mode := defaultFilePermissions
 		if file.Mode != nil {
 			mode = os.FileMode(*file.Mode)
 		}
 
 		// set chown if file information is provided
-		uid, gid, err := getFileOwnership(file)
+		uid, gid, err := getNodeOwnership(file.Node)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve file ownership for file %q: %w", file.Path, err)
 		}
-		if err := createOrigFile(file.Path, file.Path); err != nil {
+		if err := createPristineOrigFile(file.Path, oldAppends[file.Path]); err != nil {
+			return err
+		}
+		// the contents of files with appends are built from the orig file, so they
+		// must be computed after it has been backed up.
+		decodedContents, err := fileContents(file)
+		if err != nil {
 			return err
 		}
 		if err := writeFileAtomically(file.Path, decodedContents, defaultDirectoryPermissions, mode, uid, gid); err != nil {
@@ -193,6 +203,208 @@ func writeFiles(files []ign3types.File, skipCertificateWrite bool) error {
 	return nil
 }
 
+// createPristineOrigFile backs up fpath like createOrigFile and, if the backup is new,
+// strips the appends that were applied to fpath, so that the orig file holds the contents
+// fpath had before any append.
+func createPristineOrigFile(fpath string, applied []byte) error {
+	_, err := os.Lstat(origFileName(fpath))
+	existed := err == nil
+	if err := createOrigFile(fpath, fpath); err != nil {
+		return err
+	}
+	if existed || len(applied) == 0 {
+		return nil
+	}
+	contents, err := os.ReadFile(origFileName(fpath))
+	if os.IsNotExist(err) {
+		// fpath didn't exist and has a noorig stamp instead
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read orig file of %q: %w", fpath, err)
+	}
+	if !bytes.HasSuffix(contents, applied) {
+		return nil
+	}
+	fi, err := os.Stat(origFileName(fpath))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(origFileName(fpath), bytes.TrimSuffix(contents, applied), defaultDirectoryPermissions, fi.Mode(), -1, -1)
+}
+
+// fileContents returns the contents file should have on disk. The appends of a file are applied in
+// order on top of its contents or, if it has none, on top of the original file backed up by
+// createOrigFile, so that applying a config again never duplicates appended content.
+func fileContents(file ign3types.File) ([]byte, error) {
+	contents, err := ctrlcommon.DecodeIgnitionFileContents(file.Contents.Source, file.Contents.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode file %q: %w", file.Path, err)
+	}
+	if len(file.Append) == 0 {
+		return contents, nil
+	}
+
+	appended, err := fileAppends(file)
+	if err != nil {
+		return nil, err
+	}
+	if file.Contents.Source == nil {
+		if contents, err = origFileContents(file.Path, appended); err != nil {
+			return nil, err
+		}
+	}
+	return append(contents, appended...), nil
+}
+
+// fileAppends returns the decoded appends of file, concatenated in order.
+func fileAppends(file ign3types.File) ([]byte, error) {
+	var appended []byte
+	for _, resource := range file.Append {
+		decoded, err := ctrlcommon.DecodeIgnitionFileContents(resource.Source, resource.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode append to file %q: %w", file.Path, err)
+		}
+		appended = append(appended, decoded...)
+	}
+	return appended, nil
+}
+
+// origFileContents returns the contents of fpath before the MCD first wrote it: nothing if it
+// didn't exist, the contents of its orig file if it was backed up, or otherwise its current
+// contents without the applied appends, since the MCD hasn't written it yet and Ignition
+// applied them when provisioning the node.
+func origFileContents(fpath string, applied []byte) ([]byte, error) {
+	if _, err := os.Stat(noOrigFileStampName(fpath)); err == nil {
+		return nil, nil
+	}
+	contents, err := os.ReadFile(origFileName(fpath))
+	if err == nil {
+		return contents, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read original contents of file %q: %w", fpath, err)
+	}
+	contents, err = os.ReadFile(fpath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read original contents of file %q: %w", fpath, err)
+	}
+	return bytes.TrimSuffix(contents, applied), nil
+}
+
+// directoryMode returns the os.FileMode of the given directory. Ignition gives the setuid,
+// setgid and sticky bits as Unix mode bits, which os.FileMode keeps elsewhere.
+func directoryMode(dir ign3types.Directory) os.FileMode {
+	if dir.Mode == nil {
+		return defaultDirectoryPermissions
+	}
+	mode := os.FileMode(*dir.Mode) & os.ModePerm
+	if *dir.Mode&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if *dir.Mode&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if *dir.Mode&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// writeDirectories creates the given directories and sets their mode and ownership.
+// Directories that didn't exist are stamped so that deleteStaleData() can remove them.
+func writeDirectories(dirs []ign3types.Directory) error {
+	for _, dir := range dirs {
+		glog.Infof("Writing directory %q", dir.Path)
+
+		mode := directoryMode(dir)
+		uid, gid, err := getNodeOwnership(dir.Node)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve directory ownership for directory %q: %w", dir.Path, err)
+		}
+
+		fi, err := os.Lstat(dir.Path)
+		switch {
+		case os.IsNotExist(err):
+			if err := createNoOrigFileStamp(dir.Path); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir.Path, defaultDirectoryPermissions); err != nil {
+				return fmt.Errorf("failed to create directory %q: %w", dir.Path, err)
+			}
+		case err != nil:
+			return fmt.Errorf("could not stat directory %q: %w", dir.Path, err)
+		case !fi.IsDir():
+			return fmt.Errorf("cannot create directory %q: a file already exists at this path", dir.Path)
+		}
+
+		if err := os.Chmod(dir.Path, mode); err != nil {
+			return fmt.Errorf("failed to set mode of directory %q: %w", dir.Path, err)
+		}
+		if err := os.Chown(dir.Path, uid, gid); err != nil {
+			return fmt.Errorf("failed to set ownership of directory %q: %w", dir.Path, err)
+		}
+	}
+	return nil
+}
+
+// writeLinks creates the given symbolic and hard links, backing up whatever was at their path.
+func writeLinks(links []ign3types.Link) error {
+	for _, link := range links {
+		if link.Target == "" {
+			return fmt.Errorf("link %q has no target", link.Path)
+		}
+		glog.Infof("Writing link %q to %q", link.Path, link.Target)
+
+		uid, gid, err := getNodeOwnership(link.Node)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve link ownership for link %q: %w", link.Path, err)
+		}
+		if err := createOrigFile(link.Path, link.Path); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(link.Path), defaultDirectoryPermissions); err != nil {
+			return fmt.Errorf("failed to create directory %q: %w", filepath.Dir(link.Path), err)
+		}
+
+		if link.Hard != nil && *link.Hard {
+			// A hard link shares the ownership of its target, so there's nothing to chown.
+			if err := writeHardLinkAtomically(link.Target, link.Path); err != nil {
+				return fmt.Errorf("failed to write hard link %q: %w", link.Path, err)
+			}
+			continue
+		}
+		if err := renameio.Symlink(link.Target, link.Path); err != nil {
+			return fmt.Errorf("failed to write symlink %q: %w", link.Path, err)
+		}
+		if err := os.Lchown(link.Path, uid, gid); err != nil {
+			return fmt.Errorf("failed to set ownership of symlink %q: %w", link.Path, err)
+		}
+	}
+	return nil
+}
+
+// writeHardLinkAtomically links target to a temporary path next to fpath and renames it over fpath.
+func writeHardLinkAtomically(target, fpath string) error {
+	tmpPath := filepath.Join(filepath.Dir(fpath), "."+filepath.Base(fpath)+".mcdtmp")
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(target, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, fpath); err != nil {
+		if rmErr := os.Remove(tmpPath); rmErr != nil {
+			glog.Warningf("failed to remove %q: %v", tmpPath, rmErr)
+		}
+		return err
+	}
+	return nil
+}
+
 // writeUnit writes a systemd unit and its dropins to disk
 func writeUnit(u ign3types.Unit, systemdRoot string, isCoreOSVariant bool) error {
 	if err := writeDropins(u, systemdRoot, isCoreOSVariant); err != nil {
@@ -279,22 +491,22 @@ func lookupGID(group string) (int, error) {
 }
 
 // This is essentially ResolveNodeUidAndGid() from Ignition; XXX should dedupe
-func getFileOwnership(file ign3types.File) (int, int, error) {
+func getNodeOwnership(node ign3types.Node) (int, int, error) {
 	uid, gid := 0, 0 // default to root
 	var err error    // create default error var
-	if file.User.ID != nil {
-		uid = *file.User.ID
-	} else if file.User.Name != nil && *file.User.Name != "" {
-		uid, err = lookupUID(*file.User.Name)
+	if node.User.ID != nil {
+		uid = *node.User.ID
+	} else if node.User.Name != nil && *node.User.Name != "" {
+		uid, err = lookupUID(*node.User.Name)
 		if err != nil {
 			return uid, gid, err
 		}
 	}
 
-	if file.Group.ID != nil {
-		gid = *file.Group.ID
-	} else if file.Group.Name != nil && *file.Group.Name != "" {
-		gid, err = lookupGID(*file.Group.Name)
+	if node.Group.ID != nil {
+		gid = *node.Group.ID
+	} else if node.Group.Name != nil && *node.Group.Name != "" {
+		gid, err = lookupGID(*node.Group.Name)
 		if err != nil {
 			return uid, gid, err
 		}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// defaultHealthGatesTimeout is how long health gates without a timeout have to pass.
+	defaultHealthGatesTimeout = 10 * time.Minute
+	// healthGatesInterval is how often failing health gates are checked again.
+	healthGatesInterval = 10 * time.Second
+	// healthCheckTimeout is how long a single kubelet, unit or probe check may take.
+	healthCheckTimeout = 10 * time.Second
+)
+
+// runHealthGates waits for pool's health gates to pass once the node booted into configName.
+// Nodes whose gates don't pass in time are annotated so that the pool's rollout halts.
+func (dn *Daemon) runHealthGates(pool *mcfgv1.MachineConfigPool, configName string) error {
+	if pool == nil || pool.Spec.HealthGates == nil {
+		return nil
+	}
+	gates := pool.Spec.HealthGates
+	timeout := defaultHealthGatesTimeout
+	if gates.Timeout != nil && gates.Timeout.Duration > 0 {
+		timeout = gates.Timeout.Duration
+	}
+
+	logSystem("Waiting up to %s for health gates of config %s", timeout, configName)
+	var failures []string
+	if err := wait.PollImmediate(healthGatesInterval, timeout, func() (bool, error) {
+		failures = checkHealthGates(gates, dn.kubeletHealthzEndpoint)
+		if len(failures) > 0 {
+			glog.Infof("Health gates of config %s not passing yet: %s", configName, strings.Join(failures, "; "))
+		}
+		return len(failures) == 0, nil
+	}); err != nil {
+		err = fmt.Errorf("health gates of config %s did not pass within %s: %s", configName, timeout, strings.Join(failures, "; "))
+		if dn.nodeWriter != nil {
+			dn.nodeWriter.Eventf(corev1.EventTypeWarning, "HealthGateFailed", err.Error())
+			if _, annoErr := dn.nodeWriter.SetAnnotations(map[string]string{constants.HealthGateFailedAnnotationKey: configName}); annoErr != nil {
+				glog.Warningf("Failed to annotate node as failing health gates: %v", annoErr)
+			}
+		}
+		return err
+	}
+
+	logSystem("Health gates of config %s passed", configName)
+	if dn.nodeWriter != nil {
+		dn.nodeWriter.Eventf(corev1.EventTypeNormal, "HealthGatesPassed", "Health gates of config %s passed", configName)
+		if dn.node != nil && dn.node.Annotations[constants.HealthGateFailedAnnotationKey] != "" {
+			if _, err := dn.nodeWriter.SetAnnotations(map[string]string{constants.HealthGateFailedAnnotationKey: ""}); err != nil {
+				return fmt.Errorf("failed to clear %s annotation: %w", constants.HealthGateFailedAnnotationKey, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkHealthGates checks all of gates once and returns why those that failed did, checking
+// the kubelet with kubeletHealthzEndpoint.
+func checkHealthGates(gates *mcfgv1.HealthGates, kubeletHealthzEndpoint string) []string {
+	var failures []string
+	if gates.Kubelet {
+		if err := checkHTTPHealth(kubeletHealthzEndpoint, true); err != nil {
+			failures = append(failures, fmt.Sprintf("kubelet: %v", err))
+		}
+	}
+	for _, unit := range gates.Units {
+		if err := checkUnitActive(unit); err != nil {
+			failures = append(failures, fmt.Sprintf("unit %s: %v", unit, err))
+		}
+	}
+	for i := range gates.Probes {
+		probe := &gates.Probes[i]
+		if err := runHealthProbe(probe); err != nil {
+			failures = append(failures, fmt.Sprintf("probe %s: %v", probe.Name, err))
+		}
+	}
+	return failures
+}
+
+// checkHTTPHealth checks that url responds with a 2xx status, and with "ok" if requireOK is set
+// as healthz endpoints do.
+func checkHTTPHealth(url string, requireOK bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if requireOK && strings.TrimSpace(string(body)) != "ok" {
+		return fmt.Errorf("%s returned %q", url, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// checkUnitActive checks that the systemd unit is active.
+func checkUnitActive(unit string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "systemctl", "is-active", unit).Output()
+	if err != nil {
+		if state := strings.TrimSpace(string(out)); state != "" {
+			return fmt.Errorf("%s", state)
+		}
+		return err
+	}
+	return nil
+}
+
+// runHealthProbe runs probe once.
+func runHealthProbe(probe *mcfgv1.HealthProbe) error {
+	switch {
+	case probe.HTTPGet != "" && len(probe.Command) == 0:
+		return checkHTTPHealth(probe.HTTPGet, false)
+	case probe.HTTPGet == "" && len(probe.Command) > 0:
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, probe.Command[0], probe.Command[1:]...).CombinedOutput()
+		if err != nil {
+			output := strings.TrimSpace(string(out))
+			if len(output) > maxUpdateHookOutput {
+				output = "..." + output[len(output)-maxUpdateHookOutput:]
+			}
+			return fmt.Errorf("%w, output: %s", err, output)
+		}
+		return nil
+	default:
+		return fmt.Errorf("exactly one of httpGet and command must be set")
+	}
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckHealthGates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			fmt.Fprint(w, "ok")
+		case "/starting":
+			fmt.Fprint(w, "starting")
+		default:
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	healthy := &mcfgv1.HealthGates{
+		Kubelet: true,
+		Probes: []mcfgv1.HealthProbe{
+			{Name: "sdn", HTTPGet: server.URL + "/healthz"},
+			{Name: "exec", Command: []string{"true"}},
+		},
+	}
+	assert.Empty(t, checkHealthGates(healthy, server.URL+"/healthz"))
+
+	unhealthy := &mcfgv1.HealthGates{
+		Kubelet: true,
+		Probes: []mcfgv1.HealthProbe{
+			{Name: "sdn", HTTPGet: server.URL + "/broken"},
+			{Name: "exec", Command: []string{"sh", "-c", "echo not ready; exit 1"}},
+			{Name: "invalid"},
+		},
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf("kubelet: %s/starting returned \"starting\"", server.URL),
+		fmt.Sprintf("probe sdn: %s/broken returned 503 Service Unavailable: unhealthy", server.URL),
+		"probe exec: exit status 1, output: not ready",
+		"probe invalid: exactly one of httpGet and command must be set",
+	}, checkHealthGates(unhealthy, server.URL+"/starting"))
+}
+
+func TestRunHealthGates(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	recorder := &annotationRecorder{annotations: map[string]string{}}
+	dn := newMockDaemon()
+	dn.nodeWriter = recorder
+	dn.node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{}}}
+
+	// Pools without health gates don't wait.
+	require.NoError(t, dn.runHealthGates(pool, "rendered-worker-1"))
+	assert.Empty(t, recorder.annotations)
+
+	pool.Spec.HealthGates = &mcfgv1.HealthGates{
+		Timeout: &metav1.Duration{Duration: 100 * time.Millisecond},
+		Probes:  []mcfgv1.HealthProbe{{Name: "exec", Command: []string{"false"}}},
+	}
+	assert.EqualError(t, dn.runHealthGates(pool, "rendered-worker-1"),
+		"health gates of config rendered-worker-1 did not pass within 100ms: probe exec: exit status 1, output: ")
+	assert.Equal(t, map[string]string{constants.HealthGateFailedAnnotationKey: "rendered-worker-1"}, recorder.annotations)
+
+	// Passing gates clear the annotation.
+	dn.node.Annotations = recorder.annotations
+	recorder.annotations = map[string]string{}
+	pool.Spec.HealthGates.Probes[0].Command = []string{"true"}
+	require.NoError(t, dn.runHealthGates(pool, "rendered-worker-1"))
+	assert.Equal(t, map[string]string{constants.HealthGateFailedAnnotationKey: ""}, recorder.annotations)
+}
+
+func TestPerformPostConfigChangeActionHealthGates(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	pool.Spec.HealthGates = &mcfgv1.HealthGates{
+		Timeout: &metav1.Duration{Duration: 100 * time.Millisecond},
+		Probes:  []mcfgv1.HealthProbe{{Name: "exec", Command: []string{"false"}}},
+	}
+	recorder := &annotationRecorder{annotations: map[string]string{}}
+	dn := newMockDaemon()
+	dn.nodeWriter = recorder
+	dn.node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{}}}
+
+	// Rebootless updates fail on failing gates before the node is marked Done.
+	assert.EqualError(t, dn.performPostConfigChangeAction(pool, []string{postConfigChangeActionNone}, "rendered-worker-1"),
+		"could not apply update: health gates of config rendered-worker-1 did not pass within 100ms: probe exec: exit status 1, output: ")
+	assert.Equal(t, map[string]string{constants.HealthGateFailedAnnotationKey: "rendered-worker-1"}, recorder.annotations)
+}
@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultUpdateHookTimeout is how long hooks without a timeout may run.
+	defaultUpdateHookTimeout = 5 * time.Minute
+	// maxUpdateHookOutput caps how much of a failing hook's output ends up in its error,
+	// which is used as the node's degraded reason.
+	maxUpdateHookOutput = 1024
+)
+
+// runUpdateHooks runs pool's update hooks for phase, in order, for an update to configName.
+// It stops at the first failing hook.
+func (dn *Daemon) runUpdateHooks(pool *mcfgv1.MachineConfigPool, phase mcfgv1.UpdateHookPhase, configName string) error {
+	if pool == nil {
+		return nil
+	}
+	for i := range pool.Spec.UpdateHooks {
+		hook := &pool.Spec.UpdateHooks[i]
+		if hook.Phase != phase {
+			continue
+		}
+		logSystem("Running %s hook %s for config %s", phase, hook.Name, configName)
+		if err := runUpdateHook(hook, configName); err != nil {
+			if dn.nodeWriter != nil {
+				dn.nodeWriter.Eventf(corev1.EventTypeWarning, "UpdateHookFailed", err.Error())
+			}
+			return err
+		}
+		if dn.nodeWriter != nil {
+			dn.nodeWriter.Eventf(corev1.EventTypeNormal, "UpdateHook", "Ran %s hook %s for config %s", phase, hook.Name, configName)
+		}
+	}
+	return nil
+}
+
+// runUpdateHook runs a single hook until it exits or its timeout expires. The error of
+// a failing hook includes the tail of its output.
+func runUpdateHook(hook *mcfgv1.UpdateHook, configName string) error {
+	timeout := defaultUpdateHookTimeout
+	if hook.Timeout != nil && hook.Timeout.Duration > 0 {
+		timeout = hook.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch {
+	case len(hook.Command) > 0 && hook.Unit == "":
+		cmd = exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+		cmd.Env = append(os.Environ(), "MCD_HOOK_PHASE="+string(hook.Phase), "MCD_CONFIG="+configName)
+	case len(hook.Command) == 0 && hook.Unit != "":
+		cmd = exec.CommandContext(ctx, "systemctl", "start", hook.Unit)
+	default:
+		return fmt.Errorf("%s hook %s: exactly one of command and unit must be set", hook.Phase, hook.Name)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if hook.Unit != "" {
+		// systemctl only points at the journal, fetch the unit's latest logs instead.
+		if logs, logErr := exec.Command("journalctl", "--unit", hook.Unit, "--lines", "20", "--no-pager", "--output", "cat").Output(); logErr == nil {
+			out = append(out, logs...)
+		} else {
+			glog.Warningf("Failed to get logs of %s hook unit %s: %v", hook.Phase, hook.Unit, logErr)
+		}
+	}
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxUpdateHookOutput {
+		output = "..." + output[len(output)-maxUpdateHookOutput:]
+	}
+	return fmt.Errorf("%s hook %s failed: %w, output: %s", hook.Phase, hook.Name, err, output)
+}
@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunUpdateHook(t *testing.T) {
+	tests := []struct {
+		name string
+		hook mcfgv1.UpdateHook
+		err  string
+	}{{
+		name: "succeeding command",
+		hook: mcfgv1.UpdateHook{Name: "check", Phase: mcfgv1.UpdateHookPostBoot, Command: []string{"sh", "-c", `test "$MCD_HOOK_PHASE/$MCD_CONFIG" = PostBoot/rendered-worker-1`}},
+	}, {
+		name: "failing command",
+		hook: mcfgv1.UpdateHook{Name: "check", Phase: mcfgv1.UpdateHookPostBoot, Command: []string{"sh", "-c", "echo database not ready; exit 3"}},
+		err:  "PostBoot hook check failed: exit status 3, output: database not ready",
+	}, {
+		name: "timeout",
+		hook: mcfgv1.UpdateHook{Name: "flush", Phase: mcfgv1.UpdateHookPreDrain, Command: []string{"sleep", "10"}, Timeout: &metav1.Duration{Duration: 100 * time.Millisecond}},
+		err:  "PreDrain hook flush failed: timed out after 100ms, output: ",
+	}, {
+		name: "neither command nor unit",
+		hook: mcfgv1.UpdateHook{Name: "empty", Phase: mcfgv1.UpdateHookPreReboot},
+		err:  "PreReboot hook empty: exactly one of command and unit must be set",
+	}, {
+		name: "both command and unit",
+		hook: mcfgv1.UpdateHook{Name: "both", Phase: mcfgv1.UpdateHookPreReboot, Command: []string{"true"}, Unit: "quiesce.service"},
+		err:  "PreReboot hook both: exactly one of command and unit must be set",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := runUpdateHook(&test.hook, "rendered-worker-1")
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestRunUpdateHookTruncatesOutput(t *testing.T) {
+	hook := mcfgv1.UpdateHook{Name: "noisy", Phase: mcfgv1.UpdateHookPostBoot, Command: []string{"sh", "-c", "head -c 5000 /dev/zero | tr '\\0' a; echo the end; exit 1"}}
+	err := runUpdateHook(&hook, "rendered-worker-1")
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), "the end"))
+	assert.Less(t, len(err.Error()), maxUpdateHookOutput+100)
+}
+
+func TestRunUpdateHooks(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	appendHook := func(name string, phase mcfgv1.UpdateHookPhase) mcfgv1.UpdateHook {
+		return mcfgv1.UpdateHook{Name: name, Phase: phase, Command: []string{"sh", "-c", "echo " + name + " >> " + log}}
+	}
+	pool := &mcfgv1.MachineConfigPool{
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			UpdateHooks: []mcfgv1.UpdateHook{
+				appendHook("quiesce", mcfgv1.UpdateHookPreDrain),
+				appendHook("smoke", mcfgv1.UpdateHookPostBoot),
+				appendHook("notify", mcfgv1.UpdateHookPreDrain),
+				{Name: "fail", Phase: mcfgv1.UpdateHookPreReboot, Command: []string{"false"}},
+				appendHook("unreached", mcfgv1.UpdateHookPreReboot),
+			},
+		},
+	}
+
+	dn := &Daemon{}
+	assert.NoError(t, dn.runUpdateHooks(nil, mcfgv1.UpdateHookPreDrain, "rendered-worker-1"))
+	assert.NoError(t, dn.runUpdateHooks(pool, mcfgv1.UpdateHookPreDrain, "rendered-worker-1"))
+	assert.Error(t, dn.runUpdateHooks(pool, mcfgv1.UpdateHookPreReboot, "rendered-worker-1"))
+
+	out, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "quiesce\nnotify\n", string(out))
+}
+
+func TestFinishUpdatePreRebootHookFailure(t *testing.T) {
+	// Stub out logger so that the pending states stored to the journal can be checked.
+	bin := t.TempDir()
+	journal := filepath.Join(bin, "journal")
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "logger"), []byte("#!/bin/sh\nif pending=$(grep PENDING); then echo \"$pending\" >> "+journal+"; fi\n"), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dn := newMockDaemon()
+	dn.loggerSupportsJournal = true
+	newConfig := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "rendered-worker-2"}}
+	pool := &mcfgv1.MachineConfigPool{
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			UpdateHooks: []mcfgv1.UpdateHook{{Name: "fail", Phase: mcfgv1.UpdateHookPreReboot, Command: []string{"false"}}},
+		},
+	}
+
+	err := dn.finishUpdate(pool, newConfig, []string{postConfigChangeActionReboot})
+	assert.EqualError(t, err, "PreReboot hook fail failed: exit status 1, output: ")
+	// The config isn't stored as pending, it was never applied.
+	_, err = os.Stat(journal)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, dn.finalizeBeforeReboot(newConfig))
+	out, err := os.ReadFile(journal)
+	require.NoError(t, err)
+	assert.Equal(t, "PENDING=1\n", string(out))
+}
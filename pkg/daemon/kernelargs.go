@@ -28,6 +28,52 @@ var tuneableRHCOSArgsAllowlist = map[string]bool{
 	"nosmt": true,
 }
 
+// deferrableKernelArgsAllowlist contains the keys of kernel arguments whose changes can wait for the
+// next reboot: they either only take effect at boot anyway or have a runtime equivalent (sysctl,
+// sysfs) that workloads don't depend on being set at boot.
+var deferrableKernelArgsAllowlist = map[string]bool{
+	"audit":                true,
+	"audit_backlog_limit":  true,
+	"default_hugepagesz":   true,
+	"hugepages":            true,
+	"hugepagesz":           true,
+	"mitigations":          true,
+	"nosmt":                true,
+	"panic":                true,
+	"slab_nomerge":         true,
+	"transparent_hugepage": true,
+}
+
+// isKernelArgDeferrable returns if a change to the kernel argument can wait for the next reboot
+func isKernelArgDeferrable(arg string) bool {
+	key, _, _ := strings.Cut(arg, "=")
+	return deferrableKernelArgsAllowlist[key]
+}
+
+// areKernelArgsChangesDeferrable returns true if every kernel argument added or removed
+// between oldKernelArguments and newKernelArguments can wait for the next reboot
+func areKernelArgsChangesDeferrable(oldKernelArguments, newKernelArguments []string) bool {
+	oldKargs := parseKernelArguments(oldKernelArguments)
+	newKargs := parseKernelArguments(newKernelArguments)
+	oldSet := make(map[string]bool, len(oldKargs))
+	for _, arg := range oldKargs {
+		oldSet[arg] = true
+	}
+	newSet := make(map[string]bool, len(newKargs))
+	for _, arg := range newKargs {
+		newSet[arg] = true
+		if !oldSet[arg] && !isKernelArgDeferrable(arg) {
+			return false
+		}
+	}
+	for _, arg := range oldKargs {
+		if !newSet[arg] && !isKernelArgDeferrable(arg) {
+			return false
+		}
+	}
+	return true
+}
+
 // isArgTuneable returns if the argument provided is allowed to be modified
 func isArgTunable(arg string) (bool, error) {
 	os, err := osrelease.GetHostRunningOS()
@@ -57,6 +57,13 @@ var (
 			Name: "mcd_update_state",
 			Help: "completed update config or error",
 		}, []string{"config", "err"})
+
+	// mcdConfigDriftRemediations tallys config drift remediations by result
+	mcdConfigDriftRemediations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcd_config_drift_remediations_total",
+			Help: "Total number of config drift remediations, by result (succeeded or failed).",
+		}, []string{"result"})
 )
 
 // Updates metric with new labels & timestamp, deletes any existing
@@ -78,6 +85,7 @@ func RegisterMCDMetrics() error {
 		kubeletHealthState,
 		mcdRebootErr,
 		mcdUpdateState,
+		mcdConfigDriftRemediations,
 	})
 
 	if err != nil {
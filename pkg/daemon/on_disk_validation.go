@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	ign2types "github.com/coreos/ignition/config/v2_2/types"
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
@@ -29,9 +30,18 @@ func validateOnDiskState(currentConfig *mcfgv1.MachineConfig, systemdPath string
 		if err := checkV3Files(ignconfigi.(ign3types.Config).Storage.Files); err != nil {
 			return &fileConfigDriftErr{err}
 		}
+		if err := checkV3Directories(ignconfigi.(ign3types.Config).Storage.Directories); err != nil {
+			return &fileConfigDriftErr{err}
+		}
+		if err := checkV3Links(ignconfigi.(ign3types.Config).Storage.Links); err != nil {
+			return &fileConfigDriftErr{err}
+		}
 		if err := checkV3Units(ignconfigi.(ign3types.Config).Systemd.Units, systemdPath); err != nil {
 			return &unitConfigDriftErr{err}
 		}
+		if err := checkLocalUsers(ignconfigi.(ign3types.Config).Passwd); err != nil {
+			return &userConfigDriftErr{err}
+		}
 		return nil
 	case ign2types.Config:
 		if err := checkV2Files(ignconfigi.(ign2types.Config).Storage.Files); err != nil {
@@ -179,16 +189,14 @@ func checkV3Files(files []ign3types.File) error {
 			glog.V(4).Infof("Skipping file %s during checkV3Files", caBundleFilePath)
 			continue
 		}
-		if len(f.Append) > 0 {
-			return fmt.Errorf("found an append section when checking files. Append is not supported")
-		}
 		mode := defaultFilePermissions
 		if f.Mode != nil {
 			mode = os.FileMode(*f.Mode)
 		}
-		contents, err := ctrlcommon.DecodeIgnitionFileContents(f.Contents.Source, f.Contents.Compression)
+		// files with appends are expected to match their orig file with the appends applied
+		contents, err := fileContents(f)
 		if err != nil {
-			return fmt.Errorf("couldn't decode file %q: %w", f.Path, err)
+			return err
 		}
 		if err := checkFileContentsAndMode(f.Path, contents, mode); err != nil {
 			return err
@@ -197,6 +205,62 @@ func checkV3Files(files []ign3types.File) error {
 	return nil
 }
 
+// checkV3Directories validates the existence, mode and ownership of all the directories in
+// the target config.
+func checkV3Directories(dirs []ign3types.Directory) error {
+	for _, d := range dirs {
+		mode := directoryMode(d)
+		fi, err := os.Lstat(d.Path)
+		if err != nil {
+			return fmt.Errorf("could not stat directory %q: %w", d.Path, err)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("%q is not a directory", d.Path)
+		}
+		if fi.Mode() != mode|os.ModeDir {
+			return fmt.Errorf("mode mismatch for directory: %q; expected: %[2]v/%[2]d/%#[2]o; received: %[3]v/%[3]d/%#[3]o", d.Path, mode|os.ModeDir, fi.Mode())
+		}
+		uid, gid, err := getNodeOwnership(d.Node)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve directory ownership for directory %q: %w", d.Path, err)
+		}
+		if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+			if (uid != -1 && int(stat.Uid) != uid) || (gid != -1 && int(stat.Gid) != gid) {
+				return fmt.Errorf("ownership mismatch for directory: %q; expected: %d:%d; received: %d:%d", d.Path, uid, gid, stat.Uid, stat.Gid)
+			}
+		}
+	}
+	return nil
+}
+
+// checkV3Links validates that all the links in the target config point to their target.
+func checkV3Links(links []ign3types.Link) error {
+	for _, l := range links {
+		if l.Hard != nil && *l.Hard {
+			fi, err := os.Lstat(l.Path)
+			if err != nil {
+				return fmt.Errorf("could not stat hard link %q: %w", l.Path, err)
+			}
+			targetFi, err := os.Lstat(l.Target)
+			if err != nil {
+				return fmt.Errorf("could not stat target of hard link %q: %w", l.Path, err)
+			}
+			if !os.SameFile(fi, targetFi) {
+				return fmt.Errorf("hard link %q does not link to %q", l.Path, l.Target)
+			}
+			continue
+		}
+		target, err := os.Readlink(l.Path)
+		if err != nil {
+			return fmt.Errorf("could not read symlink %q: %w", l.Path, err)
+		}
+		if target != l.Target {
+			return fmt.Errorf("target mismatch for symlink: %q; expected: %q; received: %q", l.Path, l.Target, target)
+		}
+	}
+	return nil
+}
+
 // checkV2Files validates the contents of all the files in the target config.
 func checkV2Files(files []ign2types.File) error {
 	checkedFiles := make(map[string]bool)
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"errors"
+	"reflect"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// UpdatePreview reports what the MachineConfigDaemon would do to update a node from one
+// rendered MachineConfig to another, without changing anything.
+type UpdatePreview struct {
+	// OldConfig is the name of the rendered config the update starts from.
+	OldConfig string `json:"oldConfig"`
+	// Reconcilable is false if the update can't be applied in place and needs a reprovision.
+	Reconcilable bool `json:"reconcilable"`
+	// Errors are the reasons the update would fail.
+	Errors []string `json:"errors,omitempty"`
+
+	// Files are the paths of files written or removed.
+	Files []string `json:"files,omitempty"`
+	// Units are the names of systemd units added, changed or removed.
+	Units []string `json:"units,omitempty"`
+	// KernelArgumentsAdded are the kernel arguments added.
+	KernelArgumentsAdded []string `json:"kernelArgumentsAdded,omitempty"`
+	// KernelArgumentsRemoved are the kernel arguments removed.
+	KernelArgumentsRemoved []string `json:"kernelArgumentsRemoved,omitempty"`
+	// ExtensionsAdded are the RHCOS extensions installed.
+	ExtensionsAdded []string `json:"extensionsAdded,omitempty"`
+	// ExtensionsRemoved are the RHCOS extensions uninstalled.
+	ExtensionsRemoved []string `json:"extensionsRemoved,omitempty"`
+	// OSUpdate is set if the OS image changes.
+	OSUpdate bool `json:"osUpdate"`
+	// KernelType is the new kernel type, if it changes.
+	KernelType string `json:"kernelType,omitempty"`
+
+	// Drain is set if the node would be drained.
+	Drain bool `json:"drain"`
+	// Reprovision is set if the update changes the storage layout and the node would be
+	// flagged for reprovisioning by the pool's reprovision policy.
+	Reprovision bool `json:"reprovision,omitempty"`
+	// PostConfigChangeActions are the actions taken once the changes are written, e.g. reboot.
+	PostConfigChangeActions []string `json:"postConfigChangeActions,omitempty"`
+}
+
+// PreviewUpdate reports what updating a node from oldConfig to newConfig would do, using the same
+// checks and calculations as an actual update. pool provides the update settings of the node's
+// pool and may be nil.
+func PreviewUpdate(oldConfig, newConfig *mcfgv1.MachineConfig, pool *mcfgv1.MachineConfigPool) *UpdatePreview {
+	oldConfig = canonicalizeEmptyMC(oldConfig)
+	preview := &UpdatePreview{OldConfig: oldConfig.GetName()}
+
+	oldIgnConfig, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
+	if err != nil {
+		preview.Errors = append(preview.Errors, "parsing old Ignition config failed: "+err.Error())
+		return preview
+	}
+	newIgnConfig, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
+	if err != nil {
+		preview.Errors = append(preview.Errors, "parsing new Ignition config failed: "+err.Error())
+		return preview
+	}
+
+	diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
+	if len(diffFileSet) > 0 {
+		preview.Files = diffFileSet
+	}
+	preview.Units = calculateUnitDiffs(oldIgnConfig.Systemd.Units, newIgnConfig.Systemd.Units)
+	preview.KernelArgumentsRemoved, preview.KernelArgumentsAdded = diffStrings(parseKernelArguments(oldConfig.Spec.KernelArguments), parseKernelArguments(newConfig.Spec.KernelArguments))
+	preview.ExtensionsRemoved, preview.ExtensionsAdded = diffStrings(oldConfig.Spec.Extensions, newConfig.Spec.Extensions)
+	preview.OSUpdate = oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL
+	if canonicalizeKernelType(oldConfig.Spec.KernelType) != canonicalizeKernelType(newConfig.Spec.KernelType) {
+		preview.KernelType = canonicalizeKernelType(newConfig.Spec.KernelType)
+	}
+
+	diff, err := reconcilable(oldConfig, newConfig)
+	var storageErr *storageChangeErr
+	if errors.As(err, &storageErr) && pool != nil && pool.Spec.ReprovisionPolicy != nil {
+		preview.Reprovision = true
+		preview.Drain = true
+		return preview
+	}
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview
+	}
+	preview.Reconcilable = true
+
+	if err := validateExtensions(newConfig.Spec.Extensions); err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+	}
+
+	actions, err := calculatePostConfigChangeAction(diff, diffFileSet, pool)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview
+	}
+	preview.PostConfigChangeActions = actions
+
+	drain, err := isDrainRequired(actions, diffFileSet, oldIgnConfig, newIgnConfig)
+	if err != nil {
+		preview.Errors = append(preview.Errors, err.Error())
+		return preview
+	}
+	preview.Drain = drain
+	return preview
+}
+
+// calculateUnitDiffs returns the sorted names of the units added, changed or removed.
+func calculateUnitDiffs(oldUnits, newUnits []ign3types.Unit) []string {
+	oldUnitSet := make(map[string]ign3types.Unit, len(oldUnits))
+	for _, u := range oldUnits {
+		oldUnitSet[u.Name] = u
+	}
+	changed := sets.NewString()
+	for _, u := range newUnits {
+		if old, ok := oldUnitSet[u.Name]; !ok || !reflect.DeepEqual(old, u) {
+			changed.Insert(u.Name)
+		}
+		delete(oldUnitSet, u.Name)
+	}
+	for name := range oldUnitSet {
+		changed.Insert(name)
+	}
+	if changed.Len() == 0 {
+		return nil
+	}
+	return changed.List()
+}
+
+// diffStrings returns the sorted entries only in oldList and only in newList.
+func diffStrings(oldList, newList []string) ([]string, []string) {
+	oldSet := sets.NewString(oldList...)
+	newSet := sets.NewString(newList...)
+	removed := oldSet.Difference(newSet).List()
+	added := newSet.Difference(oldSet).List()
+	if len(removed) == 0 {
+		removed = nil
+	}
+	if len(added) == 0 {
+		added = nil
+	}
+	return removed, added
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewUpdate(t *testing.T) {
+	chrony1 := ctrlcommon.NewIgnFile("/etc/chrony.d/servers.conf", "server a\n")
+	chrony2 := ctrlcommon.NewIgnFile("/etc/chrony.d/servers.conf", "server b\n")
+	motd := ctrlcommon.NewIgnFile("/etc/motd", "hello\n")
+	chronyd1 := ign3types.Unit{Name: "chronyd.service", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Service]\nExecStart=/usr/sbin/chronyd\n")}
+	chronyd2 := ign3types.Unit{Name: "chronyd.service", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Service]\nExecStart=/usr/sbin/chronyd -d\n")}
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-0")
+	pool.Spec.PostConfigChangeActions = []mcfgv1.PostConfigChangeActionRule{
+		{Path: "/etc/chrony.d/*.conf", Type: mcfgv1.PostConfigChangeActionRestart, Unit: "chronyd.service"},
+	}
+
+	reprovisionPool := pool.DeepCopy()
+	reprovisionPool.Spec.ReprovisionPolicy = &mcfgv1.ReprovisionPolicy{Action: mcfgv1.ReprovisionActionDeleteMachine}
+
+	newConfig := func(files []ign3types.File, units []ign3types.Unit, extensions, kargs []string) *mcfgv1.MachineConfig {
+		return helpers.NewMachineConfigExtended("rendered-worker-0", nil, nil, files, units, []ign3types.SSHAuthorizedKey{}, extensions, false, kargs, "default", "dummy://")
+	}
+	oldConfig := newConfig([]ign3types.File{chrony1}, []ign3types.Unit{chronyd1}, []string{"usbguard"}, []string{"nosmt", "audit=1"})
+
+	tests := []struct {
+		name      string
+		newConfig *mcfgv1.MachineConfig
+		pool      *mcfgv1.MachineConfigPool
+		expected  *UpdatePreview
+	}{{
+		name:      "file covered by a pool rule",
+		newConfig: newConfig([]ign3types.File{chrony2}, []ign3types.Unit{chronyd1}, []string{"usbguard"}, []string{"nosmt", "audit=1"}),
+		pool:      pool,
+		expected: &UpdatePreview{
+			OldConfig:               "rendered-worker-0",
+			Reconcilable:            true,
+			Files:                   []string{"/etc/chrony.d/servers.conf"},
+			PostConfigChangeActions: []string{"restart chronyd.service"},
+		},
+	}, {
+		name:      "file without pool rule",
+		newConfig: newConfig([]ign3types.File{chrony2}, []ign3types.Unit{chronyd1}, []string{"usbguard"}, []string{"nosmt", "audit=1"}),
+		expected: &UpdatePreview{
+			OldConfig:               "rendered-worker-0",
+			Reconcilable:            true,
+			Files:                   []string{"/etc/chrony.d/servers.conf"},
+			Drain:                   true,
+			PostConfigChangeActions: []string{postConfigChangeActionReboot},
+		},
+	}, {
+		name:      "unit change",
+		newConfig: newConfig([]ign3types.File{chrony1}, []ign3types.Unit{chronyd2}, []string{"usbguard"}, []string{"nosmt", "audit=1"}),
+		pool:      pool,
+		expected: &UpdatePreview{
+			OldConfig:               "rendered-worker-0",
+			Reconcilable:            true,
+			Units:                   []string{"chronyd.service"},
+			PostConfigChangeActions: []string{postConfigChangeActionDaemonReload, "restart chronyd.service"},
+		},
+	}, {
+		name:      "kernel arguments, extensions and files",
+		newConfig: newConfig([]ign3types.File{chrony1, motd}, []ign3types.Unit{chronyd1}, []string{"kerberos"}, []string{"nosmt", "audit=0"}),
+		pool:      pool,
+		expected: &UpdatePreview{
+			OldConfig:               "rendered-worker-0",
+			Reconcilable:            true,
+			Files:                   []string{"/etc/motd"},
+			KernelArgumentsAdded:    []string{"audit=0"},
+			KernelArgumentsRemoved:  []string{"audit=1"},
+			ExtensionsAdded:         []string{"kerberos"},
+			ExtensionsRemoved:       []string{"usbguard"},
+			Drain:                   true,
+			PostConfigChangeActions: []string{postConfigChangeActionReboot},
+		},
+	}, {
+		name:      "unsupported extension",
+		newConfig: newConfig([]ign3types.File{chrony1}, []ign3types.Unit{chronyd1}, []string{"usbguard", "foo"}, []string{"nosmt", "audit=1"}),
+		pool:      pool,
+		expected: &UpdatePreview{
+			OldConfig:               "rendered-worker-0",
+			Reconcilable:            true,
+			Errors:                  []string{"invalid extensions found: [foo]"},
+			ExtensionsAdded:         []string{"foo"},
+			Drain:                   true,
+			PostConfigChangeActions: []string{postConfigChangeActionReboot},
+		},
+	}, {
+		name: "unreconcilable",
+		newConfig: helpers.CreateMachineConfigFromIgnition(ign3types.Config{
+			Ignition: ign3types.Ignition{Version: ign3types.MaxVersion.String()},
+			Storage:  ign3types.Storage{Disks: []ign3types.Disk{{Device: "/dev/sdb"}}},
+			Passwd:   ign3types.Passwd{Users: []ign3types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{}}}},
+		}),
+		pool: pool,
+		expected: &UpdatePreview{
+			OldConfig:              "rendered-worker-0",
+			Errors:                 []string{"ignition disks section contains changes"},
+			Files:                  []string{"/etc/chrony.d/servers.conf"},
+			Units:                  []string{"chronyd.service"},
+			KernelArgumentsRemoved: []string{"audit=1", "nosmt"},
+			ExtensionsRemoved:      []string{"usbguard"},
+			OSUpdate:               true,
+		},
+	}, {
+		name: "reprovision",
+		newConfig: helpers.CreateMachineConfigFromIgnition(ign3types.Config{
+			Ignition: ign3types.Ignition{Version: ign3types.MaxVersion.String()},
+			Storage:  ign3types.Storage{Disks: []ign3types.Disk{{Device: "/dev/sdb"}}},
+			Passwd:   ign3types.Passwd{Users: []ign3types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{}}}},
+		}),
+		pool: reprovisionPool,
+		expected: &UpdatePreview{
+			OldConfig:              "rendered-worker-0",
+			Files:                  []string{"/etc/chrony.d/servers.conf"},
+			Units:                  []string{"chronyd.service"},
+			KernelArgumentsRemoved: []string{"audit=1", "nosmt"},
+			ExtensionsRemoved:      []string{"usbguard"},
+			OSUpdate:               true,
+			Drain:                  true,
+			Reprovision:            true,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, PreviewUpdate(oldConfig, test.newConfig, test.pool))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// storageChangeErr is returned by reconcilable for changes to disks, filesystems or RAID
+// arrays. They can't be applied to a running node, which can only be reprovisioned with
+// the new config instead.
+type storageChangeErr struct {
+	error
+}
+
+// reprovision cordons and drains the node for an update to configName that changes its
+// storage layout, then flags it for reprovisioning as configured by pool's reprovisionPolicy.
+// The node is left Working on its current config until it is replaced.
+func (dn *Daemon) reprovision(pool *mcfgv1.MachineConfigPool, configName string, reason error) error {
+	if dn.node.Annotations[constants.ReprovisionAnnotationKey] == configName {
+		glog.Infof("Node is already flagged for reprovisioning with config %s", configName)
+		return nil
+	}
+
+	logSystem("Reprovisioning node for config %s: %v", configName, reason)
+	dn.nodeWriter.Eventf(corev1.EventTypeNormal, "Reprovision", "Reprovisioning node for config %s: %v", configName, reason)
+
+	if err := dn.performDrain(pool); err != nil {
+		return err
+	}
+	if pool.Spec.ReprovisionPolicy.Action == mcfgv1.ReprovisionActionHook {
+		if err := dn.runUpdateHooks(pool, mcfgv1.UpdateHookReprovision, configName); err != nil {
+			return err
+		}
+	}
+	if _, err := dn.nodeWriter.SetAnnotations(map[string]string{constants.ReprovisionAnnotationKey: configName}); err != nil {
+		return fmt.Errorf("flagging node for reprovisioning: %w", err)
+	}
+	dn.nodeWriter.Eventf(corev1.EventTypeNormal, "Reprovision", "Node flagged for reprovisioning with config %s", configName)
+	return nil
+}
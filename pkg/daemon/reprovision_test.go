@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annotationRecorder is a NodeWriter that only records the annotations it sets.
+type annotationRecorder struct {
+	NodeWriter
+	annotations map[string]string
+}
+
+func (r *annotationRecorder) SetAnnotations(annos map[string]string) (*corev1.Node, error) {
+	for k, v := range annos {
+		r.annotations[k] = v
+	}
+	return nil, nil
+}
+
+func (r *annotationRecorder) Eventf(eventtype, reason, messageFmt string, args ...interface{}) {}
+
+func TestReprovision(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "reprovisioned")
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	pool.Spec.UpdateHooks = []mcfgv1.UpdateHook{
+		{Name: "reinstall", Phase: mcfgv1.UpdateHookReprovision, Command: []string{"sh", "-c", `echo "$MCD_CONFIG" > ` + marker}},
+	}
+
+	for _, action := range []mcfgv1.ReprovisionAction{mcfgv1.ReprovisionActionDeleteMachine, mcfgv1.ReprovisionActionHook} {
+		t.Run(string(action), func(t *testing.T) {
+			pool.Spec.ReprovisionPolicy = &mcfgv1.ReprovisionPolicy{Action: action}
+			recorder := &annotationRecorder{annotations: map[string]string{}}
+			dn := newMockDaemon()
+			// Skip the drain, it's driven by the drain controller.
+			dn.kubeClient = nil
+			dn.nodeWriter = recorder
+			dn.node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: map[string]string{}}}
+
+			require.NoError(t, dn.reprovision(pool, "rendered-worker-1", &storageChangeErr{}))
+			assert.Equal(t, map[string]string{constants.ReprovisionAnnotationKey: "rendered-worker-1"}, recorder.annotations)
+			if action == mcfgv1.ReprovisionActionHook {
+				contents, err := os.ReadFile(marker)
+				require.NoError(t, err)
+				assert.Equal(t, "rendered-worker-1\n", string(contents))
+			} else {
+				assert.NoFileExists(t, marker)
+			}
+
+			// Nodes already flagged for the config are left alone.
+			dn.node.Annotations = recorder.annotations
+			recorder.annotations = map[string]string{}
+			require.NoError(t, dn.reprovision(pool, "rendered-worker-1", &storageChangeErr{}))
+			assert.Empty(t, recorder.annotations)
+		})
+	}
+}
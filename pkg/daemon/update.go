@@ -11,6 +11,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -51,6 +52,21 @@ const (
 	postConfigChangeActionReloadCrio = "reload crio"
 	// Rebooting is still the default scenario for any other change
 	postConfigChangeActionReboot = "reboot"
+	// The "reload" and "restart" actions are configured through the pool's postConfigChangeActions
+	// rules and are followed by the unit name, e.g. "restart chronyd.service"
+	postConfigChangeActionReload  = "reload"
+	postConfigChangeActionRestart = "restart"
+	// Changed systemd units are applied without a reboot by running "daemon-reload" followed by
+	// "restart", "try-restart" or "stop" of each changed unit, e.g. "try-restart chronyd.service"
+	postConfigChangeActionDaemonReload = "daemon-reload"
+	postConfigChangeActionTryRestart   = "try-restart"
+	postConfigChangeActionStop         = "stop"
+	// The "defer reboot" action stages kernel argument changes for the next boot and
+	// marks the node as pending a reboot instead of rebooting it
+	postConfigChangeActionDeferReboot = "defer reboot"
+
+	// unitFailedState is the ActiveState systemd reports for a unit that failed to (re)start
+	unitFailedState = "failed"
 
 	// GPGNoRebootPath is the path MCO expects will contain GPG key updates. MCO will attempt to only reload crio for
 	// changes to this path. Note that other files added to the parent directory will not be handled specially
@@ -69,13 +85,153 @@ func reloadService(name string) error {
 	return runCmdSync("systemctl", "reload", name)
 }
 
+// unitsRequiringReboot are systemd units which can't be safely restarted in place,
+// changes to them always go through the full reboot flow.
+var unitsRequiringReboot = []string{
+	"kubelet.service",
+	"crio.service",
+	"NetworkManager.service",
+	"openvswitch.service",
+	"ovs-vswitchd.service",
+	"ovsdb-server.service",
+	"machine-config-daemon-firstboot.service",
+	"machine-config-daemon-pull.service",
+}
+
+// unitRequiresReboot returns true if changes to the named unit can't be applied in place.
+// Only services are restarted; any other unit type (mounts, targets, sockets...) reboots, as do
+// the unitsRequiringReboot and the units matching one of the extraUnits names or globs.
+func unitRequiresReboot(name string, extraUnits []string) bool {
+	if !strings.HasSuffix(name, ".service") || ctrlcommon.InSlice(name, unitsRequiringReboot) {
+		return true
+	}
+	for _, pattern := range extraUnits {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			glog.Warningf("Ignoring invalid unit requiring reboot %q: %v", pattern, err)
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// unitActionsRequireReboot returns true if any of the unit post config change actions applies to
+// a unit matching one of the extraUnits names or globs.
+func unitActionsRequireReboot(actions, extraUnits []string) bool {
+	for _, action := range actions {
+		if _, unit, ok := parseUnitPostConfigChangeAction(action); ok && unitRequiresReboot(unit, extraUnits) {
+			glog.Infof("Changes to unit %s require a reboot", unit)
+			return true
+		}
+	}
+	return false
+}
+
+// getUnitActiveState returns the ActiveState systemd reports for the named unit.
+func getUnitActiveState(name string) (string, error) {
+	out, err := runGetOut("systemctl", "show", "--property=ActiveState", "--value", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// unitPostConfigChangeAction returns the action string for reloading or restarting unit.
+func unitPostConfigChangeAction(verb, unit string) string {
+	return fmt.Sprintf("%s %s", verb, unit)
+}
+
+// parseUnitPostConfigChangeAction splits a "<verb> <unit>" action such as "reload crio" or
+// "restart chronyd.service" into its verb and unit. ok is false for any other action.
+func parseUnitPostConfigChangeAction(action string) (verb, unit string, ok bool) {
+	verb, unit, found := strings.Cut(action, " ")
+	if !found || unit == "" {
+		return "", "", false
+	}
+	switch verb {
+	case postConfigChangeActionReload, postConfigChangeActionRestart, postConfigChangeActionTryRestart, postConfigChangeActionStop:
+		return verb, unit, true
+	}
+	return "", "", false
+}
+
+// runUnitPostConfigChangeActions runs the systemd actions out of postConfigChangeActions in order.
+// Restarted units are checked afterwards and an error is returned if any of them failed.
+func (dn *Daemon) runUnitPostConfigChangeActions(postConfigChangeActions []string, configName string) error {
+	for _, action := range postConfigChangeActions {
+		if action == postConfigChangeActionDaemonReload {
+			if err := runCmdSync("systemctl", "daemon-reload"); err != nil {
+				return fmt.Errorf("could not apply update: reloading systemd units failed. Error: %w", err)
+			}
+			continue
+		}
+
+		verb, serviceName, ok := parseUnitPostConfigChangeAction(action)
+		if !ok {
+			continue
+		}
+
+		switch verb {
+		case postConfigChangeActionReload:
+			if err := reloadService(serviceName); err != nil {
+				if dn.nodeWriter != nil {
+					dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedServiceReload", fmt.Sprintf("Reloading %s service failed. Error: %v", serviceName, err))
+				}
+				return fmt.Errorf("could not apply update: reloading %s configuration failed. Error: %w", serviceName, err)
+			}
+
+			if dn.nodeWriter != nil {
+				dn.nodeWriter.Eventf(corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was reloaded.", serviceName)
+			}
+			logSystem("%s config reloaded successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
+		case postConfigChangeActionStop:
+			if err := runCmdSync("systemctl", "stop", serviceName); err != nil {
+				if dn.nodeWriter != nil {
+					dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedServiceStop", fmt.Sprintf("Stopping %s service failed. Error: %v", serviceName, err))
+				}
+				return fmt.Errorf("could not apply update: stopping %s failed. Error: %w", serviceName, err)
+			}
+
+			if dn.nodeWriter != nil {
+				dn.nodeWriter.Eventf(corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was stopped.", serviceName)
+			}
+			logSystem("%s stopped successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
+		default:
+			err := runCmdSync("systemctl", verb, serviceName)
+			if err == nil {
+				var state string
+				state, err = getUnitActiveState(serviceName)
+				if err == nil && state == unitFailedState {
+					err = fmt.Errorf("unit %s is in state %s after %s", serviceName, state, verb)
+				}
+			}
+			if err != nil {
+				if dn.nodeWriter != nil {
+					dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedServiceRestart", fmt.Sprintf("Restarting %s service failed. Error: %v", serviceName, err))
+				}
+				return fmt.Errorf("could not apply update: restarting %s failed. Error: %w", serviceName, err)
+			}
+
+			if dn.nodeWriter != nil {
+				dn.nodeWriter.Eventf(corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was restarted.", serviceName)
+			}
+			logSystem("%s restarted successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
+		}
+	}
+	return nil
+}
+
 // performPostConfigChangeAction takes action based on what postConfigChangeAction has been asked.
-// For non-reboot action, it applies configuration, updates node's config and state.
+// For non-reboot action, it applies configuration, waits for pool's health gates, updates node's config and state.
 // In the end uncordon node to schedule workload.
 // If at any point an error occurs, we reboot the node so that node has correct configuration.
-func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string, configName string) error {
+func (dn *Daemon) performPostConfigChangeAction(pool *mcfgv1.MachineConfigPool, postConfigChangeActions []string, configName string) error {
 	if ctrlcommon.InSlice(postConfigChangeActionReboot, postConfigChangeActions) {
 		logSystem("Rebooting node")
+		dn.recordUpdateHistoryReboot(configName)
 		return dn.reboot(fmt.Sprintf("Node will reboot into config %s", configName))
 	}
 
@@ -86,24 +242,27 @@ func (dn *Daemon) performPostConfigChangeAction(postConfigChangeActions []string
 		logSystem("Node has Desired Config %s, skipping reboot", configName)
 	}
 
-	if ctrlcommon.InSlice(postConfigChangeActionReloadCrio, postConfigChangeActions) {
-		serviceName := "crio"
-
-		if err := reloadService(serviceName); err != nil {
-			if dn.nodeWriter != nil {
-				dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedServiceReload", fmt.Sprintf("Reloading %s service failed. Error: %v", serviceName, err))
-			}
-			return fmt.Errorf("could not apply update: reloading %s configuration failed. Error: %w", serviceName, err)
+	if ctrlcommon.InSlice(postConfigChangeActionDeferReboot, postConfigChangeActions) {
+		if err := dn.setPendingReboot(configName); err != nil {
+			return fmt.Errorf("could not apply update: marking node as pending reboot failed. Error: %w", err)
 		}
-
 		if dn.nodeWriter != nil {
-			dn.nodeWriter.Eventf(corev1.EventTypeNormal, "SkipReboot", "Config changes do not require reboot. Service %s was reloaded.", serviceName)
+			dn.nodeWriter.Eventf(corev1.EventTypeNormal, "RebootDeferred", "Kernel argument changes for config %s are staged for the next reboot.", configName)
 		}
-		logSystem("%s config reloaded successfully! Desired config %s has been applied, skipping reboot", serviceName, configName)
+		logSystem("Kernel argument changes staged, deferring reboot into config %s", configName)
+	}
+
+	if err := dn.runUnitPostConfigChangeActions(postConfigChangeActions, configName); err != nil {
+		return err
 	}
 
 	// We are here, which means reboot was not needed to apply the configuration.
 
+	// Gate rebootless updates, including deferred reboots, like those booting into the config.
+	if err := dn.runHealthGates(pool, configName); err != nil {
+		return fmt.Errorf("could not apply update: %w", err)
+	}
+
 	// Get current state of node, in case of an error reboot
 	state, err := dn.getStateAndConfigs(configName)
 	if err != nil {
@@ -414,7 +573,10 @@ func (dn *CoreOSDaemon) applyOSChanges(mcDiff machineConfigDiff, oldConfig, newC
 	return nil
 }
 
-func calculatePostConfigChangeActionFromFileDiffs(diffFileSet []string) (actions []string) {
+// calculatePostConfigChangeActionFromFileDiffs returns the actions needed to apply the changed files.
+// The built-in file lists take precedence; every other file is matched against rules in order and
+// the first matching rule decides its action. Files not matched by any rule require a reboot.
+func calculatePostConfigChangeActionFromFileDiffs(diffFileSet []string, rules []mcfgv1.PostConfigChangeActionRule) (actions []string) {
 	filesPostConfigChangeActionNone := []string{
 		caBundleFilePath,
 		"/var/lib/kubelet/config.json",
@@ -425,21 +587,72 @@ func calculatePostConfigChangeActionFromFileDiffs(diffFileSet []string) (actions
 		"/etc/containers/policy.json",
 	}
 
-	actions = []string{postConfigChangeActionNone}
+	reloadCrio := false
+	// ruleActions is indexed like rules so the resulting actions run in rule order
+	ruleActions := make([]string, len(rules))
 	for _, path := range diffFileSet {
 		if ctrlcommon.InSlice(path, filesPostConfigChangeActionNone) {
 			continue
 		} else if ctrlcommon.InSlice(path, filesPostConfigChangeActionReloadCrio) {
-			actions = []string{postConfigChangeActionReloadCrio}
-		} else {
-			actions = []string{postConfigChangeActionReboot}
-			return
+			reloadCrio = true
+			continue
+		}
+
+		idx, rule := matchPostConfigChangeActionRule(path, rules)
+		if rule == nil {
+			return []string{postConfigChangeActionReboot}
+		}
+		switch rule.Type {
+		case mcfgv1.PostConfigChangeActionNone:
+		case mcfgv1.PostConfigChangeActionReload:
+			ruleActions[idx] = unitPostConfigChangeAction(postConfigChangeActionReload, rule.Unit)
+		case mcfgv1.PostConfigChangeActionRestart:
+			ruleActions[idx] = unitPostConfigChangeAction(postConfigChangeActionRestart, rule.Unit)
+		default:
+			glog.Infof("File %s matches post config change action rule %q with type %q, rebooting", path, rule.Path, rule.Type)
+			return []string{postConfigChangeActionReboot}
 		}
 	}
-	return
+
+	if reloadCrio {
+		actions = append(actions, postConfigChangeActionReloadCrio)
+	}
+	for _, action := range ruleActions {
+		if action != "" && !ctrlcommon.InSlice(action, actions) {
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) == 0 {
+		actions = []string{postConfigChangeActionNone}
+	}
+	return actions
+}
+
+// matchPostConfigChangeActionRule returns the first rule whose path glob matches path, along with its
+// index in rules. Reload and Restart rules without a unit are skipped since there is nothing to act on.
+func matchPostConfigChangeActionRule(path string, rules []mcfgv1.PostConfigChangeActionRule) (int, *mcfgv1.PostConfigChangeActionRule) {
+	for i := range rules {
+		rule := &rules[i]
+		matched, err := filepath.Match(rule.Path, path)
+		if err != nil {
+			glog.Warningf("Ignoring post config change action rule with invalid path %q: %v", rule.Path, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		if (rule.Type == mcfgv1.PostConfigChangeActionReload || rule.Type == mcfgv1.PostConfigChangeActionRestart) && rule.Unit == "" {
+			glog.Warningf("Ignoring post config change action rule %q with type %q: no unit set", rule.Path, rule.Type)
+			continue
+		}
+		return i, rule
+	}
+	return -1, nil
 }
 
-func calculatePostConfigChangeAction(diff *machineConfigDiff, diffFileSet []string) ([]string, error) {
+// calculatePostConfigChangeAction returns the actions needed to apply diff. The pool, if any,
+// provides the post config change action rules and the kernel arguments reboot policy.
+func calculatePostConfigChangeAction(diff *machineConfigDiff, diffFileSet []string, pool *mcfgv1.MachineConfigPool) ([]string, error) {
 	// If a machine-config-daemon-force file is present, it means the user wants to
 	// move to desired state without additional validation. We will reboot the node in
 	// this case regardless of what MachineConfig diff is.
@@ -451,13 +664,102 @@ func calculatePostConfigChangeAction(diff *machineConfigDiff, diffFileSet []stri
 		return []string{postConfigChangeActionReboot}, nil
 	}
 
-	if diff.osUpdate || diff.kargs || diff.fips || diff.units || diff.kernelType || diff.extensions {
+	var rules []mcfgv1.PostConfigChangeActionRule
+	var extraUnits []string
+	deferKargs := false
+	if pool != nil {
+		rules = pool.Spec.PostConfigChangeActions
+		extraUnits = pool.Spec.UnitsRequiringReboot
+		deferKargs = diff.kargs && diff.kargsDeferrable && pool.Spec.KernelArgumentsRebootPolicy == mcfgv1.KernelArgumentsRebootDeferred
+	}
+
+	if diff.osUpdate || (diff.kargs && !deferKargs) || diff.fips || diff.kernelType || diff.extensions {
 		// must reboot
 		return []string{postConfigChangeActionReboot}, nil
 	}
 
-	// We don't actually have to consider ssh keys changes, which is the only section of passwd that is allowed to change
-	return calculatePostConfigChangeActionFromFileDiffs(diffFileSet), nil
+	if diff.units && (diff.unitActions == nil || unitActionsRequireReboot(diff.unitActions, extraUnits)) {
+		// at least one of the changed units can't be restarted in place
+		return []string{postConfigChangeActionReboot}, nil
+	}
+
+	// We don't actually have to consider passwd changes, users and SSH keys are updated in place
+	actions := calculatePostConfigChangeActionFromFileDiffs(diffFileSet, rules)
+	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
+		return actions, nil
+	}
+	if deferKargs {
+		if actions[0] == postConfigChangeActionNone {
+			actions = []string{}
+		}
+		actions = append(actions, postConfigChangeActionDeferReboot)
+	}
+	if !diff.units {
+		return actions, nil
+	}
+
+	// systemd has to pick up the changed units before anything else is reloaded or restarted
+	unitActions := []string{postConfigChangeActionDaemonReload}
+	for _, action := range actions {
+		if action != postConfigChangeActionNone {
+			unitActions = append(unitActions, action)
+		}
+	}
+	for _, action := range diff.unitActions {
+		if !ctrlcommon.InSlice(action, unitActions) {
+			unitActions = append(unitActions, action)
+		}
+	}
+	return unitActions, nil
+}
+
+// calculateUnitPostConfigChangeActions returns the actions needed to apply the changes between
+// oldUnits and newUnits without a reboot, or nil if any changed unit requires a reboot.
+// Added and changed units are restarted if enabled, restarted only if already running if their
+// enablement is left to presets, and stopped if disabled or masked. Removed units are stopped,
+// unless only their dropins were removed, in which case they are restarted if running.
+// Units matching one of the extraUnits names or globs require a reboot as well.
+func calculateUnitPostConfigChangeActions(oldUnits, newUnits []ign3types.Unit, extraUnits []string) []string {
+	oldUnitSet := make(map[string]ign3types.Unit, len(oldUnits))
+	for _, u := range oldUnits {
+		oldUnitSet[u.Name] = u
+	}
+	newUnitSet := make(map[string]struct{}, len(newUnits))
+
+	actions := []string{}
+	for _, u := range newUnits {
+		newUnitSet[u.Name] = struct{}{}
+		if old, ok := oldUnitSet[u.Name]; ok && reflect.DeepEqual(old, u) {
+			continue
+		}
+		if unitRequiresReboot(u.Name, extraUnits) {
+			glog.Infof("Changes to unit %s require a reboot", u.Name)
+			return nil
+		}
+		switch {
+		case (u.Mask != nil && *u.Mask) || (u.Enabled != nil && !*u.Enabled):
+			actions = append(actions, unitPostConfigChangeAction(postConfigChangeActionStop, u.Name))
+		case u.Enabled != nil && *u.Enabled:
+			actions = append(actions, unitPostConfigChangeAction(postConfigChangeActionRestart, u.Name))
+		default:
+			actions = append(actions, unitPostConfigChangeAction(postConfigChangeActionTryRestart, u.Name))
+		}
+	}
+	for _, u := range oldUnits {
+		if _, ok := newUnitSet[u.Name]; ok {
+			continue
+		}
+		if unitRequiresReboot(u.Name, extraUnits) {
+			glog.Infof("Removing unit %s requires a reboot", u.Name)
+			return nil
+		}
+		if u.Contents == nil {
+			actions = append(actions, unitPostConfigChangeAction(postConfigChangeActionTryRestart, u.Name))
+		} else {
+			actions = append(actions, unitPostConfigChangeAction(postConfigChangeActionStop, u.Name))
+		}
+	}
+	return actions
 }
 
 // update the node to the provided node configuration.
@@ -488,6 +790,13 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 	oldConfigName := oldConfig.GetName()
 	newConfigName := newConfig.GetName()
 
+	dn.startUpdateHistory(oldConfigName, newConfigName)
+	defer func() {
+		if retErr != nil {
+			dn.failUpdateHistory(newConfigName, retErr)
+		}
+	}()
+
 	oldIgnConfig, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
 	if err != nil {
 		return fmt.Errorf("parsing old Ignition config failed: %w", err)
@@ -504,6 +813,16 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 
 	if reconcilableError != nil {
 		wrappedErr := fmt.Errorf("can't reconcile config %s with %s: %w", oldConfigName, newConfigName, reconcilableError)
+		var storageErr *storageChangeErr
+		if errors.As(reconcilableError, &storageErr) && dn.nodeWriter != nil {
+			pool, err := dn.getPoolForConfig(newConfig)
+			if err != nil {
+				glog.Warningf("Could not get pool for config %s, ignoring its reprovision policy: %v", newConfigName, err)
+			}
+			if pool != nil && pool.Spec.ReprovisionPolicy != nil {
+				return dn.reprovision(pool, newConfigName, wrappedErr)
+			}
+		}
 		if dn.nodeWriter != nil {
 			dn.nodeWriter.Eventf(corev1.EventTypeWarning, "FailedToReconcile", wrappedErr.Error())
 		}
@@ -513,10 +832,20 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 	logSystem("Starting update from %s to %s: %+v", oldConfigName, newConfigName, diff)
 
 	diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
-	actions, err := calculatePostConfigChangeAction(diff, diffFileSet)
+	pool, err := dn.getPoolForConfig(newConfig)
+	if err != nil {
+		glog.Warningf("Could not get pool for config %s, ignoring its update settings: %v", newConfigName, err)
+	}
+	actions, err := calculatePostConfigChangeAction(diff, diffFileSet, pool)
 	if err != nil {
 		return err
 	}
+	logSystem("Post config change actions for %s: %v", newConfigName, actions)
+	dn.editUpdateHistory(newConfigName, func(entry *UpdateHistoryEntry) {
+		entry.Changes = diff.changedSections()
+		entry.Files = diffFileSet
+		entry.PostConfigChangeActions = actions
+	})
 
 	// Check and perform node drain if required
 	drain, err := isDrainRequired(actions, diffFileSet, oldIgnConfig, newIgnConfig)
@@ -524,9 +853,16 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 		return err
 	}
 	if drain {
-		if err := dn.performDrain(); err != nil {
+		if err := dn.runUpdateHooks(pool, mcfgv1.UpdateHookPreDrain, newConfigName); err != nil {
+			return err
+		}
+		drainStart := time.Now()
+		if err := dn.performDrain(pool); err != nil {
 			return err
 		}
+		dn.editUpdateHistory(newConfigName, func(entry *UpdateHistoryEntry) {
+			entry.DrainDuration = &metav1.Duration{Duration: time.Since(drainStart)}
+		})
 	} else {
 		glog.Info("Changes do not require drain, skipping.")
 	}
@@ -546,6 +882,20 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 		}
 	}()
 
+	if err := dn.updateLocalUsersAndGroups(oldIgnConfig.Passwd, newIgnConfig.Passwd); err != nil {
+		return err
+	}
+
+	defer func() {
+		if retErr != nil {
+			if err := dn.updateLocalUsersAndGroups(newIgnConfig.Passwd, oldIgnConfig.Passwd); err != nil {
+				errs := kubeErrs.NewAggregate([]error{err, retErr})
+				retErr = fmt.Errorf("error rolling back local users and groups updates: %w", errs)
+				return
+			}
+		}
+	}()
+
 	if err := dn.updateSSHKeys(newIgnConfig.Passwd.Users); err != nil {
 		return err
 	}
@@ -616,11 +966,24 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig, skipCertifi
 		}
 	}()
 
+	return dn.finishUpdate(pool, newConfig, actions)
+}
+
+// finishUpdate runs the PreReboot hooks if the update reboots, stores newConfig as
+// pending and takes the postConfigChangeActions. The hooks run first so that a failing
+// hook doesn't leave a pending config behind that was never applied.
+func (dn *Daemon) finishUpdate(pool *mcfgv1.MachineConfigPool, newConfig *mcfgv1.MachineConfig, actions []string) error {
+	if ctrlcommon.InSlice(postConfigChangeActionReboot, actions) {
+		if err := dn.runUpdateHooks(pool, mcfgv1.UpdateHookPreReboot, newConfig.GetName()); err != nil {
+			return err
+		}
+	}
+
 	if err := dn.finalizeBeforeReboot(newConfig); err != nil {
 		return err
 	}
 
-	return dn.performPostConfigChangeAction(actions, newConfig.GetName())
+	return dn.performPostConfigChangeAction(pool, actions, newConfig.GetName())
 }
 
 // This is currently a subsection copied over from update() since we need to be more nuanced. Should eventually
@@ -651,6 +1014,20 @@ func (dn *Daemon) updateHypershift(oldConfig, newConfig *mcfgv1.MachineConfig, d
 		}
 	}()
 
+	if err := dn.updateLocalUsersAndGroups(oldIgnConfig.Passwd, newIgnConfig.Passwd); err != nil {
+		return err
+	}
+
+	defer func() {
+		if retErr != nil {
+			if err := dn.updateLocalUsersAndGroups(newIgnConfig.Passwd, oldIgnConfig.Passwd); err != nil {
+				errs := kubeErrs.NewAggregate([]error{err, retErr})
+				retErr = fmt.Errorf("error rolling back local users and groups updates: %w", errs)
+				return
+			}
+		}
+	}()
+
 	if err := dn.updateSSHKeys(newIgnConfig.Passwd.Users); err != nil {
 		return err
 	}
@@ -719,6 +1096,11 @@ type machineConfigDiff struct {
 	units      bool
 	kernelType bool
 	extensions bool
+	// kargsDeferrable is set if kargs changed and all changed kernel arguments can wait for the next reboot.
+	kargsDeferrable bool
+	// unitActions holds the actions applying the unit changes without a reboot.
+	// It is nil if units didn't change or if any changed unit requires a reboot.
+	unitActions []string
 }
 
 // isEmpty returns true if the machineConfigDiff has no changes, or
@@ -774,15 +1156,25 @@ func newMachineConfigDiff(oldConfig, newConfig *mcfgv1.MachineConfig) (*machineC
 	kargsEmpty := len(oldConfig.Spec.KernelArguments) == 0 && len(newConfig.Spec.KernelArguments) == 0
 	extensionsEmpty := len(oldConfig.Spec.Extensions) == 0 && len(newConfig.Spec.Extensions) == 0
 
+	units := !reflect.DeepEqual(oldIgn.Systemd.Units, newIgn.Systemd.Units)
+	var unitActions []string
+	if units {
+		unitActions = calculateUnitPostConfigChangeActions(oldIgn.Systemd.Units, newIgn.Systemd.Units, nil)
+	}
+
+	kargs := !(kargsEmpty || reflect.DeepEqual(oldConfig.Spec.KernelArguments, newConfig.Spec.KernelArguments))
+
 	return &machineConfigDiff{
-		osUpdate:   oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL,
-		kargs:      !(kargsEmpty || reflect.DeepEqual(oldConfig.Spec.KernelArguments, newConfig.Spec.KernelArguments)),
-		fips:       oldConfig.Spec.FIPS != newConfig.Spec.FIPS,
-		passwd:     !reflect.DeepEqual(oldIgn.Passwd, newIgn.Passwd),
-		files:      !reflect.DeepEqual(oldIgn.Storage.Files, newIgn.Storage.Files),
-		units:      !reflect.DeepEqual(oldIgn.Systemd.Units, newIgn.Systemd.Units),
-		kernelType: canonicalizeKernelType(oldConfig.Spec.KernelType) != canonicalizeKernelType(newConfig.Spec.KernelType),
-		extensions: !(extensionsEmpty || reflect.DeepEqual(oldConfig.Spec.Extensions, newConfig.Spec.Extensions)),
+		osUpdate:        oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL,
+		kargs:           kargs,
+		kargsDeferrable: kargs && areKernelArgsChangesDeferrable(oldConfig.Spec.KernelArguments, newConfig.Spec.KernelArguments),
+		fips:            oldConfig.Spec.FIPS != newConfig.Spec.FIPS,
+		passwd:          !reflect.DeepEqual(oldIgn.Passwd, newIgn.Passwd),
+		files:           !reflect.DeepEqual(oldIgn.Storage.Files, newIgn.Storage.Files) || !reflect.DeepEqual(oldIgn.Storage.Directories, newIgn.Storage.Directories) || !reflect.DeepEqual(oldIgn.Storage.Links, newIgn.Storage.Links),
+		units:           units,
+		kernelType:      canonicalizeKernelType(oldConfig.Spec.KernelType) != canonicalizeKernelType(newConfig.Spec.KernelType),
+		extensions:      !(extensionsEmpty || reflect.DeepEqual(oldConfig.Spec.Extensions, newConfig.Spec.Extensions)),
+		unitActions:     unitActions,
 	}, nil
 }
 
@@ -813,64 +1205,34 @@ func reconcilable(oldConfig, newConfig *mcfgv1.MachineConfig) (*machineConfigDif
 
 	// Passwd section
 
-	// we don't currently configure Groups in place. we don't configure Users except
-	// for setting/updating SSHAuthorizedKeys for the only allowed user "core".
+	// we can set/update the SSHAuthorizedKeys and password hash of the user "core", and
+	// create, update and remove local users and groups other than system ones.
 	// otherwise we can't fix it if something changed here.
-	passwdChanged := !reflect.DeepEqual(oldIgn.Passwd, newIgn.Passwd)
-
-	if passwdChanged {
-		if !reflect.DeepEqual(oldIgn.Passwd.Groups, newIgn.Passwd.Groups) {
-			return nil, fmt.Errorf("ignition Passwd Groups section contains changes")
-		}
-		if !reflect.DeepEqual(oldIgn.Passwd.Users, newIgn.Passwd.Users) {
-			if len(oldIgn.Passwd.Users) > 0 && len(newIgn.Passwd.Users) == 0 {
-				return nil, fmt.Errorf("ignition passwd user section contains unsupported changes: user core may not be deleted")
-			}
-			// there is an update to Users, we must verify that it is ONLY making an acceptable
-			// change to the SSHAuthorizedKeys for the user "core"
-			for _, user := range newIgn.Passwd.Users {
-				if user.Name != constants.CoreUserName {
-					return nil, fmt.Errorf("ignition passwd user section contains unsupported changes: non-core user")
-				}
-			}
-
-			glog.Infof("user data to be verified before ssh update: %v", newIgn.Passwd.Users[len(newIgn.Passwd.Users)-1])
-			if err := verifyUserFields(newIgn.Passwd.Users[len(newIgn.Passwd.Users)-1]); err != nil {
-				return nil, err
-			}
-		}
+	if err := verifyPasswd(oldIgn.Passwd, newIgn.Passwd); err != nil {
+		return nil, err
 	}
 
 	// Storage section
 
-	// we can only reconcile files right now. make sure the sections we can't
-	// fix aren't changed.
+	// we can only reconcile files, directories and links right now. make sure the
+	// sections we can't fix aren't changed. changes to them can only be applied by
+	// reprovisioning the node, if the pool's reprovision policy allows it.
 	if !reflect.DeepEqual(oldIgn.Storage.Disks, newIgn.Storage.Disks) {
-		return nil, fmt.Errorf("ignition disks section contains changes")
+		return nil, &storageChangeErr{fmt.Errorf("ignition disks section contains changes")}
 	}
 	if !reflect.DeepEqual(oldIgn.Storage.Filesystems, newIgn.Storage.Filesystems) {
-		return nil, fmt.Errorf("ignition filesystems section contains changes")
+		return nil, &storageChangeErr{fmt.Errorf("ignition filesystems section contains changes")}
 	}
 	if !reflect.DeepEqual(oldIgn.Storage.Raid, newIgn.Storage.Raid) {
-		return nil, fmt.Errorf("ignition raid section contains changes")
-	}
-	if !reflect.DeepEqual(oldIgn.Storage.Directories, newIgn.Storage.Directories) {
-		return nil, fmt.Errorf("ignition directories section contains changes")
-	}
-	if !reflect.DeepEqual(oldIgn.Storage.Links, newIgn.Storage.Links) {
-		// This means links have been added, as opposed as being removed as it happened with
-		// https://bugzilla.redhat.com/show_bug.cgi?id=1677198. This doesn't really change behavior
-		// since we still don't support links but we allow old MC to remove links when upgrading.
-		if len(newIgn.Storage.Links) != 0 {
-			return nil, fmt.Errorf("ignition links section contains changes")
-		}
+		return nil, &storageChangeErr{fmt.Errorf("ignition raid section contains changes")}
 	}
-
-	// Special case files append: if the new config wants us to append, then we
-	// have to force a reprovision since it's not idempotent
+	// Special case files append: appends are reapplied on top of the original file
+	// every time, so they must be inline contents the MCD can decode.
 	for _, f := range newIgn.Storage.Files {
-		if len(f.Append) > 0 {
-			return nil, fmt.Errorf("ignition file %v includes append", f.Path)
+		for _, resource := range f.Append {
+			if _, err := ctrlcommon.DecodeIgnitionFileContents(resource.Source, resource.Compression); err != nil {
+				return nil, fmt.Errorf("ignition file %v includes an append that can't be applied: %w", f.Path, err)
+			}
 		}
 		// We also disallow writing some special files
 		if f.Path == constants.MachineConfigDaemonForceFile {
@@ -1195,23 +1557,24 @@ func (dn *CoreOSDaemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig)
 // updateFiles writes files specified by the nodeconfig to disk. it also writes
 // systemd units. there is no support for multiple filesystems at this point.
 //
-// in addition to files, we also write systemd units to disk. we mask, enable,
-// and disable unit files when appropriate. this function relies on the system
-// being restarted after an upgrade, so it doesn't daemon-reload or restart
-// any services.
+// in addition to files, we also write directories, links and systemd units to disk. we mask, enable,
+// and disable unit files when appropriate. this function doesn't daemon-reload
+// or restart any services; that is left to the reboot or to the post config
+// change actions computed for the changed units.
 //
 // it is worth noting that this function explicitly doesn't rely on the ignition
 // implementation of file, unit writing, enabling or disabling. this is because
 // ignition is built on the assumption that it is working with a fresh system,
 // where as we are trying to reconcile a system that has already been running.
-//
-// in the future, this function should do any additional work to confirm that
-// whatever has been written is picked up by the appropriate daemons, if
-// required. in particular, a daemon-reload and restart for any unit files
-// touched.
 func (dn *Daemon) updateFiles(oldIgnConfig, newIgnConfig ign3types.Config, skipCertificateWrite bool) error {
 	glog.Info("Updating files")
-	if err := dn.writeFiles(newIgnConfig.Storage.Files, skipCertificateWrite); err != nil {
+	if err := writeDirectories(newIgnConfig.Storage.Directories); err != nil {
+		return err
+	}
+	if err := dn.writeFiles(oldIgnConfig.Storage.Files, newIgnConfig.Storage.Files, skipCertificateWrite); err != nil {
+		return err
+	}
+	if err := writeLinks(newIgnConfig.Storage.Links); err != nil {
 		return err
 	}
 	if err := dn.writeUnits(newIgnConfig.Systemd.Units); err != nil {
@@ -1270,17 +1633,26 @@ func (dn *Daemon) isPathInDropins(path string, systemd *ign3types.Systemd) bool
 }
 
 // deleteStaleData performs a diff of the new and the old Ignition config. It then deletes
-// all the files, units that are present in the old config but not in the new one.
+// all the files, links, directories and units that are present in the old config but not
+// in the new one.
 // this function will error out if it fails to delete a file (with the exception
 // of simply warning if the error is ENOENT since that's the desired state).
 //
 //nolint:gocyclo
 func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig ign3types.Config) error {
 	glog.Info("Deleting stale data")
+	// a path may switch between being a file, a link or a directory, so don't
+	// delete anything that is still in the new config in any form.
 	newFileSet := make(map[string]struct{})
 	for _, f := range newIgnConfig.Storage.Files {
 		newFileSet[f.Path] = struct{}{}
 	}
+	for _, l := range newIgnConfig.Storage.Links {
+		newFileSet[l.Path] = struct{}{}
+	}
+	for _, d := range newIgnConfig.Storage.Directories {
+		newFileSet[d.Path] = struct{}{}
+	}
 
 	for _, f := range oldIgnConfig.Storage.Files {
 		if _, ok := newFileSet[f.Path]; ok {
@@ -1342,6 +1714,13 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig ign3types.Config) e
 		glog.Infof("Removed stale file %q", f.Path)
 	}
 
+	if err := deleteStaleLinks(oldIgnConfig.Storage.Links, newFileSet); err != nil {
+		return err
+	}
+	if err := deleteStaleDirectories(oldIgnConfig.Storage.Directories, newFileSet); err != nil {
+		return err
+	}
+
 	newUnitSet := make(map[string]struct{})
 	newDropinSet := make(map[string]struct{})
 	for _, u := range newIgnConfig.Systemd.Units {
@@ -1418,6 +1797,72 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig ign3types.Config) e
 	return nil
 }
 
+// deleteStaleLinks deletes the links that aren't in newPaths anymore, restoring whatever was
+// at their path before the MCD wrote them.
+func deleteStaleLinks(oldLinks []ign3types.Link, newPaths map[string]struct{}) error {
+	for _, l := range oldLinks {
+		if _, ok := newPaths[l.Path]; ok {
+			continue
+		}
+		if _, err := os.Stat(noOrigFileStampName(l.Path)); err == nil {
+			if delErr := os.Remove(noOrigFileStampName(l.Path)); delErr != nil {
+				return fmt.Errorf("deleting noorig file stamp %q: %w", noOrigFileStampName(l.Path), delErr)
+			}
+			glog.V(2).Infof("Removing link %q completely", l.Path)
+		} else if _, err := os.Lstat(origFileName(l.Path)); err == nil {
+			if err := restorePath(l.Path); err != nil {
+				return err
+			}
+			glog.V(2).Infof("Restored link %q", l.Path)
+			continue
+		}
+		glog.V(2).Infof("Deleting stale link: %s", l.Path)
+		if err := os.Remove(l.Path); err != nil {
+			newErr := fmt.Errorf("unable to delete %s: %w", l.Path, err)
+			if !os.IsNotExist(err) {
+				return newErr
+			}
+			// otherwise, just warn
+			glog.Warningf("%v", newErr)
+		}
+		glog.Infof("Removed stale link %q", l.Path)
+	}
+	return nil
+}
+
+// deleteStaleDirectories deletes the directories that aren't in newPaths anymore and that the
+// MCD created. Directories that existed before are left in place, as are directories that
+// still contain files not managed by the MCD.
+func deleteStaleDirectories(oldDirs []ign3types.Directory, newPaths map[string]struct{}) error {
+	var stale []string
+	for _, d := range oldDirs {
+		if _, ok := newPaths[d.Path]; !ok {
+			stale = append(stale, d.Path)
+		}
+	}
+	// delete nested directories before their parents
+	sort.Sort(sort.Reverse(sort.StringSlice(stale)))
+
+	for _, path := range stale {
+		if _, err := os.Stat(noOrigFileStampName(path)); err != nil {
+			glog.Infof("Not removing directory %q: it existed before it was written by the MCD", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			if errors.Is(err, syscall.ENOTEMPTY) || errors.Is(err, syscall.EEXIST) {
+				glog.Warningf("Not removing stale directory %q: it is not empty", path)
+				continue
+			}
+			return fmt.Errorf("unable to delete %s: %w", path, err)
+		}
+		if err := os.Remove(noOrigFileStampName(path)); err != nil {
+			return fmt.Errorf("deleting noorig file stamp %q: %w", noOrigFileStampName(path), err)
+		}
+		glog.Infof("Removed stale directory %q", path)
+	}
+	return nil
+}
+
 // enableUnits enables a set of systemd units via systemctl, if any fail all fails.
 func (dn *Daemon) enableUnits(units []string) error {
 	args := append([]string{"enable"}, units...)
@@ -1537,8 +1982,8 @@ func (dn *Daemon) writeUnits(units []ign3types.Unit) error {
 
 // writeFiles writes the given files to disk.
 // it doesn't fetch remote files and expects a flattened config file.
-func (dn *Daemon) writeFiles(files []ign3types.File, skipCertificateWrite bool) error {
-	return writeFiles(files, skipCertificateWrite)
+func (dn *Daemon) writeFiles(oldFiles, files []ign3types.File, skipCertificateWrite bool) error {
+	return writeFiles(oldFiles, files, skipCertificateWrite)
 }
 
 // Ensures that both the SSH root directory (/home/core/.ssh) as well as any
@@ -1615,7 +2060,11 @@ func (dn *Daemon) SetPasswordHash(newUsers []ign3types.PasswdUser) error {
 	}
 
 	// SetPasswordHash sets the password hash of the specified user.
+	// the password hashes of other users are set by updateLocalUsersAndGroups
 	for _, u := range newUsers {
+		if u.Name != constants.CoreUserName {
+			continue
+		}
 		pwhash := "*"
 		if u.PasswordHash != nil && *u.PasswordHash != "" {
 			pwhash = *u.PasswordHash
@@ -1653,13 +2102,11 @@ func (dn *Daemon) updateSSHKeys(newUsers []ign3types.PasswdUser) error {
 		return fmt.Errorf("failed to check if user core exists: %w", err)
 	}
 
-	// we're also appending all keys for any user to core, so for now
-	// we pass this to atomicallyWriteSSHKeys to write.
-	// we know these users are "core" ones also cause this slice went through Reconcilable
-	var concatSSHKeys string
+	// the keys of other users are written by updateLocalUsersAndGroups
+	var coreSSHKeys string
 	for _, u := range newUsers {
-		for _, k := range u.SSHAuthorizedKeys {
-			concatSSHKeys = concatSSHKeys + string(k) + "\n"
+		if u.Name == constants.CoreUserName {
+			coreSSHKeys += concatSSHKeys(u.SSHAuthorizedKeys)
 		}
 	}
 
@@ -1684,7 +2131,7 @@ func (dn *Daemon) updateSSHKeys(newUsers []ign3types.PasswdUser) error {
 		}
 
 		// Note we write keys only for the core user and so this ignores the user list
-		return dn.atomicallyWriteSSHKey(authKeyPath, concatSSHKeys)
+		return dn.atomicallyWriteSSHKey(authKeyPath, coreSSHKeys)
 	}
 
 	return nil
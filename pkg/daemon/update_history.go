@@ -0,0 +1,263 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateResult is the outcome of an update recorded in a node's update history.
+type UpdateResult string
+
+const (
+	// UpdateResultInProgress is the result of an update that hasn't completed yet, e.g. because
+	// the node is rebooting into the new config.
+	UpdateResultInProgress UpdateResult = "InProgress"
+	// UpdateResultSucceeded is the result of an update the node completed.
+	UpdateResultSucceeded UpdateResult = "Succeeded"
+	// UpdateResultFailed is the result of an update whose last attempt failed.
+	UpdateResultFailed UpdateResult = "Failed"
+)
+
+// UpdateHistoryNamespace is the namespace of the update history ConfigMaps. It holds nothing
+// else, so that the daemons can be granted write access to the history only.
+const UpdateHistoryNamespace = "openshift-machine-config-operator-update-history"
+
+const (
+	// maxUpdateHistoryEntries is how many updates a node's update history keeps.
+	maxUpdateHistoryEntries = 20
+	// updateHistoryConfigMapKey is the key of the update history in its ConfigMap.
+	updateHistoryConfigMapKey = "history.json"
+	// updateHistoryLabelKey labels the ConfigMaps nodes publish their update history in.
+	updateHistoryLabelKey = "machineconfiguration.openshift.io/update-history"
+)
+
+// UpdateHistoryEntry records an update of a node from one rendered config to another.
+type UpdateHistoryEntry struct {
+	// From is the config the node was updated from, empty for the initial update on firstboot.
+	From string `json:"from"`
+	// To is the config the node was updated to.
+	To string `json:"to"`
+	// StartTime is when the first attempt of the update started.
+	StartTime metav1.Time `json:"startTime"`
+	// Attempts is how many times the update was attempted.
+	Attempts int `json:"attempts"`
+	// Changes are the sections of the config that changed, e.g. files, units or osUpdate.
+	Changes []string `json:"changes,omitempty"`
+	// Files are the paths of the files, directories and links that changed.
+	Files []string `json:"files,omitempty"`
+	// DrainDuration is how long draining the node took, if it was drained.
+	DrainDuration *metav1.Duration `json:"drainDuration,omitempty"`
+	// PostConfigChangeActions are the actions taken to apply the changes.
+	PostConfigChangeActions []string `json:"postConfigChangeActions,omitempty"`
+	// RebootTime is when the node rebooted into the config, if it did.
+	RebootTime *metav1.Time `json:"rebootTime,omitempty"`
+	// CompletionTime is when the update succeeded or last failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Result is the outcome of the update.
+	Result UpdateResult `json:"result"`
+	// Error is why the last attempt of the update failed.
+	Error string `json:"error,omitempty"`
+}
+
+// changedSections returns the names of the sections of the config that changed.
+func (mcDiff *machineConfigDiff) changedSections() []string {
+	var sections []string
+	for _, section := range []struct {
+		name    string
+		changed bool
+	}{
+		{"osUpdate", mcDiff.osUpdate},
+		{"kargs", mcDiff.kargs},
+		{"fips", mcDiff.fips},
+		{"passwd", mcDiff.passwd},
+		{"files", mcDiff.files},
+		{"units", mcDiff.units},
+		{"kernelType", mcDiff.kernelType},
+		{"extensions", mcDiff.extensions},
+	} {
+		if section.changed {
+			sections = append(sections, section.name)
+		}
+	}
+	return sections
+}
+
+// getUpdateHistoryConfigMapName returns the name of the ConfigMap node publishes its update history in.
+func getUpdateHistoryConfigMapName(node string) string {
+	return "update-history-" + node
+}
+
+// loadUpdateHistory reads the update history stored at path. A missing history is empty.
+func loadUpdateHistory(path string) ([]UpdateHistoryEntry, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []UpdateHistoryEntry
+	if err := json.Unmarshal(b, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse update history %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// saveUpdateHistory stores history at path.
+func saveUpdateHistory(path string, history []UpdateHistoryEntry) error {
+	b, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(path, b)
+}
+
+// startUpdateHistoryEntry records the start of an update from one config to another in history,
+// dropping the oldest entries past maxUpdateHistoryEntries. Retrying an update that didn't
+// succeed reuses its entry.
+func startUpdateHistoryEntry(history []UpdateHistoryEntry, from, to string, now metav1.Time) []UpdateHistoryEntry {
+	if len(history) > 0 {
+		last := &history[len(history)-1]
+		if last.From == from && last.To == to && last.Result != UpdateResultSucceeded {
+			last.Attempts++
+			last.Result = UpdateResultInProgress
+			last.CompletionTime = nil
+			return history
+		}
+	}
+	history = append(history, UpdateHistoryEntry{From: from, To: to, StartTime: now, Attempts: 1, Result: UpdateResultInProgress})
+	if len(history) > maxUpdateHistoryEntries {
+		history = history[len(history)-maxUpdateHistoryEntries:]
+	}
+	return history
+}
+
+// editLatestUpdateHistoryEntry applies edit to the latest entry of history if it is an update
+// to config. It returns false if there is no such entry.
+func editLatestUpdateHistoryEntry(history []UpdateHistoryEntry, config string, edit func(*UpdateHistoryEntry)) bool {
+	if len(history) == 0 || history[len(history)-1].To != config {
+		return false
+	}
+	edit(&history[len(history)-1])
+	return true
+}
+
+// startUpdateHistory records the start of an update from one config to another in the node's
+// update history.
+func (dn *Daemon) startUpdateHistory(from, to string) {
+	dn.recordUpdateHistory(func(history []UpdateHistoryEntry) []UpdateHistoryEntry {
+		return startUpdateHistoryEntry(history, from, to, metav1.Now())
+	})
+}
+
+// editUpdateHistory applies edit to the node's update history entry of the update to config.
+func (dn *Daemon) editUpdateHistory(config string, edit func(*UpdateHistoryEntry)) {
+	dn.recordUpdateHistory(func(history []UpdateHistoryEntry) []UpdateHistoryEntry {
+		if !editLatestUpdateHistoryEntry(history, config, edit) {
+			return nil
+		}
+		return history
+	})
+}
+
+// completeUpdateHistory records that the update to config succeeded.
+func (dn *Daemon) completeUpdateHistory(config string) {
+	dn.editUpdateHistory(config, func(entry *UpdateHistoryEntry) {
+		if entry.Result == UpdateResultSucceeded {
+			return
+		}
+		now := metav1.Now()
+		entry.CompletionTime = &now
+		entry.Result = UpdateResultSucceeded
+		entry.Error = ""
+	})
+}
+
+// recordUpdateHistoryReboot records that the node is rebooting into config.
+func (dn *Daemon) recordUpdateHistoryReboot(config string) {
+	dn.editUpdateHistory(config, func(entry *UpdateHistoryEntry) {
+		now := metav1.Now()
+		entry.RebootTime = &now
+	})
+}
+
+// failUpdateHistory records that the update to config failed with err.
+func (dn *Daemon) failUpdateHistory(config string, err error) {
+	dn.editUpdateHistory(config, func(entry *UpdateHistoryEntry) {
+		if entry.Result == UpdateResultSucceeded {
+			return
+		}
+		now := metav1.Now()
+		entry.CompletionTime = &now
+		entry.Result = UpdateResultFailed
+		entry.Error = err.Error()
+	})
+}
+
+// recordUpdateHistory replaces the node's update history stored on disk with the one returned by
+// record, unless it returns nil, and publishes it. Failures are only logged, the update history
+// must never get in the way of updates.
+func (dn *Daemon) recordUpdateHistory(record func([]UpdateHistoryEntry) []UpdateHistoryEntry) {
+	if dn.mock {
+		return
+	}
+	history, err := loadUpdateHistory(constants.UpdateHistoryFilePath)
+	if err != nil {
+		glog.Warningf("Discarding update history: %v", err)
+		history = nil
+	}
+	history = record(history)
+	if history == nil {
+		return
+	}
+	if err := saveUpdateHistory(constants.UpdateHistoryFilePath, history); err != nil {
+		glog.Warningf("Failed to store update history: %v", err)
+	}
+	if err := dn.publishUpdateHistory(history); err != nil {
+		glog.Warningf("Failed to publish update history: %v", err)
+	}
+}
+
+// publishUpdateHistory publishes history in the node's update history ConfigMap, owned by the
+// node so that it goes away with it.
+func (dn *Daemon) publishUpdateHistory(history []UpdateHistoryEntry) error {
+	if dn.kubeClient == nil {
+		return nil
+	}
+	b, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	configMaps := dn.kubeClient.CoreV1().ConfigMaps(UpdateHistoryNamespace)
+	name := getUpdateHistoryConfigMapName(dn.name)
+	cm, err := configMaps.Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: UpdateHistoryNamespace,
+				Labels:    map[string]string{updateHistoryLabelKey: ""},
+			},
+			Data: map[string]string{updateHistoryConfigMapKey: string(b)},
+		}
+		if dn.node != nil && dn.node.UID != "" {
+			cm.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Node", Name: dn.node.Name, UID: dn.node.UID}}
+		}
+		_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = map[string]string{updateHistoryConfigMapKey: string(b)}
+	_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return err
+}
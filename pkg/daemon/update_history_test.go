@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStartUpdateHistoryEntry(t *testing.T) {
+	now := metav1.NewTime(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	history := startUpdateHistoryEntry(nil, "rendered-worker-0", "rendered-worker-1", now)
+	assert.Equal(t, []UpdateHistoryEntry{
+		{From: "rendered-worker-0", To: "rendered-worker-1", StartTime: now, Attempts: 1, Result: UpdateResultInProgress},
+	}, history)
+
+	// Retrying a failed update reuses its entry.
+	assert.True(t, editLatestUpdateHistoryEntry(history, "rendered-worker-1", func(entry *UpdateHistoryEntry) {
+		entry.Result = UpdateResultFailed
+		entry.Error = "drain failed"
+	}))
+	history = startUpdateHistoryEntry(history, "rendered-worker-0", "rendered-worker-1", now)
+	require.Len(t, history, 1)
+	assert.Equal(t, 2, history[0].Attempts)
+	assert.Equal(t, UpdateResultInProgress, history[0].Result)
+	assert.Equal(t, "drain failed", history[0].Error)
+
+	// Only the latest entry is edited, and only for its config.
+	assert.False(t, editLatestUpdateHistoryEntry(history, "rendered-worker-2", func(entry *UpdateHistoryEntry) {
+		t.Fatal("unexpected edit")
+	}))
+
+	for i := 1; i <= maxUpdateHistoryEntries; i++ {
+		history[len(history)-1].Result = UpdateResultSucceeded
+		history = startUpdateHistoryEntry(history, fmt.Sprintf("rendered-worker-%d", i), fmt.Sprintf("rendered-worker-%d", i+1), now)
+	}
+	assert.Len(t, history, maxUpdateHistoryEntries)
+	assert.Equal(t, "rendered-worker-1", history[0].From)
+	assert.Equal(t, fmt.Sprintf("rendered-worker-%d", maxUpdateHistoryEntries+1), history[len(history)-1].To)
+}
+
+func TestUpdateHistoryStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "update-history.json")
+	history, err := loadUpdateHistory(path)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	rebootTime := metav1.NewTime(time.Date(2021, 3, 1, 12, 5, 0, 0, time.UTC).Local())
+	diff := &machineConfigDiff{files: true, kargs: true}
+	history = []UpdateHistoryEntry{{
+		From:                    "rendered-worker-0",
+		To:                      "rendered-worker-1",
+		StartTime:               metav1.NewTime(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC).Local()),
+		Attempts:                1,
+		Changes:                 diff.changedSections(),
+		Files:                   []string{"/etc/motd"},
+		DrainDuration:           &metav1.Duration{Duration: 90 * time.Second},
+		PostConfigChangeActions: []string{postConfigChangeActionReboot},
+		RebootTime:              &rebootTime,
+		Result:                  UpdateResultInProgress,
+	}}
+	assert.Equal(t, []string{"kargs", "files"}, history[0].Changes)
+	require.NoError(t, saveUpdateHistory(path, history))
+	loaded, err := loadUpdateHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, history, loaded)
+}
+
+func TestPublishUpdateHistory(t *testing.T) {
+	dn := newMockDaemon()
+	dn.node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "nodeName", UID: "node-uid"}}
+	history := []UpdateHistoryEntry{{From: "rendered-worker-0", To: "rendered-worker-1", Attempts: 1, Result: UpdateResultInProgress}}
+
+	getPublished := func() (*corev1.ConfigMap, []UpdateHistoryEntry) {
+		cm, err := dn.kubeClient.CoreV1().ConfigMaps(UpdateHistoryNamespace).Get(context.TODO(), "update-history-nodeName", metav1.GetOptions{})
+		require.NoError(t, err)
+		var published []UpdateHistoryEntry
+		require.NoError(t, json.Unmarshal([]byte(cm.Data[updateHistoryConfigMapKey]), &published))
+		return cm, published
+	}
+
+	require.NoError(t, dn.publishUpdateHistory(history))
+	cm, published := getPublished()
+	assert.Equal(t, history, published)
+	assert.Contains(t, cm.Labels, updateHistoryLabelKey)
+	assert.Equal(t, []metav1.OwnerReference{{APIVersion: "v1", Kind: "Node", Name: "nodeName", UID: "node-uid"}}, cm.OwnerReferences)
+
+	history[0].Result = UpdateResultSucceeded
+	require.NoError(t, dn.publishUpdateHistory(history))
+	_, published = getPublished()
+	assert.Equal(t, history, published)
+}
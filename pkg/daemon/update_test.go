@@ -153,7 +153,29 @@ func TestReconcilable(t *testing.T) {
 	_, isReconcilable = reconcilable(oldConfig, newConfig)
 	checkReconcilableResults(t, "Raid", isReconcilable)
 
-	// Verify Passwd Groups changes unsupported
+	// Verify appends are reconcilable as long as they can be decoded
+	appendFile := ign3types.File{Node: ign3types.Node{Path: "/etc/hosts.allow"}}
+	appendFile.Append = []ign3types.Resource{{Source: helpers.StrToPtr("data:,sshd%3A%20ALL%0A")}}
+	newIgnCfg.Storage.Files = []ign3types.File{appendFile}
+	newConfig = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, isReconcilable = reconcilable(oldConfig, newConfig)
+	checkReconcilableResults(t, "Append", isReconcilable)
+
+	appendFile.Append = []ign3types.Resource{{Source: helpers.StrToPtr("https://example.com/hosts.allow")}}
+	newIgnCfg.Storage.Files = []ign3types.File{appendFile}
+	newConfig = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, isReconcilable = reconcilable(oldConfig, newConfig)
+	checkIrreconcilableResults(t, "Append", isReconcilable)
+	newIgnCfg.Storage.Files = nil
+
+	// Verify Directories and Links changes are reconcilable
+	newIgnCfg.Storage.Directories = []ign3types.Directory{{Node: ign3types.Node{Path: "/etc/foo"}, DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o750)}}}
+	newIgnCfg.Storage.Links = []ign3types.Link{{Node: ign3types.Node{Path: "/etc/foo/bar"}, LinkEmbedded1: ign3types.LinkEmbedded1{Target: "/etc/bar"}}}
+	newConfig = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, isReconcilable = reconcilable(oldConfig, newConfig)
+	checkReconcilableResults(t, "DirectoriesAndLinks", isReconcilable)
+
+	// Verify Passwd Groups changes limited to local groups
 	oldIgnCfg = ctrlcommon.NewIgnConfig()
 	oldConfig = helpers.CreateMachineConfigFromIgnition(oldIgnCfg)
 	newIgnCfg = ctrlcommon.NewIgnConfig()
@@ -163,7 +185,13 @@ func TestReconcilable(t *testing.T) {
 	checkReconcilableResults(t, "PasswdGroups", isReconcilable)
 
 	tempGroup := ign3types.PasswdGroup{}
-	tempGroup.Name = "testGroup"
+	tempGroup.Name = "testgroup"
+	newIgnCfg.Passwd.Groups = []ign3types.PasswdGroup{tempGroup}
+	newConfig = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, isReconcilable = reconcilable(oldConfig, newConfig)
+	checkReconcilableResults(t, "PasswdGroups", isReconcilable)
+
+	tempGroup.System = helpers.BoolToPtr(true)
 	newIgnCfg.Passwd.Groups = []ign3types.PasswdGroup{tempGroup}
 	newConfig = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
 	_, isReconcilable = reconcilable(oldConfig, newConfig)
@@ -362,6 +390,41 @@ func TestKernelAguments(t *testing.T) {
 	}
 }
 
+func TestAreKernelArgsChangesDeferrable(t *testing.T) {
+	tests := []struct {
+		oldKargs []string
+		newKargs []string
+		out      bool
+	}{
+		{
+			oldKargs: nil,
+			newKargs: []string{"nosmt", "mitigations=auto,nosmt"},
+			out:      true,
+		},
+		{
+			oldKargs: []string{"hugepagesz=1G hugepages=4", "isolcpus=1-3"},
+			newKargs: []string{"hugepagesz=1G hugepages=8", "isolcpus=1-3"},
+			out:      true,
+		},
+		{
+			oldKargs: []string{"audit=1"},
+			newKargs: []string{"audit=1", "isolcpus=1-3"},
+			out:      false,
+		},
+		{
+			oldKargs: []string{"audit=1", "rd.driver.blacklist=foo"},
+			newKargs: []string{"audit=1"},
+			out:      false,
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			assert.Equal(t, test.out, areKernelArgsChangesDeferrable(test.oldKargs, test.newKargs))
+		})
+	}
+}
+
 func TestReconcilableSSH(t *testing.T) {
 	// Check that updating SSH Key of user core supported
 	oldIgnCfg := ctrlcommon.NewIgnConfig()
@@ -373,7 +436,7 @@ func TestReconcilableSSH(t *testing.T) {
 	_, errMsg := reconcilable(oldMcfg, newMcfg)
 	checkReconcilableResults(t, "SSH", errMsg)
 
-	// 	Check that updating User with User that is not core is not supported
+	// 	Check that replacing user core with another user is not supported
 	tempUser2 := ign3types.PasswdUser{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"1234"}}
 	oldIgnCfg.Passwd.Users = append(oldIgnCfg.Passwd.Users, tempUser2)
 	oldMcfg = helpers.CreateMachineConfigFromIgnition(oldIgnCfg)
@@ -390,7 +453,7 @@ func TestReconcilableSSH(t *testing.T) {
 	_, errMsg = reconcilable(oldMcfg, newMcfg)
 	checkIrreconcilableResults(t, "SSH", errMsg)
 
-	// check that we cannot add a user or have len(Passwd.Users)> 1
+	// check that we cannot add a user with an invalid name
 	tempUser5 := ign3types.PasswdUser{Name: "some user", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"5678"}}
 	newIgnCfg.Passwd.Users = append(newIgnCfg.Passwd.Users, tempUser5)
 	newMcfg = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
@@ -481,7 +544,7 @@ func TestWriteFiles(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := d.writeFiles(test.files, true)
+			err := d.writeFiles(nil, test.files, true)
 			assert.Equal(t, test.expectedErr, err)
 			if test.expectedContents != nil {
 				fileContents, err := os.ReadFile(filePath)
@@ -493,6 +556,194 @@ func TestWriteFiles(t *testing.T) {
 	}
 }
 
+func TestWriteAndDeleteDirectoriesAndLinks(t *testing.T) {
+	testDir, cleanup := setupTempDirWithEtc(t)
+	defer cleanup()
+
+	d := newMockDaemon()
+
+	// use current user so test doesn't try to chown to root
+	currentUser, err := user.Current()
+	require.Nil(t, err)
+	currentUID, err := strconv.Atoi(currentUser.Uid)
+	require.Nil(t, err)
+	currentGID, err := strconv.Atoi(currentUser.Gid)
+	require.Nil(t, err)
+	newNode := func(path string) ign3types.Node {
+		return ign3types.Node{
+			Path:  filepath.Join(testDir, path),
+			User:  ign3types.NodeUser{ID: &currentUID},
+			Group: ign3types.NodeGroup{ID: &currentGID},
+		}
+	}
+
+	// a directory that existed before the MCD wrote it is kept when it is dropped from the config
+	existingDir := filepath.Join(testDir, "etc", "existing")
+	require.Nil(t, os.Mkdir(existingDir, 0o755))
+	// a file that gets replaced by a link is restored when the link is dropped
+	replacedFile := filepath.Join(testDir, "etc", "replaced")
+	require.Nil(t, os.WriteFile(replacedFile, []byte("original"), 0o644))
+	targetFile := filepath.Join(testDir, "etc", "target")
+	require.Nil(t, os.WriteFile(targetFile, []byte("target"), 0o644))
+
+	newIgnCfg := ctrlcommon.NewIgnConfig()
+	newIgnCfg.Storage.Directories = []ign3types.Directory{
+		{Node: newNode("etc/new/nested"), DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o750)}},
+		{Node: newNode("etc/new"), DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o700)}},
+		{Node: newNode("etc/existing"), DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o700)}},
+		{Node: newNode("etc/sticky"), DirectoryEmbedded1: ign3types.DirectoryEmbedded1{Mode: helpers.IntToPtr(0o1777)}},
+	}
+	newIgnCfg.Storage.Links = []ign3types.Link{
+		{Node: newNode("etc/new/symlink"), LinkEmbedded1: ign3types.LinkEmbedded1{Target: targetFile}},
+		{Node: newNode("etc/replaced"), LinkEmbedded1: ign3types.LinkEmbedded1{Target: targetFile}},
+		{Node: newNode("etc/hardlink"), LinkEmbedded1: ign3types.LinkEmbedded1{Target: targetFile, Hard: helpers.BoolToPtr(true)}},
+	}
+
+	require.Nil(t, writeDirectories(newIgnCfg.Storage.Directories))
+	require.Nil(t, writeLinks(newIgnCfg.Storage.Links))
+	assert.Nil(t, checkV3Directories(newIgnCfg.Storage.Directories))
+	assert.Nil(t, checkV3Links(newIgnCfg.Storage.Links))
+
+	fi, err := os.Stat(filepath.Join(testDir, "etc", "new"))
+	require.Nil(t, err)
+	assert.Equal(t, os.ModeDir|0o700, fi.Mode())
+	fi, err = os.Stat(filepath.Join(testDir, "etc", "sticky"))
+	require.Nil(t, err)
+	assert.Equal(t, os.ModeDir|os.ModeSticky|0o777, fi.Mode())
+	contents, err := os.ReadFile(replacedFile)
+	require.Nil(t, err)
+	assert.Equal(t, "target", string(contents))
+
+	// drift is detected
+	require.Nil(t, os.Chmod(existingDir, 0o755))
+	assert.NotNil(t, checkV3Directories(newIgnCfg.Storage.Directories))
+	require.Nil(t, os.Chmod(existingDir, 0o700))
+	require.Nil(t, os.Remove(filepath.Join(testDir, "etc", "new", "symlink")))
+	require.Nil(t, os.Symlink(replacedFile, filepath.Join(testDir, "etc", "new", "symlink")))
+	assert.NotNil(t, checkV3Links(newIgnCfg.Storage.Links))
+
+	// keep a file the MCD doesn't know about in one of the directories
+	require.Nil(t, os.WriteFile(filepath.Join(testDir, "etc", "new", "unmanaged"), nil, 0o644))
+
+	require.Nil(t, d.deleteStaleData(newIgnCfg, ctrlcommon.NewIgnConfig()))
+
+	for path, exists := range map[string]bool{
+		"etc/new/nested":  false,
+		"etc/new/symlink": false,
+		"etc/new":         true,
+		"etc/existing":    true,
+		"etc/sticky":      false,
+		"etc/hardlink":    false,
+		"etc/target":      true,
+	} {
+		_, err := os.Lstat(filepath.Join(testDir, path))
+		assert.Equal(t, exists, err == nil, path)
+	}
+	contents, err = os.ReadFile(replacedFile)
+	require.Nil(t, err)
+	assert.Equal(t, "original", string(contents))
+}
+
+func TestWriteFilesWithAppends(t *testing.T) {
+	testDir, cleanup := setupTempDirWithEtc(t)
+	defer cleanup()
+
+	d := newMockDaemon()
+
+	// use current user so test doesn't try to chown to root
+	currentUser, err := user.Current()
+	require.Nil(t, err)
+	currentUID, err := strconv.Atoi(currentUser.Uid)
+	require.Nil(t, err)
+	currentGID, err := strconv.Atoi(currentUser.Gid)
+	require.Nil(t, err)
+
+	newFile := func(path string, contents *string, appends ...string) ign3types.File {
+		file := ign3types.File{
+			Node: ign3types.Node{
+				Path:  filepath.Join(testDir, path),
+				User:  ign3types.NodeUser{ID: &currentUID},
+				Group: ign3types.NodeGroup{ID: &currentGID},
+			},
+			FileEmbedded1: ign3types.FileEmbedded1{Mode: helpers.IntToPtr(0o644)},
+		}
+		if contents != nil {
+			file.Contents.Source = helpers.StrToPtr(dataurl.EncodeBytes([]byte(*contents)))
+		}
+		for _, a := range appends {
+			file.Append = append(file.Append, ign3types.Resource{Source: helpers.StrToPtr(dataurl.EncodeBytes([]byte(a)))})
+		}
+		return file
+	}
+
+	tests := []struct {
+		name     string
+		existing *string
+		file     ign3types.File
+		expected string
+	}{{
+		name:     "append to an existing file",
+		existing: helpers.StrToPtr("ALL: 10.0.0.0/8\n"),
+		file:     newFile("etc/hosts.allow", nil, "sshd: 192.168.0.0/16\n", "sshd: 172.16.0.0/12\n"),
+		expected: "ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\nsshd: 172.16.0.0/12\n",
+	}, {
+		name:     "append to a new file",
+		file:     newFile("etc/new-file", nil, "first\n", "second\n"),
+		expected: "first\nsecond\n",
+	}, {
+		name:     "append to contents",
+		existing: helpers.StrToPtr("replaced\n"),
+		file:     newFile("etc/contents", helpers.StrToPtr("contents\n"), "appended\n"),
+		expected: "contents\nappended\n",
+	}, {
+		name:     "appends already applied by Ignition",
+		existing: helpers.StrToPtr("ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\n"),
+		file:     newFile("etc/provisioned", nil, "sshd: 192.168.0.0/16\n"),
+		expected: "ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\n",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.existing != nil {
+				require.Nil(t, os.WriteFile(test.file.Path, []byte(*test.existing), 0o644))
+			}
+			// applying the file again must not append twice
+			for i := 0; i < 2; i++ {
+				require.Nil(t, d.writeFiles([]ign3types.File{test.file}, []ign3types.File{test.file}, true))
+				contents, err := os.ReadFile(test.file.Path)
+				require.Nil(t, err)
+				assert.Equal(t, test.expected, string(contents))
+			}
+			assert.Nil(t, checkV3Files([]ign3types.File{test.file}))
+
+			require.Nil(t, os.WriteFile(test.file.Path, []byte(test.expected+test.expected), 0o644))
+			assert.NotNil(t, checkV3Files([]ign3types.File{test.file}))
+		})
+	}
+
+	t.Run("changed appends", func(t *testing.T) {
+		oldFile := newFile("etc/changed", nil, "sshd: 192.168.0.0/16\n")
+		changedFile := newFile("etc/changed", nil, "sshd: 192.168.0.0/16\n", "sshd: 172.16.0.0/12\n")
+		// Ignition applied the appends of the old config when provisioning the node
+		require.Nil(t, os.WriteFile(oldFile.Path, []byte("ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\n"), 0o644))
+
+		require.Nil(t, d.writeFiles([]ign3types.File{oldFile}, []ign3types.File{changedFile}, true))
+		contents, err := os.ReadFile(changedFile.Path)
+		require.Nil(t, err)
+		assert.Equal(t, "ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\nsshd: 172.16.0.0/12\n", string(contents))
+		orig, err := os.ReadFile(origFileName(changedFile.Path))
+		require.Nil(t, err)
+		assert.Equal(t, "ALL: 10.0.0.0/8\n", string(orig))
+		assert.Nil(t, checkV3Files([]ign3types.File{changedFile}))
+
+		// and going back to the old config removes the new append
+		require.Nil(t, d.writeFiles([]ign3types.File{changedFile}, []ign3types.File{oldFile}, true))
+		contents, err = os.ReadFile(oldFile.Path)
+		require.Nil(t, err)
+		assert.Equal(t, "ALL: 10.0.0.0/8\nsshd: 192.168.0.0/16\n", string(contents))
+	})
+}
+
 // This test provides a false sense of security. Given the combination of the
 // mock mode in the MCD coupled with the inputs into this test, it effectively
 // no-ops and does not test what we think it tests.
@@ -626,11 +877,35 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 		"policy2":         ctrlcommon.NewIgnFile("/etc/containers/policy.json", "policy2"),
 		"containers-gpg1": ctrlcommon.NewIgnFile("/etc/machine-config-daemon/no-reboot/containers-gpg.pub", "containers-gpg1"),
 		"containers-gpg2": ctrlcommon.NewIgnFile("/etc/machine-config-daemon/no-reboot/containers-gpg.pub", "containers-gpg2"),
+		"chrony1":         ctrlcommon.NewIgnFile("/etc/chrony.d/servers.conf", "server a\n"),
+		"chrony2":         ctrlcommon.NewIgnFile("/etc/chrony.d/servers.conf", "server b\n"),
+		"sshd1":           ctrlcommon.NewIgnFile("/etc/ssh/sshd_config.d/10-custom.conf", "PermitRootLogin no\n"),
+		"sshd2":           ctrlcommon.NewIgnFile("/etc/ssh/sshd_config.d/10-custom.conf", "PermitRootLogin yes\n"),
+		"motd1":           ctrlcommon.NewIgnFile("/etc/motd", "hello\n"),
+		"motd2":           ctrlcommon.NewIgnFile("/etc/motd", "hello again\n"),
+	}
+
+	units := map[string]ign3types.Unit{
+		"chronyd1":  {Name: "chronyd.service", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Service]\nExecStart=/usr/sbin/chronyd\n")},
+		"chronyd2":  {Name: "chronyd.service", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Service]\nExecStart=/usr/sbin/chronyd -d\n")},
+		"kubelet1":  {Name: "kubelet.service", Dropins: []ign3types.Dropin{{Name: "10-foo.conf", Contents: helpers.StrToPtr("[Service]\n")}}},
+		"kubelet2":  {Name: "kubelet.service", Dropins: []ign3types.Dropin{{Name: "10-foo.conf", Contents: helpers.StrToPtr("[Service]\nCPUWeight=1\n")}}},
+		"foo-mount": {Name: "var-foo.mount", Enabled: helpers.BoolToPtr(true), Contents: helpers.StrToPtr("[Mount]\n")},
+	}
+
+	rules := []mcfgv1.PostConfigChangeActionRule{
+		{Path: "/etc/motd", Type: mcfgv1.PostConfigChangeActionNone},
+		{Path: "/etc/ssh/sshd_config.d/*", Type: mcfgv1.PostConfigChangeActionReload, Unit: "sshd.service"},
+		{Path: "/etc/chrony.d/*.conf", Type: mcfgv1.PostConfigChangeActionRestart, Unit: "chronyd.service"},
+		{Path: "/etc/random-*", Type: mcfgv1.PostConfigChangeActionReboot},
 	}
 
 	tests := []struct {
 		oldConfig      *mcfgv1.MachineConfig
 		newConfig      *mcfgv1.MachineConfig
+		rules          []mcfgv1.PostConfigChangeActionRule
+		kargsPolicy    mcfgv1.KernelArgumentsRebootPolicy
+		rebootUnits    []string
 		expectedAction []string
 	}{
 		{
@@ -705,6 +980,128 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["containers-gpg2"]}),
 			expectedAction: []string{postConfigChangeActionReloadCrio},
 		},
+		{
+			// test that changing a safe unit restarts it
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd1"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd2"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionDaemonReload, "restart chronyd.service"},
+		},
+		{
+			// test that changing a unit the pool always reboots for is reboot
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd1"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd2"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			rebootUnits:    []string{"chrony*.service"},
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that unit changes are combined with file actions
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{files["policy1"], files["pullsecret1"]}, []ign3types.Unit{units["chronyd1"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{files["policy2"], files["pullsecret2"]}, []ign3types.Unit{units["chronyd2"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionDaemonReload, postConfigChangeActionReloadCrio, "restart chronyd.service"},
+		},
+		{
+			// test that changing a kubelet dropin is reboot
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd1"], units["kubelet1"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["chronyd2"], units["kubelet2"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a unit change still reboots along with a file needing a reboot
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{files["randomfile1"]}, []ign3types.Unit{units["chronyd1"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{files["randomfile2"]}, []ign3types.Unit{units["chronyd2"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that adding a mount unit is reboot
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{units["foo-mount"]}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a deferrable kargs change is reboot by default
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"audit=0"}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"audit=1", "nosmt"}, "default", "dummy://"),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a deferrable kargs change is deferred with the Deferred policy
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"audit=0"}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"audit=1", "nosmt"}, "default", "dummy://"),
+			kargsPolicy:    mcfgv1.KernelArgumentsRebootDeferred,
+			expectedAction: []string{postConfigChangeActionDeferReboot},
+		},
+		{
+			// test that a deferred kargs change is combined with file actions
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{files["policy1"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{files["policy2"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"mitigations=off"}, "default", "dummy://"),
+			kargsPolicy:    mcfgv1.KernelArgumentsRebootDeferred,
+			expectedAction: []string{postConfigChangeActionReloadCrio, postConfigChangeActionDeferReboot},
+		},
+		{
+			// test that kargs not on the allowlist still reboot with the Deferred policy
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"nosmt", "isolcpus=1-3"}, "default", "dummy://"),
+			kargsPolicy:    mcfgv1.KernelArgumentsRebootDeferred,
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a file needing a reboot overrides a deferred kargs change
+			oldConfig:      helpers.NewMachineConfigExtended("00-test", nil, nil, []ign3types.File{files["randomfile1"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{}, "default", "dummy://"),
+			newConfig:      helpers.NewMachineConfigExtended("01-test", nil, nil, []ign3types.File{files["randomfile2"]}, []ign3types.Unit{}, []ign3types.SSHAuthorizedKey{}, []string{}, false, []string{"nosmt"}, "default", "dummy://"),
+			kargsPolicy:    mcfgv1.KernelArgumentsRebootDeferred,
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a file matching a None rule is none
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["motd1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["motd2"]}),
+			rules:          rules,
+			expectedAction: []string{postConfigChangeActionNone},
+		},
+		{
+			// test that a file matching a Restart rule restarts the unit
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["chrony1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["chrony2"]}),
+			rules:          rules,
+			expectedAction: []string{"restart chronyd.service"},
+		},
+		{
+			// test that the same change without rules is reboot
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["chrony1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["chrony2"]}),
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that rule actions are combined in rule order after a crio reload
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["chrony1"], files["policy1"], files["sshd1"], files["motd1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["chrony2"], files["policy2"], files["sshd2"], files["motd2"]}),
+			rules:          rules,
+			expectedAction: []string{postConfigChangeActionReloadCrio, "reload sshd.service", "restart chronyd.service"},
+		},
+		{
+			// test that a Reboot rule overrides the other rule actions
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["sshd1"], files["randomfile1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["sshd2"], files["randomfile2"]}),
+			rules:          rules,
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that a Restart rule without a unit is ignored
+			oldConfig:      helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["chrony1"]}),
+			newConfig:      helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["chrony2"]}),
+			rules:          []mcfgv1.PostConfigChangeActionRule{{Path: "/etc/chrony.d/*", Type: mcfgv1.PostConfigChangeActionRestart}},
+			expectedAction: []string{postConfigChangeActionReboot},
+		},
+		{
+			// test that the first matching rule wins
+			oldConfig: helpers.NewMachineConfig("00-test", nil, "dummy://", []ign3types.File{files["chrony1"]}),
+			newConfig: helpers.NewMachineConfig("01-test", nil, "dummy://", []ign3types.File{files["chrony2"]}),
+			rules: []mcfgv1.PostConfigChangeActionRule{
+				{Path: "/etc/chrony.d/servers.conf", Type: mcfgv1.PostConfigChangeActionNone},
+				{Path: "/etc/chrony.d/*", Type: mcfgv1.PostConfigChangeActionRestart, Unit: "chronyd.service"},
+			},
+			expectedAction: []string{postConfigChangeActionNone},
+		},
 	}
 
 	for idx, test := range tests {
@@ -722,7 +1119,11 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 				t.Errorf("error creating machineConfigDiff: %v", err)
 			}
 			diffFileSet := ctrlcommon.CalculateConfigFileDiffs(&oldIgnConfig, &newIgnConfig)
-			calculatedAction, err := calculatePostConfigChangeAction(mcDiff, diffFileSet)
+			pool := helpers.NewMachineConfigPool("test", nil, nil, "")
+			pool.Spec.PostConfigChangeActions = test.rules
+			pool.Spec.KernelArgumentsRebootPolicy = test.kargsPolicy
+			pool.Spec.UnitsRequiringReboot = test.rebootUnits
+			calculatedAction, err := calculatePostConfigChangeAction(mcDiff, diffFileSet, pool)
 
 			if !reflect.DeepEqual(test.expectedAction, calculatedAction) {
 				t.Errorf("Failed calculating config change action: expected: %v but result is: %v. Error: %v", test.expectedAction, calculatedAction, err)
@@ -731,6 +1132,110 @@ func TestCalculatePostConfigChangeAction(t *testing.T) {
 	}
 }
 
+func TestCalculateUnitPostConfigChangeActions(t *testing.T) {
+	contents1 := helpers.StrToPtr("[Service]\nExecStart=/usr/bin/foo\n")
+	contents2 := helpers.StrToPtr("[Service]\nExecStart=/usr/bin/foo --bar\n")
+	dropins1 := []ign3types.Dropin{{Name: "10-foo.conf", Contents: contents1}}
+	dropins2 := []ign3types.Dropin{{Name: "10-foo.conf", Contents: contents2}}
+
+	tests := []struct {
+		name       string
+		oldUnits   []ign3types.Unit
+		newUnits   []ign3types.Unit
+		extraUnits []string
+		expected   []string
+	}{
+		{
+			name:     "unchanged units",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents1}},
+			newUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents1}},
+			expected: []string{},
+		},
+		{
+			name:     "added enabled unit is restarted",
+			newUnits: []ign3types.Unit{{Name: "foo.service", Enabled: helpers.BoolToPtr(true), Contents: contents1}},
+			expected: []string{"restart foo.service"},
+		},
+		{
+			name:     "changed unit using presets is restarted if running",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents1}},
+			newUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents2}},
+			expected: []string{"try-restart foo.service"},
+		},
+		{
+			name:     "disabled unit is stopped",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Enabled: helpers.BoolToPtr(true), Contents: contents1}},
+			newUnits: []ign3types.Unit{{Name: "foo.service", Enabled: helpers.BoolToPtr(false), Contents: contents1}},
+			expected: []string{"stop foo.service"},
+		},
+		{
+			name:     "masked unit is stopped",
+			newUnits: []ign3types.Unit{{Name: "foo.service", Mask: helpers.BoolToPtr(true)}},
+			expected: []string{"stop foo.service"},
+		},
+		{
+			name:     "removed unit is stopped",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents1}, {Name: "bar.service", Contents: contents1}},
+			newUnits: []ign3types.Unit{{Name: "bar.service", Contents: contents1}},
+			expected: []string{"stop foo.service"},
+		},
+		{
+			name:     "removed dropin restarts the unit if running",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Dropins: dropins1}},
+			expected: []string{"try-restart foo.service"},
+		},
+		{
+			name:     "changed dropin restarts the unit if running",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Dropins: dropins1}},
+			newUnits: []ign3types.Unit{{Name: "foo.service", Dropins: dropins2}},
+			expected: []string{"try-restart foo.service"},
+		},
+		{
+			name:     "changed crio dropin requires reboot",
+			oldUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents1}, {Name: "crio.service", Dropins: dropins1}},
+			newUnits: []ign3types.Unit{{Name: "foo.service", Contents: contents2}, {Name: "crio.service", Dropins: dropins2}},
+			expected: nil,
+		},
+		{
+			name:     "removed kubelet dropin requires reboot",
+			oldUnits: []ign3types.Unit{{Name: "kubelet.service", Dropins: dropins1}},
+			expected: nil,
+		},
+		{
+			name:       "changed unit matching an extra unit glob requires reboot",
+			oldUnits:   []ign3types.Unit{{Name: "foo.service", Contents: contents1}},
+			newUnits:   []ign3types.Unit{{Name: "foo.service", Contents: contents2}},
+			extraUnits: []string{"bar.service", "fo*.service"},
+			expected:   nil,
+		},
+		{
+			name:       "removed extra unit requires reboot",
+			oldUnits:   []ign3types.Unit{{Name: "bar.service", Contents: contents1}},
+			extraUnits: []string{"bar.service"},
+			expected:   nil,
+		},
+		{
+			name:       "changed unit not matching an extra unit is restarted",
+			oldUnits:   []ign3types.Unit{{Name: "foo.service", Contents: contents1}},
+			newUnits:   []ign3types.Unit{{Name: "foo.service", Contents: contents2}},
+			extraUnits: []string{"bar.service", "[invalid"},
+			expected:   []string{"try-restart foo.service"},
+		},
+		{
+			name:     "changed timer requires reboot",
+			oldUnits: []ign3types.Unit{{Name: "foo.timer", Contents: contents1}},
+			newUnits: []ign3types.Unit{{Name: "foo.timer", Contents: contents2}},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, calculateUnitPostConfigChangeActions(test.oldUnits, test.newUnits, test.extraUnits))
+		})
+	}
+}
+
 // checkReconcilableResults is a shortcut for verifying results that should be reconcilable
 func checkReconcilableResults(t *testing.T, key string, reconcilableError error) {
 	if reconcilableError != nil {
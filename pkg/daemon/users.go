@@ -0,0 +1,453 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// minLocalID is the lowest UID and GID of the local users and groups the MCD manages;
+// lower IDs belong to system accounts.
+const minLocalID = 1000
+
+// localNameRegexp matches the user and group names accepted by useradd and groupadd.
+var localNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// verifyPasswd checks that the changes to the passwd section are limited to the SSH keys and
+// password hash of the core user, and to local users and groups the MCD can manage in place.
+func verifyPasswd(oldPasswd, newPasswd ign3types.Passwd) error {
+	oldGroups := make(map[string]ign3types.PasswdGroup, len(oldPasswd.Groups))
+	for _, group := range oldPasswd.Groups {
+		oldGroups[group.Name] = group
+	}
+	for _, group := range newPasswd.Groups {
+		oldGroup, ok := oldGroups[group.Name]
+		if ok && reflect.DeepEqual(oldGroup, group) {
+			continue
+		}
+		if err := verifyGroupFields(group, oldGroups[group.Name].Gid); err != nil {
+			return fmt.Errorf("ignition passwd group section contains unsupported changes: %w", err)
+		}
+	}
+
+	oldUsers := make(map[string]ign3types.PasswdUser, len(oldPasswd.Users))
+	for _, u := range oldPasswd.Users {
+		oldUsers[u.Name] = u
+	}
+	hasCore := false
+	for _, u := range newPasswd.Users {
+		oldUser, ok := oldUsers[u.Name]
+		if u.Name == constants.CoreUserName {
+			hasCore = true
+			if ok && reflect.DeepEqual(oldUser, u) {
+				continue
+			}
+			glog.Infof("user data to be verified before ssh update: %v", u)
+			if err := verifyUserFields(u); err != nil {
+				return err
+			}
+			continue
+		}
+		if ok && reflect.DeepEqual(oldUser, u) {
+			continue
+		}
+		var oldLocalUser *ign3types.PasswdUser
+		if ok {
+			oldLocalUser = &oldUser
+		}
+		if err := verifyLocalUserFields(u, oldLocalUser); err != nil {
+			return fmt.Errorf("ignition passwd user section contains unsupported changes: %w", err)
+		}
+	}
+	if _, ok := oldUsers[constants.CoreUserName]; ok && !hasCore {
+		return fmt.Errorf("ignition passwd user section contains unsupported changes: user core may not be deleted")
+	}
+	return nil
+}
+
+// verifyLocalUserFields checks that a local user other than core only sets fields the MCD can
+// reconcile. oldUser is the user as declared in the old config, if it was.
+func verifyLocalUserFields(u ign3types.PasswdUser, oldUser *ign3types.PasswdUser) error {
+	if !localNameRegexp.MatchString(u.Name) {
+		return fmt.Errorf("invalid user name %q", u.Name)
+	}
+	if u.ShouldExist != nil && !*u.ShouldExist {
+		return nil
+	}
+	if isTrue(u.System) {
+		return fmt.Errorf("user %s: system users are not supported", u.Name)
+	}
+	if isTrue(u.NoCreateHome) || isTrue(u.NoUserGroup) || isTrue(u.NoLogInit) {
+		return fmt.Errorf("user %s: noCreateHome, noUserGroup and noLogInit are not supported", u.Name)
+	}
+	if u.UID != nil && *u.UID < minLocalID {
+		return fmt.Errorf("user %s: uid %d is reserved for system users", u.Name, *u.UID)
+	}
+	if oldUser != nil && (oldUser.ShouldExist == nil || *oldUser.ShouldExist) {
+		if oldUser.UID != nil && !reflect.DeepEqual(oldUser.UID, u.UID) {
+			return fmt.Errorf("user %s: uid may not be changed", u.Name)
+		}
+		if !reflect.DeepEqual(oldUser.HomeDir, u.HomeDir) {
+			return fmt.Errorf("user %s: homeDir may not be changed", u.Name)
+		}
+	}
+	return nil
+}
+
+// verifyGroupFields checks that a local group only sets fields the MCD can reconcile.
+// oldGID is the gid of the group as declared in the old config, if any.
+func verifyGroupFields(group ign3types.PasswdGroup, oldGID *int) error {
+	if !localNameRegexp.MatchString(group.Name) {
+		return fmt.Errorf("invalid group name %q", group.Name)
+	}
+	if group.ShouldExist != nil && !*group.ShouldExist {
+		return nil
+	}
+	if isTrue(group.System) {
+		return fmt.Errorf("group %s: system groups are not supported", group.Name)
+	}
+	if group.PasswordHash != nil {
+		return fmt.Errorf("group %s: group passwords are not supported", group.Name)
+	}
+	if group.Gid != nil && *group.Gid < minLocalID {
+		return fmt.Errorf("group %s: gid %d is reserved for system groups", group.Name, *group.Gid)
+	}
+	if oldGID != nil && !reflect.DeepEqual(oldGID, group.Gid) {
+		return fmt.Errorf("group %s: gid may not be changed", group.Name)
+	}
+	return nil
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// updateLocalUsersAndGroups reconciles the local users and groups other than core declared in
+// newPasswd: groups are created, users are created or updated, and users dropped since
+// oldPasswd are locked. Users and groups declared with shouldExist false are removed.
+func (dn *Daemon) updateLocalUsersAndGroups(oldPasswd, newPasswd ign3types.Passwd) error {
+	if dn.mock || reflect.DeepEqual(oldPasswd, newPasswd) {
+		return nil
+	}
+
+	for _, group := range newPasswd.Groups {
+		if group.ShouldExist != nil && !*group.ShouldExist {
+			if err := removeLocalGroup(group.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ensureLocalGroup(group); err != nil {
+			return err
+		}
+	}
+
+	declared := make(map[string]bool, len(newPasswd.Users))
+	for _, u := range newPasswd.Users {
+		declared[u.Name] = true
+		if u.Name == constants.CoreUserName {
+			continue
+		}
+		if u.ShouldExist != nil && !*u.ShouldExist {
+			if err := removeLocalUser(u.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ensureLocalUser(u); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range oldPasswd.Users {
+		if u.Name == constants.CoreUserName || declared[u.Name] {
+			continue
+		}
+		if err := lockLocalUser(u.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupLocalUser returns the user name on the node, or nil if it doesn't exist. It refuses
+// to return system users.
+func lookupLocalUser(name string) (*user.User, error) {
+	var uErr user.UnknownUserError
+	existing, err := user.Lookup(name)
+	switch {
+	case errors.As(err, &uErr):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to look up user %s: %w", name, err)
+	}
+	if uid, err := strconv.Atoi(existing.Uid); err != nil || uid < minLocalID {
+		return nil, fmt.Errorf("refusing to manage system user %s with uid %s", name, existing.Uid)
+	}
+	return existing, nil
+}
+
+// lookupLocalGroup returns the group name on the node, or nil if it doesn't exist. It refuses
+// to return system groups.
+func lookupLocalGroup(name string) (*user.Group, error) {
+	var gErr user.UnknownGroupError
+	existing, err := user.LookupGroup(name)
+	switch {
+	case errors.As(err, &gErr):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to look up group %s: %w", name, err)
+	}
+	if gid, err := strconv.Atoi(existing.Gid); err != nil || gid < minLocalID {
+		return nil, fmt.Errorf("refusing to manage system group %s with gid %s", name, existing.Gid)
+	}
+	return existing, nil
+}
+
+func ensureLocalGroup(group ign3types.PasswdGroup) error {
+	existing, err := lookupLocalGroup(group.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	args := []string{}
+	if group.Gid != nil {
+		args = append(args, "--gid", strconv.Itoa(*group.Gid))
+	}
+	logSystem("Creating group %s", group.Name)
+	return runUserCmd("groupadd", append(args, group.Name)...)
+}
+
+func removeLocalGroup(name string) error {
+	existing, err := lookupLocalGroup(name)
+	if err != nil || existing == nil {
+		return err
+	}
+	logSystem("Removing group %s", name)
+	return runUserCmd("groupdel", name)
+}
+
+func ensureLocalUser(u ign3types.PasswdUser) error {
+	existing, err := lookupLocalUser(u.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		logSystem("Creating user %s", u.Name)
+		if err := runUserCmd("useradd", userAddArgs(u)...); err != nil {
+			return err
+		}
+	} else {
+		logSystem("Updating user %s", u.Name)
+		if err := runUserCmd("usermod", userModArgs(u)...); err != nil {
+			return err
+		}
+	}
+	return writeLocalUserSSHKeys(u)
+}
+
+// lockLocalUser locks the password and expires the account of a user dropped from the config,
+// which also denies SSH key logins, and removes its authorized keys.
+func lockLocalUser(name string) error {
+	existing, err := lookupLocalUser(name)
+	if err != nil || existing == nil {
+		return err
+	}
+	logSystem("Locking user %s", name)
+	if err := runUserCmd("usermod", "--lock", "--expiredate", "1", name); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(existing.HomeDir, ".ssh", "authorized_keys")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove SSH keys of user %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeLocalUser deletes a user, keeping its home directory.
+func removeLocalUser(name string) error {
+	existing, err := lookupLocalUser(name)
+	if err != nil || existing == nil {
+		return err
+	}
+	logSystem("Removing user %s", name)
+	return runUserCmd("userdel", name)
+}
+
+// userAddArgs returns the useradd arguments creating u.
+func userAddArgs(u ign3types.PasswdUser) []string {
+	args := []string{"--create-home", "--password", passwordHashOrLocked(u.PasswordHash)}
+	if u.UID != nil {
+		args = append(args, "--uid", strconv.Itoa(*u.UID))
+	}
+	if u.HomeDir != nil {
+		args = append(args, "--home-dir", *u.HomeDir)
+	}
+	if groups := userGroups(u); groups != "" {
+		args = append(args, "--groups", groups)
+	}
+	return append(append(args, userAttributeArgs(u)...), u.Name)
+}
+
+// userModArgs returns the usermod arguments updating an existing user to u. The account is
+// unlocked in case u was dropped from a previous config, and its supplementary groups are
+// replaced by the declared ones.
+func userModArgs(u ign3types.PasswdUser) []string {
+	args := []string{"--password", passwordHashOrLocked(u.PasswordHash), "--expiredate", "", "--groups", userGroups(u)}
+	return append(append(args, userAttributeArgs(u)...), u.Name)
+}
+
+func userAttributeArgs(u ign3types.PasswdUser) []string {
+	var args []string
+	if u.PrimaryGroup != nil {
+		args = append(args, "--gid", *u.PrimaryGroup)
+	}
+	if u.Shell != nil {
+		args = append(args, "--shell", *u.Shell)
+	}
+	if u.Gecos != nil {
+		args = append(args, "--comment", *u.Gecos)
+	}
+	return args
+}
+
+func userGroups(u ign3types.PasswdUser) string {
+	groups := make([]string, 0, len(u.Groups))
+	for _, group := range u.Groups {
+		groups = append(groups, string(group))
+	}
+	return strings.Join(groups, ",")
+}
+
+// passwordHashOrLocked returns hash, or "*" which disables password logins.
+func passwordHashOrLocked(hash *string) string {
+	if hash == nil || *hash == "" {
+		return "*"
+	}
+	return *hash
+}
+
+// runUserCmd runs one of the shadow-utils commands. Its arguments aren't logged since they
+// may contain password hashes.
+func runUserCmd(name string, args ...string) error {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed for %s: %s: %w", name, args[len(args)-1], strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// writeLocalUserSSHKeys writes the authorized keys of u in its home directory.
+func writeLocalUserSSHKeys(u ign3types.PasswdUser) error {
+	existing, err := lookupLocalUser(u.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("user %s not found after creating it", u.Name)
+	}
+	authKeyPath := filepath.Join(existing.HomeDir, ".ssh", "authorized_keys")
+	if len(u.SSHAuthorizedKeys) == 0 {
+		if err := os.Remove(authKeyPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove SSH keys of user %s: %w", u.Name, err)
+		}
+		return nil
+	}
+	uid, _ := strconv.Atoi(existing.Uid)
+	gid, _ := strconv.Atoi(existing.Gid)
+	if err := writeFileAtomically(authKeyPath, []byte(concatSSHKeys(u.SSHAuthorizedKeys)), os.FileMode(0o700), os.FileMode(0o600), uid, gid); err != nil {
+		return fmt.Errorf("failed to write SSH keys of user %s: %w", u.Name, err)
+	}
+	return nil
+}
+
+func concatSSHKeys(keys []ign3types.SSHAuthorizedKey) string {
+	var concat string
+	for _, k := range keys {
+		concat = concat + string(k) + "\n"
+	}
+	return concat
+}
+
+// checkLocalUsers validates that the local users other than core declared in passwd exist
+// with the declared uid, shell, groups and SSH keys.
+func checkLocalUsers(passwd ign3types.Passwd) error {
+	for _, u := range passwd.Users {
+		if u.Name == constants.CoreUserName {
+			continue
+		}
+		existing, err := lookupLocalUser(u.Name)
+		if err != nil {
+			return err
+		}
+		if u.ShouldExist != nil && !*u.ShouldExist {
+			if existing != nil {
+				return fmt.Errorf("user %s exists but should not", u.Name)
+			}
+			continue
+		}
+		if existing == nil {
+			return fmt.Errorf("user %s does not exist", u.Name)
+		}
+		if u.UID != nil && existing.Uid != strconv.Itoa(*u.UID) {
+			return fmt.Errorf("user %s: expected uid %d, have %s", u.Name, *u.UID, existing.Uid)
+		}
+		groupIDs, err := existing.GroupIds()
+		if err != nil {
+			return fmt.Errorf("failed to get groups of user %s: %w", u.Name, err)
+		}
+		for _, groupName := range u.Groups {
+			group, err := user.LookupGroup(string(groupName))
+			if err != nil {
+				return fmt.Errorf("user %s: failed to look up group %s: %w", u.Name, groupName, err)
+			}
+			found := false
+			for _, id := range groupIDs {
+				found = found || id == group.Gid
+			}
+			if !found {
+				return fmt.Errorf("user %s is not a member of group %s", u.Name, groupName)
+			}
+		}
+		if u.Shell != nil {
+			shell, err := getLoginShell(u.Name)
+			if err != nil {
+				return err
+			}
+			if shell != *u.Shell {
+				return fmt.Errorf("user %s: expected shell %s, have %s", u.Name, *u.Shell, shell)
+			}
+		}
+		authKeyPath := filepath.Join(existing.HomeDir, ".ssh", "authorized_keys")
+		if len(u.SSHAuthorizedKeys) > 0 {
+			if err := checkFileContentsAndMode(authKeyPath, []byte(concatSSHKeys(u.SSHAuthorizedKeys)), os.FileMode(0o600)); err != nil {
+				return fmt.Errorf("user %s: %w", u.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getLoginShell returns the login shell of name from the passwd database.
+func getLoginShell(name string) (string, error) {
+	out, err := exec.Command("getent", "passwd", name).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get passwd entry of user %s: %w", name, err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ":")
+	if len(fields) != 7 {
+		return "", fmt.Errorf("invalid passwd entry of user %s", name)
+	}
+	return fields[6], nil
+}
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"testing"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPasswd(t *testing.T) {
+	core := ign3types.PasswdUser{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"1234"}}
+	alice := ign3types.PasswdUser{Name: "alice", UID: helpers.IntToPtr(1500), SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"5678"}}
+	admins := ign3types.PasswdGroup{Name: "admins", Gid: helpers.IntToPtr(2000)}
+
+	withUser := func(u ign3types.PasswdUser, edit func(*ign3types.PasswdUser)) ign3types.PasswdUser {
+		edit(&u)
+		return u
+	}
+	withGroup := func(g ign3types.PasswdGroup, edit func(*ign3types.PasswdGroup)) ign3types.PasswdGroup {
+		edit(&g)
+		return g
+	}
+
+	tests := []struct {
+		name    string
+		old     ign3types.Passwd
+		new     ign3types.Passwd
+		wantErr string
+	}{{
+		name: "no changes",
+		old:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}, Groups: []ign3types.PasswdGroup{admins}},
+		new:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}, Groups: []ign3types.PasswdGroup{admins}},
+	}, {
+		name: "add user and group",
+		old:  ign3types.Passwd{Users: []ign3types.PasswdUser{core}},
+		new: ign3types.Passwd{
+			Users:  []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) { u.Groups = []ign3types.Group{"admins"} })},
+			Groups: []ign3types.PasswdGroup{admins},
+		},
+	}, {
+		name: "update user keys, shell and password",
+		old:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}},
+		new: ign3types.Passwd{Users: []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) {
+			u.SSHAuthorizedKeys = nil
+			u.Shell = helpers.StrToPtr("/bin/zsh")
+			u.PasswordHash = helpers.StrToPtr("$6$salt$hash")
+		})}},
+	}, {
+		name: "drop user",
+		old:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}},
+		new:  ign3types.Passwd{Users: []ign3types.PasswdUser{core}},
+	}, {
+		name: "remove user",
+		old:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}},
+		new:  ign3types.Passwd{Users: []ign3types.PasswdUser{core, {Name: "alice", ShouldExist: helpers.BoolToPtr(false)}}},
+	}, {
+		name:    "remove core",
+		old:     ign3types.Passwd{Users: []ign3types.PasswdUser{core}},
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{alice}},
+		wantErr: "user core may not be deleted",
+	}, {
+		name:    "change core home",
+		old:     ign3types.Passwd{Users: []ign3types.PasswdUser{core}},
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{withUser(core, func(u *ign3types.PasswdUser) { u.HomeDir = helpers.StrToPtr("/home/other") })}},
+		wantErr: "SSH keys and password hash are not reconcilable",
+	}, {
+		name:    "system uid",
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) { u.UID = helpers.IntToPtr(0) })}},
+		wantErr: "uid 0 is reserved for system users",
+	}, {
+		name:    "system user",
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) { u.System = helpers.BoolToPtr(true) })}},
+		wantErr: "system users are not supported",
+	}, {
+		name:    "change uid",
+		old:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}},
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) { u.UID = helpers.IntToPtr(1501) })}},
+		wantErr: "uid may not be changed",
+	}, {
+		name:    "change home",
+		old:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, alice}},
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, withUser(alice, func(u *ign3types.PasswdUser) { u.HomeDir = helpers.StrToPtr("/var/alice") })}},
+		wantErr: "homeDir may not be changed",
+	}, {
+		name:    "invalid user name",
+		new:     ign3types.Passwd{Users: []ign3types.PasswdUser{core, {Name: "Alice Smith"}}},
+		wantErr: `invalid user name "Alice Smith"`,
+	}, {
+		name:    "system gid",
+		new:     ign3types.Passwd{Groups: []ign3types.PasswdGroup{withGroup(admins, func(g *ign3types.PasswdGroup) { g.Gid = helpers.IntToPtr(10) })}},
+		wantErr: "gid 10 is reserved for system groups",
+	}, {
+		name:    "change gid",
+		old:     ign3types.Passwd{Groups: []ign3types.PasswdGroup{admins}},
+		new:     ign3types.Passwd{Groups: []ign3types.PasswdGroup{withGroup(admins, func(g *ign3types.PasswdGroup) { g.Gid = helpers.IntToPtr(2001) })}},
+		wantErr: "gid may not be changed",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyPasswd(test.old, test.new)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserCmdArgs(t *testing.T) {
+	u := ign3types.PasswdUser{
+		Name:         "alice",
+		UID:          helpers.IntToPtr(1500),
+		Groups:       []ign3types.Group{"wheel", "admins"},
+		PrimaryGroup: helpers.StrToPtr("admins"),
+		Shell:        helpers.StrToPtr("/bin/zsh"),
+	}
+	assert.Equal(t, []string{"--create-home", "--password", "*", "--uid", "1500", "--groups", "wheel,admins", "--gid", "admins", "--shell", "/bin/zsh", "alice"}, userAddArgs(u))
+	assert.Equal(t, []string{"--password", "*", "--expiredate", "", "--groups", "wheel,admins", "--gid", "admins", "--shell", "/bin/zsh", "alice"}, userModArgs(u))
+
+	u = ign3types.PasswdUser{Name: "bob", PasswordHash: helpers.StrToPtr("$6$salt$hash")}
+	assert.Equal(t, []string{"--create-home", "--password", "$6$salt$hash", "bob"}, userAddArgs(u))
+	assert.Equal(t, []string{"--password", "$6$salt$hash", "--expiredate", "", "--groups", "", "bob"}, userModArgs(u))
+}
+
+func TestLookupLocalUserRefusesSystemUsers(t *testing.T) {
+	_, err := lookupLocalUser("root")
+	assert.ErrorContains(t, err, "refusing to manage system user root")
+
+	existing, err := lookupLocalUser("no-such-user")
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+}
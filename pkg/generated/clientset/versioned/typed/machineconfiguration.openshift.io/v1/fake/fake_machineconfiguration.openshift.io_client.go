@@ -32,6 +32,10 @@ func (c *FakeMachineconfigurationV1) MachineConfigPools() v1.MachineConfigPoolIn
 	return &FakeMachineConfigPools{c}
 }
 
+func (c *FakeMachineconfigurationV1) NodeConfigOverlays(namespace string) v1.NodeConfigOverlayInterface {
+	return &FakeNodeConfigOverlays{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeMachineconfigurationV1) RESTClient() rest.Interface {
@@ -0,0 +1,113 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeConfigOverlays implements NodeConfigOverlayInterface
+type FakeNodeConfigOverlays struct {
+	Fake *FakeMachineconfigurationV1
+	ns   string
+}
+
+var nodeconfigoverlaysResource = v1.SchemeGroupVersion.WithResource("nodeconfigoverlays")
+
+var nodeconfigoverlaysKind = v1.SchemeGroupVersion.WithKind("NodeConfigOverlay")
+
+// Get takes name of the nodeConfigOverlay, and returns the corresponding nodeConfigOverlay object, and an error if there is any.
+func (c *FakeNodeConfigOverlays) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeConfigOverlay, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(nodeconfigoverlaysResource, c.ns, name), &v1.NodeConfigOverlay{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeConfigOverlay), err
+}
+
+// List takes label and field selectors, and returns the list of NodeConfigOverlays that match those selectors.
+func (c *FakeNodeConfigOverlays) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeConfigOverlayList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(nodeconfigoverlaysResource, nodeconfigoverlaysKind, c.ns, opts), &v1.NodeConfigOverlayList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1.NodeConfigOverlayList{ListMeta: obj.(*v1.NodeConfigOverlayList).ListMeta}
+	for _, item := range obj.(*v1.NodeConfigOverlayList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeConfigOverlays.
+func (c *FakeNodeConfigOverlays) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(nodeconfigoverlaysResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a nodeConfigOverlay and creates it.  Returns the server's representation of the nodeConfigOverlay, and an error, if there is any.
+func (c *FakeNodeConfigOverlays) Create(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.CreateOptions) (result *v1.NodeConfigOverlay, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(nodeconfigoverlaysResource, c.ns, nodeConfigOverlay), &v1.NodeConfigOverlay{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeConfigOverlay), err
+}
+
+// Update takes the representation of a nodeConfigOverlay and updates it. Returns the server's representation of the nodeConfigOverlay, and an error, if there is any.
+func (c *FakeNodeConfigOverlays) Update(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.UpdateOptions) (result *v1.NodeConfigOverlay, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(nodeconfigoverlaysResource, c.ns, nodeConfigOverlay), &v1.NodeConfigOverlay{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeConfigOverlay), err
+}
+
+// Delete takes name of the nodeConfigOverlay and deletes it. Returns an error if one occurs.
+func (c *FakeNodeConfigOverlays) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(nodeconfigoverlaysResource, c.ns, name, opts), &v1.NodeConfigOverlay{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeConfigOverlays) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(nodeconfigoverlaysResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1.NodeConfigOverlayList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeConfigOverlay.
+func (c *FakeNodeConfigOverlays) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeConfigOverlay, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(nodeconfigoverlaysResource, c.ns, name, pt, data, subresources...), &v1.NodeConfigOverlay{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeConfigOverlay), err
+}
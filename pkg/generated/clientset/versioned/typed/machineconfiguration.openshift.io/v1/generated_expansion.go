@@ -11,3 +11,5 @@ type KubeletConfigExpansion interface{}
 type MachineConfigExpansion interface{}
 
 type MachineConfigPoolExpansion interface{}
+
+type NodeConfigOverlayExpansion interface{}
@@ -0,0 +1,162 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	scheme "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeConfigOverlaysGetter has a method to return a NodeConfigOverlayInterface.
+// A group's client should implement this interface.
+type NodeConfigOverlaysGetter interface {
+	NodeConfigOverlays(namespace string) NodeConfigOverlayInterface
+}
+
+// NodeConfigOverlayInterface has methods to work with NodeConfigOverlay resources.
+type NodeConfigOverlayInterface interface {
+	Create(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.CreateOptions) (*v1.NodeConfigOverlay, error)
+	Update(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.UpdateOptions) (*v1.NodeConfigOverlay, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.NodeConfigOverlay, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.NodeConfigOverlayList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeConfigOverlay, err error)
+	NodeConfigOverlayExpansion
+}
+
+// nodeConfigOverlays implements NodeConfigOverlayInterface
+type nodeConfigOverlays struct {
+	client rest.Interface
+	ns     string
+}
+
+// newNodeConfigOverlays returns a NodeConfigOverlays
+func newNodeConfigOverlays(c *MachineconfigurationV1Client, namespace string) *nodeConfigOverlays {
+	return &nodeConfigOverlays{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the nodeConfigOverlay, and returns the corresponding nodeConfigOverlay object, and an error if there is any.
+func (c *nodeConfigOverlays) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeConfigOverlay, err error) {
+	result = &v1.NodeConfigOverlay{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeConfigOverlays that match those selectors.
+func (c *nodeConfigOverlays) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeConfigOverlayList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.NodeConfigOverlayList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeConfigOverlays.
+func (c *nodeConfigOverlays) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeConfigOverlay and creates it.  Returns the server's representation of the nodeConfigOverlay, and an error, if there is any.
+func (c *nodeConfigOverlays) Create(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.CreateOptions) (result *v1.NodeConfigOverlay, err error) {
+	result = &v1.NodeConfigOverlay{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeConfigOverlay).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeConfigOverlay and updates it. Returns the server's representation of the nodeConfigOverlay, and an error, if there is any.
+func (c *nodeConfigOverlays) Update(ctx context.Context, nodeConfigOverlay *v1.NodeConfigOverlay, opts metav1.UpdateOptions) (result *v1.NodeConfigOverlay, err error) {
+	result = &v1.NodeConfigOverlay{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		Name(nodeConfigOverlay.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeConfigOverlay).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeConfigOverlay and deletes it. Returns an error if one occurs.
+func (c *nodeConfigOverlays) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeConfigOverlays) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeConfigOverlay.
+func (c *nodeConfigOverlays) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeConfigOverlay, err error) {
+	result = &v1.NodeConfigOverlay{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("nodeconfigoverlays").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -47,6 +47,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodeconfigoverlays"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().NodeConfigOverlays().Informer()}, nil
 
 	}
 
@@ -18,6 +18,8 @@ type Interface interface {
 	MachineConfigs() MachineConfigInformer
 	// MachineConfigPools returns a MachineConfigPoolInformer.
 	MachineConfigPools() MachineConfigPoolInformer
+	// NodeConfigOverlays returns a NodeConfigOverlayInformer.
+	NodeConfigOverlays() NodeConfigOverlayInformer
 }
 
 type version struct {
@@ -55,3 +57,8 @@ func (v *version) MachineConfigs() MachineConfigInformer {
 func (v *version) MachineConfigPools() MachineConfigPoolInformer {
 	return &machineConfigPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// NodeConfigOverlays returns a NodeConfigOverlayInformer.
+func (v *version) NodeConfigOverlays() NodeConfigOverlayInformer {
+	return &nodeConfigOverlayInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
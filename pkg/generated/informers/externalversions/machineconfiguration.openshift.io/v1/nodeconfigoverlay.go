@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	machineconfigurationopenshiftiov1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	versioned "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// NodeConfigOverlayInformer provides access to a shared informer and lister for
+// NodeConfigOverlays.
+type NodeConfigOverlayInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.NodeConfigOverlayLister
+}
+
+type nodeConfigOverlayInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewNodeConfigOverlayInformer constructs a new informer for NodeConfigOverlay type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewNodeConfigOverlayInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredNodeConfigOverlayInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredNodeConfigOverlayInformer constructs a new informer for NodeConfigOverlay type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredNodeConfigOverlayInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().NodeConfigOverlays(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.MachineconfigurationV1().NodeConfigOverlays(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&machineconfigurationopenshiftiov1.NodeConfigOverlay{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *nodeConfigOverlayInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredNodeConfigOverlayInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *nodeConfigOverlayInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&machineconfigurationopenshiftiov1.NodeConfigOverlay{}, f.defaultInformer)
+}
+
+func (f *nodeConfigOverlayInformer) Lister() v1.NodeConfigOverlayLister {
+	return v1.NewNodeConfigOverlayLister(f.Informer().GetIndexer())
+}
@@ -21,3 +21,11 @@ type MachineConfigListerExpansion interface{}
 // MachineConfigPoolListerExpansion allows custom methods to be added to
 // MachineConfigPoolLister.
 type MachineConfigPoolListerExpansion interface{}
+
+// NodeConfigOverlayListerExpansion allows custom methods to be added to
+// NodeConfigOverlayLister.
+type NodeConfigOverlayListerExpansion interface{}
+
+// NodeConfigOverlayNamespaceListerExpansion allows custom methods to be added to
+// NodeConfigOverlayNamespaceLister.
+type NodeConfigOverlayNamespaceListerExpansion interface{}
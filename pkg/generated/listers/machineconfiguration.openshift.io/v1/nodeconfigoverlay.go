@@ -0,0 +1,83 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeConfigOverlayLister helps list NodeConfigOverlays.
+// All objects returned here must be treated as read-only.
+type NodeConfigOverlayLister interface {
+	// List lists all NodeConfigOverlays in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.NodeConfigOverlay, err error)
+	// NodeConfigOverlays returns an object that can list and get NodeConfigOverlays.
+	NodeConfigOverlays(namespace string) NodeConfigOverlayNamespaceLister
+	NodeConfigOverlayListerExpansion
+}
+
+// nodeConfigOverlayLister implements the NodeConfigOverlayLister interface.
+type nodeConfigOverlayLister struct {
+	indexer cache.Indexer
+}
+
+// NewNodeConfigOverlayLister returns a new NodeConfigOverlayLister.
+func NewNodeConfigOverlayLister(indexer cache.Indexer) NodeConfigOverlayLister {
+	return &nodeConfigOverlayLister{indexer: indexer}
+}
+
+// List lists all NodeConfigOverlays in the indexer.
+func (s *nodeConfigOverlayLister) List(selector labels.Selector) (ret []*v1.NodeConfigOverlay, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NodeConfigOverlay))
+	})
+	return ret, err
+}
+
+// NodeConfigOverlays returns an object that can list and get NodeConfigOverlays.
+func (s *nodeConfigOverlayLister) NodeConfigOverlays(namespace string) NodeConfigOverlayNamespaceLister {
+	return nodeConfigOverlayNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// NodeConfigOverlayNamespaceLister helps list and get NodeConfigOverlays.
+// All objects returned here must be treated as read-only.
+type NodeConfigOverlayNamespaceLister interface {
+	// List lists all NodeConfigOverlays in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.NodeConfigOverlay, err error)
+	// Get retrieves the NodeConfigOverlay from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.NodeConfigOverlay, error)
+	NodeConfigOverlayNamespaceListerExpansion
+}
+
+// nodeConfigOverlayNamespaceLister implements the NodeConfigOverlayNamespaceLister
+// interface.
+type nodeConfigOverlayNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all NodeConfigOverlays in the indexer for a given namespace.
+func (s nodeConfigOverlayNamespaceLister) List(selector labels.Selector) (ret []*v1.NodeConfigOverlay, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NodeConfigOverlay))
+	})
+	return ret, err
+}
+
+// Get retrieves the NodeConfigOverlay from the indexer for a given namespace and name.
+func (s nodeConfigOverlayNamespaceLister) Get(name string) (*v1.NodeConfigOverlay, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("nodeconfigoverlay"), name)
+	}
+	return obj.(*v1.NodeConfigOverlay), nil
+}
@@ -51,6 +51,18 @@ const (
 
 	// osImageConfigMapName is the name of our configmap for the osImageURL
 	osImageConfigMapName = "machine-config-osimageurl"
+
+	// mccEtcdClientSecretName is the name of the secret with the etcd client certificate and CA
+	// bundle the machine-config-controller looks up the etcd leader with
+	mccEtcdClientSecretName = "machine-config-controller-etcd-client"
+	// mccEtcdLeaderEndpoint is the etcd endpoint the machine-config-controller looks up the etcd leader at
+	mccEtcdLeaderEndpoint = "https://etcd.openshift-etcd.svc:2379"
+
+	// mcsConfigMapName is the name of the optional configmap with the options of the machine-config-server
+	mcsConfigMapName = "machine-config-server-config"
+	// requireIgnitionTokenKey is the key of the mcsConfigMapName option to only serve configs
+	// to requests with an Ignition token
+	requireIgnitionTokenKey = "requireIgnitionToken"
 )
 
 // Operator defines machince config operator.
@@ -86,6 +98,7 @@ type Operator struct {
 	oseKubeAPILister corelisterv1.ConfigMapLister
 	nodeLister       corelisterv1.NodeLister
 	dnsLister        configlistersv1.DNSLister
+	maoSecretLister  corelisterv1.SecretLister
 
 	crdListerSynced                  cache.InformerSynced
 	deployListerSynced               cache.InformerSynced
@@ -201,6 +214,7 @@ func New(
 	optr.nodeLister = nodeInformer.Lister()
 	optr.nodeListerSynced = nodeInformer.Informer().HasSynced
 
+	optr.maoSecretLister = maoSecretInformer.Lister()
 	optr.maoSecretInformerSynced = maoSecretInformer.Informer().HasSynced
 	optr.serviceAccountInformerSynced = serviceAccountInfomer.Informer().HasSynced
 	optr.clusterRoleInformerSynced = clusterRoleInformer.Informer().HasSynced
@@ -34,6 +34,8 @@ type renderConfig struct {
 	Infra                  configv1.Infrastructure
 	Constants              map[string]string
 	PointerConfig          string
+	RequireIgnitionToken   bool
+	EtcdLeaderEndpoint     string
 }
 
 type assetRenderer struct {
@@ -267,6 +267,38 @@ func TestRenderAsset(t *testing.T) {
 			"- name: NO_PROXY\n            value: \"*\"", // Ensure the * is quoted: "*": https://bugzilla.redhat.com/show_bug.cgi?id=1947066
 			"--payload-version=4.8.0-rc.0",
 		},
+	}, {
+		// Test that the machine-config-controller looks up the etcd leader if it has an etcd client certificate
+		Path: "manifests/machineconfigcontroller/deployment.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			ReleaseVersion:  "4.8.0-rc.0",
+			Images: &RenderConfigImages{
+				MachineConfigOperator: "mco-operator-image",
+				OauthProxy:            "oauth-proxy-image",
+			},
+			EtcdLeaderEndpoint: "https://etcd.openshift-etcd.svc:2379",
+		},
+		FindExpected: []string{
+			"--etcd-leader-endpoint=https://etcd.openshift-etcd.svc:2379",
+			"--etcd-leader-certfile=/etc/mcc/etcd/tls.crt",
+			"secretName: machine-config-controller-etcd-client",
+		},
+	}, {
+		// Test that the machine-config-server requires Ignition tokens if configured to
+		Path: "manifests/machineconfigserver/daemonset.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			ReleaseVersion:  "4.8.0-rc.0",
+			Images: &RenderConfigImages{
+				MachineConfigOperator: "mco-operator-image",
+				OauthProxy:            "oauth-proxy-image",
+			},
+			RequireIgnitionToken: true,
+		},
+		FindExpected: []string{
+			"- \"--json-access-log\"\n          - \"--require-ignition-token\"\n",
+		},
 	}, {
 		// Bad path, will cause asset error
 		Path:  "BAD PATH",
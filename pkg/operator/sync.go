@@ -78,12 +78,16 @@ const (
 	mcdEventsClusterRoleManifestPath        = "manifests/machineconfigdaemon/events-clusterrole.yaml"
 	mcdEventsRoleBindingDefaultManifestPath = "manifests/machineconfigdaemon/events-rolebinding-default.yaml"
 	mcdEventsRoleBindingTargetManifestPath  = "manifests/machineconfigdaemon/events-rolebinding-target.yaml"
+	mcdUpdateHistoryClusterRoleManifestPath = "manifests/machineconfigdaemon/update-history-clusterrole.yaml"
+	mcdUpdateHistoryRoleBindingManifestPath = "manifests/machineconfigdaemon/update-history-rolebinding.yaml"
 	mcdClusterRoleBindingManifestPath       = "manifests/machineconfigdaemon/clusterrolebinding.yaml"
 	mcdServiceAccountManifestPath           = "manifests/machineconfigdaemon/sa.yaml"
 	mcdDaemonsetManifestPath                = "manifests/machineconfigdaemon/daemonset.yaml"
 
 	// Machine Config Server manifest paths
 	mcsClusterRoleManifestPath                    = "manifests/machineconfigserver/clusterrole.yaml"
+	mcsIgnitionTokenClusterRoleManifestPath       = "manifests/machineconfigserver/ignition-token-clusterrole.yaml"
+	mcsIgnitionTokenRoleBindingManifestPath       = "manifests/machineconfigserver/ignition-token-rolebinding.yaml"
 	mcsClusterRoleBindingManifestPath             = "manifests/machineconfigserver/clusterrolebinding.yaml"
 	mcsCSRBootstrapRoleBindingManifestPath        = "manifests/machineconfigserver/csr-bootstrap-role-binding.yaml"
 	mcsCSRRenewalRoleBindingManifestPath          = "manifests/machineconfigserver/csr-renewal-role-binding.yaml"
@@ -630,6 +634,16 @@ func (optr *Operator) syncControllerConfig(config *renderConfig) error {
 }
 
 func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
+	hasEtcdClient, err := optr.syncMCCEtcdClientSecret()
+	if err != nil {
+		return fmt.Errorf("failed to sync machine config controller etcd client secret: %w", err)
+	}
+	// Without an etcd client certificate, the MCC can't defer the etcd leader.
+	config.EtcdLeaderEndpoint = ""
+	if hasEtcdClient {
+		config.EtcdLeaderEndpoint = mccEtcdLeaderEndpoint
+	}
+
 	paths := manifestPaths{
 		clusterRoles: []string{
 			mccClusterRoleManifestPath,
@@ -668,15 +682,51 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 	return optr.syncControllerConfig(config)
 }
 
+// syncMCCEtcdClientSecret copies the etcd client certificate and CA bundle of the cluster into the
+// secret the MCC looks up the etcd leader with. It returns false if the cluster has none, e.g. with
+// an external control plane.
+func (optr *Operator) syncMCCEtcdClientSecret() (bool, error) {
+	caBundle, err := optr.clusterCmLister.ConfigMaps("openshift-config").Get("etcd-ca-bundle")
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	client, err := optr.kubeClient.CoreV1().Secrets("openshift-config").Get(context.TODO(), "etcd-client", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mccEtcdClientSecretName,
+			Namespace: optr.namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       client.Data[corev1.TLSCertKey],
+			corev1.TLSPrivateKeyKey: client.Data[corev1.TLSPrivateKeyKey],
+			"ca.crt":                []byte(caBundle.Data["ca-bundle.crt"]),
+		},
+	}
+	_, _, err = resourceapply.ApplySecret(context.TODO(), optr.kubeClient.CoreV1(), optr.libgoRecorder, secret)
+	return err == nil, err
+}
+
 func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 	paths := manifestPaths{
 		clusterRoles: []string{
 			mcdClusterRoleManifestPath,
 			mcdEventsClusterRoleManifestPath,
+			mcdUpdateHistoryClusterRoleManifestPath,
 		},
 		roleBindings: []string{
 			mcdEventsRoleBindingDefaultManifestPath,
 			mcdEventsRoleBindingTargetManifestPath,
+			mcdUpdateHistoryRoleBindingManifestPath,
 		},
 		clusterRoleBindings: []string{
 			mcdClusterRoleBindingManifestPath,
@@ -703,6 +753,12 @@ func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 }
 
 func (optr *Operator) syncMachineConfigServer(config *renderConfig) error {
+	requireIgnitionToken, err := optr.getRequireIgnitionToken()
+	if err != nil {
+		return err
+	}
+	config.RequireIgnitionToken = requireIgnitionToken
+
 	paths := manifestPaths{
 		clusterRoles: []string{
 			mcsClusterRoleManifestPath,
@@ -721,13 +777,76 @@ func (optr *Operator) syncMachineConfigServer(config *renderConfig) error {
 		},
 		daemonset: mcsDaemonsetManifestPath,
 	}
+	// The MCS can only read the Ignition token Secrets when it needs to.
+	if requireIgnitionToken {
+		paths.clusterRoles = append(paths.clusterRoles, mcsIgnitionTokenClusterRoleManifestPath)
+		paths.roleBindings = append(paths.roleBindings, mcsIgnitionTokenRoleBindingManifestPath)
+	}
 	if err := optr.applyManifests(config, paths); err != nil {
 		return fmt.Errorf("failed to apply machine config server manifests: %w", err)
 	}
+	if !requireIgnitionToken {
+		if err := optr.deleteIgnitionTokenRBAC(config); err != nil {
+			return fmt.Errorf("failed to delete machine config server Ignition token RBAC: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// getRequireIgnitionToken returns whether the machine-config-server config requires Ignition
+// tokens to serve configs. It doesn't without the config, nor while the user-data of a pool,
+// which MachineSets create Machines from, fetches the config without a token.
+func (optr *Operator) getRequireIgnitionToken() (bool, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(ctrlcommon.MCONamespace).Get(mcsConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	value, ok := cm.Data[requireIgnitionTokenKey]
+	if !ok {
+		return false, nil
+	}
+	requireIgnitionToken, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s in configmap %s: %w", requireIgnitionTokenKey, mcsConfigMapName, err)
+	}
+	if !requireIgnitionToken {
+		return false, nil
+	}
+	userData, err := optr.maoSecretLister.Secrets(server.UserDataSecretNamespace).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	if pools := server.GetTokenlessUserDataPools(userData); len(pools) > 0 {
+		glog.Warningf("Not requiring Ignition tokens, the user-data of pools %v has none", pools)
+		optr.libgoRecorder.Warningf("IgnitionTokenNotRequired", "Not requiring Ignition tokens, Machines created from the user-data of pools %s would fail to fetch their config without one", strings.Join(pools, ", "))
+		return false, nil
+	}
+	return true, nil
+}
+
+// deleteIgnitionTokenRBAC deletes the access of the MCS to the Ignition token Secrets.
+func (optr *Operator) deleteIgnitionTokenRBAC(config *renderConfig) error {
+	rbBytes, err := renderAsset(config, mcsIgnitionTokenRoleBindingManifestPath)
+	if err != nil {
+		return err
+	}
+	rb := resourceread.ReadRoleBindingV1OrDie(rbBytes)
+	if _, _, err := resourceapply.DeleteRoleBinding(context.TODO(), optr.kubeClient.RbacV1(), optr.libgoRecorder, rb); err != nil {
+		return err
+	}
+	crBytes, err := renderAsset(config, mcsIgnitionTokenClusterRoleManifestPath)
+	if err != nil {
+		return err
+	}
+	cr := resourceread.ReadClusterRoleV1OrDie(crBytes)
+	_, _, err = resourceapply.DeleteClusterRole(context.TODO(), optr.kubeClient.RbacV1(), optr.libgoRecorder, cr)
+	return err
+}
+
 // syncRequiredMachineConfigPools ensures that all the nodes in machineconfigpools labeled with requiredForUpgradeMachineConfigPoolLabelKey
 // have updated to the latest configuration.
 func (optr *Operator) syncRequiredMachineConfigPools(_ *renderConfig) error {
@@ -1,16 +1,20 @@
 package operator
 
 import (
+	"context"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
 
 func TestSyncCloudConfig(t *testing.T) {
@@ -93,6 +97,152 @@ func TestSyncCloudConfig(t *testing.T) {
 	}
 }
 
+func TestGetRequireIgnitionToken(t *testing.T) {
+	userData := func(pool, pointerConfig string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: pool + "-user-data-managed", Namespace: "openshift-machine-api"},
+			Data:       map[string][]byte{"userData": []byte(pointerConfig)},
+		}
+	}
+	withToken := `{"ignition":{"config":{"merge":[{"source":"https://api-int:22623/config/worker","httpHeaders":[{"name":"Authorization","value":"Bearer token"}]}]},"version":"3.2.0"}}`
+	withoutToken := `{"ignition":{"config":{"merge":[{"source":"https://api-int:22623/config/worker"}]},"version":"3.2.0"}}`
+
+	cases := []struct {
+		name        string
+		data        map[string]string
+		userData    []*corev1.Secret
+		expected    bool
+		expectError bool
+	}{
+		{
+			name: "no configmap",
+		},
+		{
+			name: "no option",
+			data: map[string]string{},
+		},
+		{
+			name:     "required",
+			data:     map[string]string{requireIgnitionTokenKey: "true"},
+			expected: true,
+		},
+		{
+			name:     "required with user-data tokens",
+			data:     map[string]string{requireIgnitionTokenKey: "true"},
+			userData: []*corev1.Secret{userData("worker", withToken), userData("infra", withToken)},
+			expected: true,
+		},
+		{
+			name:     "required with tokenless user-data",
+			data:     map[string]string{requireIgnitionTokenKey: "true"},
+			userData: []*corev1.Secret{userData("worker", withToken), userData("infra", withoutToken)},
+		},
+		{
+			name: "not required",
+			data: map[string]string{requireIgnitionTokenKey: "false"},
+		},
+		{
+			name:        "invalid",
+			data:        map[string]string{requireIgnitionTokenKey: "yes please"},
+			expectError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			sharedInformer := informers.NewSharedInformerFactory(client, 0)
+			cmInformer := sharedInformer.Core().V1().ConfigMaps()
+			if tc.data != nil {
+				cmInformer.Informer().GetIndexer().Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: mcsConfigMapName, Namespace: ctrlcommon.MCONamespace},
+					Data:       tc.data,
+				})
+			}
+			secretInformer := sharedInformer.Core().V1().Secrets()
+			for _, secret := range tc.userData {
+				secretInformer.Informer().GetIndexer().Add(secret)
+			}
+			optr := &Operator{
+				mcoCmLister:     cmInformer.Lister(),
+				maoSecretLister: secretInformer.Lister(),
+				libgoRecorder:   events.NewInMemoryRecorder("test"),
+			}
+			requireIgnitionToken, err := optr.getRequireIgnitionToken()
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, requireIgnitionToken)
+		})
+	}
+}
+
+func TestSyncMCCEtcdClientSecret(t *testing.T) {
+	etcdClient := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd-client", Namespace: "openshift-config"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	etcdCABundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd-ca-bundle", Namespace: "openshift-config"},
+		Data:       map[string]string{"ca-bundle.crt": "ca"},
+	}
+	cases := []struct {
+		name     string
+		secret   *corev1.Secret
+		caBundle *corev1.ConfigMap
+		expected bool
+	}{
+		{
+			name: "no etcd client",
+		},
+		{
+			name:     "no etcd client certificate",
+			caBundle: etcdCABundle,
+		},
+		{
+			name:     "etcd client",
+			secret:   etcdClient,
+			caBundle: etcdCABundle,
+			expected: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tc.secret != nil {
+				client = fake.NewSimpleClientset(tc.secret)
+			}
+			sharedInformer := informers.NewSharedInformerFactory(client, 0)
+			cmInformer := sharedInformer.Core().V1().ConfigMaps()
+			if tc.caBundle != nil {
+				cmInformer.Informer().GetIndexer().Add(tc.caBundle)
+			}
+			optr := &Operator{
+				namespace:       ctrlcommon.MCONamespace,
+				kubeClient:      client,
+				clusterCmLister: cmInformer.Lister(),
+				libgoRecorder:   events.NewInMemoryRecorder("test"),
+			}
+			hasEtcdClient, err := optr.syncMCCEtcdClientSecret()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, hasEtcdClient)
+
+			secret, err := client.CoreV1().Secrets(ctrlcommon.MCONamespace).Get(context.TODO(), mccEtcdClientSecretName, metav1.GetOptions{})
+			if !tc.expected {
+				assert.True(t, apierrors.IsNotFound(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, map[string][]byte{
+				corev1.TLSCertKey:       []byte("cert"),
+				corev1.TLSPrivateKeyKey: []byte("key"),
+				"ca.crt":                []byte("ca"),
+			}, secret.Data)
+		})
+	}
+}
+
 type infraOption func(*configv1.Infrastructure)
 
 func buildInfra(opts ...infraOption) *configv1.Infrastructure {
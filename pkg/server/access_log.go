@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// unknownLabel is the metric label value of pools and spec versions that aren't
+	// known, to bound the cardinality of metrics of requests for arbitrary pools.
+	unknownLabel = "unknown"
+
+	// ipRateLimiterIdleTimeout is how long the rate limiter of a source IP is kept
+	// after its last request.
+	ipRateLimiterIdleTimeout = 10 * time.Minute
+)
+
+// requestInfo is what handling a config request learned about it, for metrics and
+// access logs.
+type requestInfo struct {
+	pool string
+	// poolFound is set once a config was found for pool.
+	poolFound   bool
+	specVersion string
+	host        string
+	tokenID     string
+	cache       string
+}
+
+// statusRecorder records the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// accessLogEntry is the JSON access log of a config request.
+type accessLogEntry struct {
+	Time            string  `json:"time"`
+	RemoteAddr      string  `json:"remoteAddr"`
+	Method          string  `json:"method"`
+	Path            string  `json:"path"`
+	Pool            string  `json:"pool,omitempty"`
+	SpecVersion     string  `json:"specVersion,omitempty"`
+	Host            string  `json:"host,omitempty"`
+	TokenID         string  `json:"tokenID,omitempty"`
+	Cache           string  `json:"cache,omitempty"`
+	Status          int     `json:"status"`
+	Bytes           int     `json:"bytes"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	UserAgent       string  `json:"userAgent,omitempty"`
+}
+
+// accessLogWriter writes access log entries as JSON lines.
+type accessLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *accessLogWriter) write(entry *accessLogEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		glog.Errorf("failed to marshal access log entry: %v", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(b, '\n')); err != nil {
+		glog.Errorf("failed to write access log entry: %v", err)
+	}
+}
+
+// SetAccessLog makes the handler write a JSON access log line per config request to w.
+func (sh *APIHandler) SetAccessLog(w io.Writer) {
+	sh.accessLog = &accessLogWriter{w: w}
+}
+
+// SetRateLimit limits the config requests of each source IP to limit per second, with
+// bursts of up to burst requests. Requests over the limit get HTTP status 429.
+func (sh *APIHandler) SetRateLimit(limit float64, burst int) {
+	sh.rateLimiter = newIPRateLimiter(rate.Limit(limit), burst)
+}
+
+// recordRequest records the metrics and access log of a config request.
+func (sh *APIHandler) recordRequest(r *http.Request, rec *statusRecorder, info *requestInfo, duration time.Duration) {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	pool, specVersion := unknownLabel, unknownLabel
+	if info.poolFound {
+		pool = info.pool
+	}
+	if info.specVersion != "" {
+		specVersion = info.specVersion
+	}
+	code := strconv.Itoa(status)
+	mcsRequests.WithLabelValues(pool, specVersion, code).Inc()
+	mcsRequestDuration.WithLabelValues(pool, specVersion, code).Observe(duration.Seconds())
+
+	if sh.accessLog == nil {
+		return
+	}
+	sh.accessLog.write(&accessLogEntry{
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		RemoteAddr:      r.RemoteAddr,
+		Method:          r.Method,
+		Path:            r.URL.Path,
+		Pool:            info.pool,
+		SpecVersion:     info.specVersion,
+		Host:            info.host,
+		TokenID:         info.tokenID,
+		Cache:           info.cache,
+		Status:          status,
+		Bytes:           rec.bytes,
+		DurationSeconds: duration.Seconds(),
+		UserAgent:       r.UserAgent(),
+	})
+}
+
+// remoteIP returns the IP address r was sent from. Forwarding headers aren't trusted,
+// anyone can set them.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipRateLimiter rate limits requests per source IP.
+type ipRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu          sync.Mutex
+	limiters    map[string]*ipLimiter
+	lastCleanup time.Time
+}
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newIPRateLimiter(limit rate.Limit, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[string]*ipLimiter),
+	}
+}
+
+// allow returns whether a request from ip at now is within the limit.
+func (l *ipRateLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget idle IPs now and then, so that the limiters don't pile up.
+	if now.Sub(l.lastCleanup) > time.Minute {
+		for ip, lim := range l.limiters {
+			if now.Sub(lim.lastSeen) > ipRateLimiterIdleTimeout {
+				delete(l.limiters, ip)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	lim, ok := l.limiters[ip]
+	if !ok {
+		lim = &ipLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[ip] = lim
+	}
+	lim.lastSeen = now
+	return lim.limiter.AllowN(now, 1)
+}
+
+// retryAfter returns the Retry-After header value of rate limited requests, how many
+// seconds it takes to be allowed another request.
+func (l *ipRateLimiter) retryAfter() string {
+	if l.limit <= 0 {
+		return "60"
+	}
+	return strconv.Itoa(int(math.Ceil(1 / float64(l.limit))))
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newIPRateLimiter(rate.Limit(0.5), 2)
+	assert.Equal(t, "2", limiter.retryAfter())
+
+	assert.True(t, limiter.allow("10.0.0.1", now))
+	assert.True(t, limiter.allow("10.0.0.1", now))
+	assert.False(t, limiter.allow("10.0.0.1", now))
+	// Each source IP has its own limit.
+	assert.True(t, limiter.allow("10.0.0.2", now))
+	assert.True(t, limiter.allow("10.0.0.1", now.Add(2*time.Second)))
+
+	// Idle source IPs are forgotten.
+	limiter.allow("10.0.0.3", now.Add(ipRateLimiterIdleTimeout+time.Minute))
+	assert.Len(t, limiter.limiters, 1)
+}
+
+func TestRemoteIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker", nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	req.Header.Set("X-Forwarded-For", "10.0.0.2")
+	assert.Equal(t, "10.0.0.1", remoteIP(req))
+	req.RemoteAddr = "[fd00::1]:41234"
+	assert.Equal(t, "fd00::1", remoteIP(req))
+}
+
+func TestAPIHandlerRateLimit(t *testing.T) {
+	handler := NewServerAPIHandler(&mockServer{GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+		return &runtime.RawExtension{Raw: []byte("{}")}, nil
+	}})
+	handler.SetRateLimit(1, 1)
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	limited := testutil.ToFloat64(mcsRequestsRateLimited)
+	assert.Equal(t, http.StatusOK, get("10.0.0.1:1234").Code)
+	w := get("10.0.0.1:1235")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, limited+1, testutil.ToFloat64(mcsRequestsRateLimited))
+	assert.Equal(t, http.StatusOK, get("10.0.0.2:1234").Code)
+}
+
+func TestAPIHandlerAccessLog(t *testing.T) {
+	handler := NewServerAPIHandler(&mockServer{GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+		if pr.machineConfigPool != "worker" {
+			return nil, nil
+		}
+		return &runtime.RawExtension{Raw: []byte("{}")}, nil
+	}})
+	var accessLog bytes.Buffer
+	handler.SetAccessLog(&accessLog)
+
+	get := func(pool string) {
+		req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/"+pool+"?node=worker-0", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+		req.Header.Set("User-Agent", "Ignition/2.14.0")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	served := testutil.ToFloat64(mcsRequests.WithLabelValues("worker", "3.2.0", "200"))
+	notFound := testutil.ToFloat64(mcsRequests.WithLabelValues(unknownLabel, "3.2.0", "404"))
+	get("worker")
+	get("does-not-exist")
+	assert.Equal(t, served+1, testutil.ToFloat64(mcsRequests.WithLabelValues("worker", "3.2.0", "200")))
+	// Pools that don't exist aren't metric labels.
+	assert.Equal(t, notFound+1, testutil.ToFloat64(mcsRequests.WithLabelValues(unknownLabel, "3.2.0", "404")))
+
+	decoder := json.NewDecoder(&accessLog)
+	var entry accessLogEntry
+	require.NoError(t, decoder.Decode(&entry))
+	assert.NotEmpty(t, entry.Time)
+	entry.Time, entry.DurationSeconds = "", 0
+	assert.Equal(t, accessLogEntry{
+		RemoteAddr:  "10.0.0.1:1234",
+		Method:      http.MethodGet,
+		Path:        "/config/worker",
+		Pool:        "worker",
+		SpecVersion: "3.2.0",
+		Host:        "worker-0",
+		Cache:       configCacheUncached,
+		Status:      http.StatusOK,
+		Bytes:       2,
+		UserAgent:   "Ignition/2.14.0",
+	}, entry)
+
+	require.NoError(t, decoder.Decode(&entry))
+	assert.Equal(t, "does-not-exist", entry.Pool)
+	assert.Equal(t, http.StatusNotFound, entry.Status)
+	assert.False(t, decoder.More())
+}
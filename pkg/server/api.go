@@ -2,17 +2,20 @@ package server
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/clarketm/json"
 	"github.com/coreos/go-semver/semver"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/cache"
 
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 )
@@ -27,6 +30,7 @@ const (
 type poolRequest struct {
 	machineConfigPool string
 	version           *semver.Version
+	host              hostIdentity
 }
 
 // APIServer provides the HTTP(s) endpoint
@@ -78,19 +82,55 @@ func (a *APIServer) Serve() {
 // Machine Config Server.
 type APIHandler struct {
 	server Server
+	// auth authenticates requests if set, otherwise configs are served to anyone.
+	auth Authenticator
+	// configCache caches served configs, for servers implementing configKeyer.
+	configCache *cache.LRUExpireCache
+	// rateLimiter limits the requests per source IP if set.
+	rateLimiter *ipRateLimiter
+	// accessLog is where JSON access logs are written to if set.
+	accessLog *accessLogWriter
 }
 
 // NewServerAPIHandler initializes a new API handler
 // for the Machine Config Server.
 func NewServerAPIHandler(s Server) *APIHandler {
 	return &APIHandler{
-		server: s,
+		server:      s,
+		configCache: cache.NewLRUExpireCache(configCacheSize),
+	}
+}
+
+// NewAuthenticatedServerAPIHandler initializes a new API handler for the
+// Machine Config Server that only serves configs to requests authenticated by a.
+func NewAuthenticatedServerAPIHandler(s Server, a Authenticator) *APIHandler {
+	return &APIHandler{
+		server:      s,
+		auth:        a,
+		configCache: cache.NewLRUExpireCache(configCacheSize),
 	}
 }
 
 // ServeHTTP handles the requests for the machine config server
 // API handler.
 func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+	info := &requestInfo{}
+	if sh.rateLimiter != nil && !sh.rateLimiter.allow(remoteIP(r), start) {
+		rec.Header().Set("Retry-After", sh.rateLimiter.retryAfter())
+		rec.Header().Set("Content-Length", "0")
+		rec.WriteHeader(http.StatusTooManyRequests)
+		mcsRequestsRateLimited.Inc()
+	} else {
+		sh.serveConfig(rec, r, info)
+	}
+	sh.recordRequest(r, rec, info, time.Since(start))
+}
+
+// serveConfig serves the config requested by r, recording what it learns about the
+// request in info.
+func (sh *APIHandler) serveConfig(w http.ResponseWriter, r *http.Request, info *requestInfo) {
 	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		w.Header().Set("Content-Length", "0")
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -104,9 +144,34 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	poolName := path.Base(r.URL.Path)
+	info.pool = poolName
 	useragent := r.Header.Get("User-Agent")
 	acceptHeader := r.Header.Get("Accept")
-	glog.Infof("Pool %s requested by address:%q User-Agent:%q Accept-Header: %q", poolName, r.RemoteAddr, useragent, acceptHeader)
+	host, err := getHostIdentity(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusBadRequest)
+		glog.Error(err)
+		return
+	}
+	info.host = host.String()
+	glog.Infof("Pool %s requested by address:%q User-Agent:%q Accept-Header: %q Host: %q", poolName, r.RemoteAddr, useragent, acceptHeader, host)
+
+	var tokenID string
+	if sh.auth != nil {
+		tokenID, err = sh.auth.Authenticate(r, poolName)
+		info.tokenID = tokenID
+		if err != nil {
+			w.Header().Set("Content-Length", "0")
+			if errors.Is(err, errMissingToken) {
+				w.WriteHeader(http.StatusUnauthorized)
+			} else {
+				w.WriteHeader(http.StatusForbidden)
+			}
+			glog.Warningf("Rejected request for pool %s from address:%q: %v", poolName, r.RemoteAddr, err)
+			return
+		}
+	}
 
 	reqConfigVer, err := detectSpecVersionFromAcceptHeader(acceptHeader)
 	if err != nil {
@@ -115,44 +180,128 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		glog.Error(err)
 		return
 	}
+	info.specVersion = reqConfigVer.String()
 
 	cr := poolRequest{
 		machineConfigPool: poolName,
 		version:           reqConfigVer,
+		host:              host,
 	}
 
-	conf, err := sh.server.GetConfig(cr)
+	start := time.Now()
+	config, cacheResult, err := sh.getServedConfig(cr)
+	info.cache = cacheResult
 	if err != nil {
 		w.Header().Set("Content-Length", "0")
 		w.WriteHeader(http.StatusInternalServerError)
 		glog.Errorf("couldn't get config for req: %v, error: %v", cr, err)
 		return
 	}
-	if conf == nil {
+	if config == nil {
 		w.Header().Set("Content-Length", "0")
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	info.poolFound = true
+
+	data, etag := config.data, config.etag
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		gzipData, gzipETag, err := config.gzipped()
+		if err != nil {
+			glog.Warningf("failed to compress %v config, serving it uncompressed: %v", cr, err)
+		} else {
+			data, etag = gzipData, gzipETag
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Accept, Accept-Encoding")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		mcsConfigServeDuration.WithLabelValues(cacheResult).Observe(time.Since(start).Seconds())
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		mcsConfigServeDuration.WithLabelValues(cacheResult).Observe(time.Since(start).Seconds())
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		glog.Errorf("failed to write %v response: %v", cr, err)
+		return
+	}
+	// Tokens are single-use. They are only used up once the config was written, so that
+	// Ignition can retry with the same token when the transfer fails; HEAD requests don't use them up.
+	if sh.auth != nil {
+		if err := sh.auth.Consume(tokenID); err != nil {
+			glog.Warningf("Failed to consume token %s after serving pool %s to address:%q: %v", tokenID, poolName, r.RemoteAddr, err)
+		}
+	}
+	mcsConfigServeDuration.WithLabelValues(cacheResult).Observe(time.Since(start).Seconds())
+	glog.Infof("Served config of pool %s to address:%q token:%q", poolName, r.RemoteAddr, tokenID)
+}
+
+// getServedConfig returns the config to serve for cr, or nil if there is none, and
+// the result of looking it up in the config cache.
+func (sh *APIHandler) getServedConfig(cr poolRequest) (*servedConfig, string, error) {
+	keyer, cacheable := sh.server.(configKeyer)
+	var key string
+	if cacheable {
+		var err error
+		if key, err = keyer.getConfigKey(cr); err != nil {
+			return nil, configCacheUncached, err
+		}
+	}
+	cacheResult := configCacheUncached
+	if key != "" {
+		if cached, ok := sh.configCache.Get(key); ok {
+			mcsConfigCacheRequests.WithLabelValues(configCacheHit).Inc()
+			return cached.(*servedConfig), configCacheHit, nil
+		}
+		cacheResult = configCacheMiss
+	}
+	mcsConfigCacheRequests.WithLabelValues(cacheResult).Inc()
+
+	conf, err := sh.server.GetConfig(cr)
+	if err != nil || conf == nil {
+		return nil, cacheResult, err
+	}
+	data, err := convertConfig(conf, cr.version)
+	if err != nil {
+		return nil, cacheResult, err
+	}
+	config := newServedConfig(data)
+
+	// Only cache the config if its inputs didn't change while it was generated.
+	if key != "" {
+		if keyAfter, err := keyer.getConfigKey(cr); err == nil && keyAfter == key {
+			sh.configCache.Add(key, config, configCacheTTL)
+		}
+	}
+	return config, cacheResult, nil
+}
+
+// convertConfig converts conf to the requested spec version and marshals it.
+func convertConfig(conf *runtime.RawExtension, reqConfigVer *semver.Version) ([]byte, error) {
 	// we know we're at 3.2 in code.. serve directly, parsing is expensive...
 	// we're doing it during an HTTP request, and most notably before we write the HTTP headers
 	var serveConf *runtime.RawExtension
 	if reqConfigVer.Equal(*semver.New("3.4.0")) {
 		converted34, err := ctrlcommon.ConvertRawExtIgnitionToV3_4(conf)
 		if err != nil {
-			w.Header().Set("Content-Length", "0")
-			w.WriteHeader(http.StatusInternalServerError)
-			glog.Errorf("couldn't convert config for req: %v, error: %v", cr, err)
-			return
+			return nil, fmt.Errorf("couldn't convert config: %w", err)
 		}
 		serveConf = &converted34
 
 	} else if reqConfigVer.Equal(*semver.New("3.3.0")) {
 		converted33, err := ctrlcommon.ConvertRawExtIgnitionToV3_3(conf)
 		if err != nil {
-			w.Header().Set("Content-Length", "0")
-			w.WriteHeader(http.StatusInternalServerError)
-			glog.Errorf("couldn't convert config for req: %v, error: %v", cr, err)
-			return
+			return nil, fmt.Errorf("couldn't convert config: %w", err)
 		}
 		serveConf = &converted33
 	} else if reqConfigVer.Equal(*semver.New("3.2.0")) {
@@ -160,10 +309,7 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else if reqConfigVer.Equal(*semver.New("3.1.0")) {
 		converted31, err := ctrlcommon.ConvertRawExtIgnitionToV3_1(conf)
 		if err != nil {
-			w.Header().Set("Content-Length", "0")
-			w.WriteHeader(http.StatusInternalServerError)
-			glog.Errorf("couldn't convert config for req: %v, error: %v", cr, err)
-			return
+			return nil, fmt.Errorf("couldn't convert config: %w", err)
 		}
 
 		serveConf = &converted31
@@ -171,10 +317,7 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Can only be 2.2 here
 		converted2, err := ctrlcommon.ConvertRawExtIgnitionToV2(conf)
 		if err != nil {
-			w.Header().Set("Content-Length", "0")
-			w.WriteHeader(http.StatusInternalServerError)
-			glog.Errorf("couldn't convert config for req: %v, error: %v", cr, err)
-			return
+			return nil, fmt.Errorf("couldn't convert config: %w", err)
 		}
 
 		serveConf = &converted2
@@ -182,23 +325,9 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data, err := json.Marshal(serveConf)
 	if err != nil {
-		w.Header().Set("Content-Length", "0")
-		w.WriteHeader(http.StatusInternalServerError)
-		glog.Errorf("failed to marshal %v config: %v", cr, err)
-		return
-	}
-
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
-	w.Header().Set("Content-Type", "application/json")
-	if r.Method == http.MethodHead {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	_, err = w.Write(data)
-	if err != nil {
-		glog.Errorf("failed to write %v response: %v", cr, err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
+	return data, nil
 }
 
 type healthHandler struct{}
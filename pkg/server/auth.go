@@ -0,0 +1,298 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/machine-config-operator/internal/clients"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// IgnitionTokenSecretType is the type of the Secrets Ignition tokens are stored in.
+	IgnitionTokenSecretType corev1.SecretType = "machineconfiguration.openshift.io/ignition-token"
+	// IgnitionTokenNamespace is the namespace of the Ignition token Secrets. It holds nothing
+	// else, so that the MachineConfigServer can be granted access to the tokens only.
+	IgnitionTokenNamespace = "openshift-machine-config-operator-ignition-tokens"
+
+	// UserDataSecretNamespace is the namespace of the pointer config Secrets of the pools.
+	UserDataSecretNamespace = "openshift-machine-api"
+	// userDataSecretSuffix suffixes the pool name in the name of its pointer config Secret.
+	userDataSecretSuffix = "-user-data-managed"
+	// userDataSecretKey is the key of the pointer config in a user-data Secret.
+	userDataSecretKey = "userData"
+
+	// ignitionTokenSecretPrefix prefixes the token ID in the name of an Ignition token Secret.
+	ignitionTokenSecretPrefix = "ignition-token-"
+	// ignitionTokenIDKey is the Secret key of the token ID.
+	ignitionTokenIDKey = "token-id"
+	// ignitionTokenSecretKey is the Secret key of the token secret.
+	ignitionTokenSecretKey = "token-secret"
+	// ignitionTokenExpirationKey is the Secret key of the RFC3339 time the token expires at.
+	ignitionTokenExpirationKey = "expiration"
+	// ignitionTokenPoolKey is the Secret key of the pool the token is restricted to, if any.
+	ignitionTokenPoolKey = "pool"
+
+	// tokenQueryParam is the query parameter carrying a token, for Ignition versions
+	// that can't send HTTP headers.
+	tokenQueryParam = "token"
+
+	ignitionTokenIDChars     = 6
+	ignitionTokenSecretChars = 16
+	ignitionTokenAlphabet    = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// ignitionTokenRegexp matches tokens of the form <token-id>.<token-secret>.
+var ignitionTokenRegexp = regexp.MustCompile(`^([a-z0-9]{6})\.([a-z0-9]{16})$`)
+
+// errMissingToken is returned by Authenticator.Authenticate for requests without a token.
+var errMissingToken = errors.New("no token in request")
+
+// Authenticator authenticates the requests for configs.
+type Authenticator interface {
+	// Authenticate returns the ID of the token r is authorized to fetch the config of pool with,
+	// or errMissingToken if r carries no token.
+	Authenticate(r *http.Request, pool string) (string, error)
+	// Consume invalidates the token, once it was used to serve a config.
+	Consume(tokenID string) error
+}
+
+// secretTokenAuthenticator authenticates requests with single-use tokens stored in
+// Secrets of IgnitionTokenSecretType in IgnitionTokenNamespace.
+type secretTokenAuthenticator struct {
+	secretLister corelisterv1.SecretLister
+	kubeClient   kubernetes.Interface
+	now          func() time.Time
+}
+
+// NewSecretTokenAuthenticator returns an Authenticator checking the Ignition token Secrets
+// of the cluster.
+func NewSecretTokenAuthenticator(kubeConfig string) (Authenticator, error) {
+	clientsBuilder, err := clients.NewBuilder(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes rest client: %w", err)
+	}
+	kubeClient := clientsBuilder.KubeClientOrDie("machine-config-server-token-authenticator")
+	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, resyncPeriod()(),
+		kubeinformers.WithNamespace(IgnitionTokenNamespace),
+		kubeinformers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("type", string(IgnitionTokenSecretType)).String()
+		}))
+	secretInformer := informerFactory.Core().V1().Secrets()
+	secretLister := secretInformer.Lister()
+
+	var informerStopCh chan struct{}
+	go informerFactory.Start(informerStopCh)
+
+	if !cache.WaitForCacheSync(informerStopCh, secretInformer.Informer().HasSynced) {
+		return nil, errors.New("failed to wait for cache sync")
+	}
+	return newSecretTokenAuthenticator(secretLister, kubeClient), nil
+}
+
+func newSecretTokenAuthenticator(secretLister corelisterv1.SecretLister, kubeClient kubernetes.Interface) *secretTokenAuthenticator {
+	return &secretTokenAuthenticator{
+		secretLister: secretLister,
+		kubeClient:   kubeClient,
+		now:          time.Now,
+	}
+}
+
+// getRequestToken returns the token r carries as a bearer token, or in its query.
+func getRequestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token := strings.TrimPrefix(auth, "Bearer "); token != auth {
+			return strings.TrimSpace(token)
+		}
+	}
+	return r.URL.Query().Get(tokenQueryParam)
+}
+
+func (a *secretTokenAuthenticator) Authenticate(r *http.Request, pool string) (string, error) {
+	token := getRequestToken(r)
+	if token == "" {
+		return "", errMissingToken
+	}
+	match := ignitionTokenRegexp.FindStringSubmatch(token)
+	if match == nil {
+		return "", errors.New("malformed token")
+	}
+	tokenID, tokenSecret := match[1], match[2]
+
+	secret, err := a.getTokenSecret(tokenID)
+	if err != nil {
+		return tokenID, err
+	}
+	if subtle.ConstantTimeCompare(secret.Data[ignitionTokenSecretKey], []byte(tokenSecret)) != 1 {
+		return tokenID, fmt.Errorf("invalid secret for token %s", tokenID)
+	}
+	if expiration := string(secret.Data[ignitionTokenExpirationKey]); expiration != "" {
+		expires, err := time.Parse(time.RFC3339, expiration)
+		if err != nil {
+			return tokenID, fmt.Errorf("invalid expiration of token %s: %w", tokenID, err)
+		}
+		if !a.now().Before(expires) {
+			return tokenID, fmt.Errorf("token %s expired at %s", tokenID, expiration)
+		}
+	}
+	if tokenPool := string(secret.Data[ignitionTokenPoolKey]); tokenPool != "" && tokenPool != pool {
+		return tokenID, fmt.Errorf("token %s is not valid for pool %s", tokenID, pool)
+	}
+	return tokenID, nil
+}
+
+// Consume deletes the Secret of the token. Deleting it only succeeds for one of concurrent
+// requests with the same token, which makes tokens single-use.
+func (a *secretTokenAuthenticator) Consume(tokenID string) error {
+	secret, err := a.getTokenSecret(tokenID)
+	if err != nil {
+		return err
+	}
+	uid := secret.UID
+	err = a.kubeClient.CoreV1().Secrets(IgnitionTokenNamespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume token %s: %w", tokenID, err)
+	}
+	return nil
+}
+
+func (a *secretTokenAuthenticator) getTokenSecret(tokenID string) (*corev1.Secret, error) {
+	secret, err := a.secretLister.Secrets(IgnitionTokenNamespace).Get(ignitionTokenSecretPrefix + tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown token %s: %w", tokenID, err)
+	}
+	if secret.Type != IgnitionTokenSecretType || string(secret.Data[ignitionTokenIDKey]) != tokenID {
+		return nil, fmt.Errorf("unknown token %s", tokenID)
+	}
+	return secret, nil
+}
+
+// NewIgnitionTokenSecret generates a token for fetching the config of pool, or of any pool if
+// it is empty, which expires after ttl. It returns the token and the Secret to create for it in
+// IgnitionTokenNamespace.
+func NewIgnitionTokenSecret(pool string, ttl time.Duration, now time.Time) (string, *corev1.Secret, error) {
+	tokenID, err := randomTokenString(ignitionTokenIDChars)
+	if err != nil {
+		return "", nil, err
+	}
+	tokenSecret, err := randomTokenString(ignitionTokenSecretChars)
+	if err != nil {
+		return "", nil, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ignitionTokenSecretPrefix + tokenID,
+			Namespace: IgnitionTokenNamespace,
+		},
+		Type: IgnitionTokenSecretType,
+		StringData: map[string]string{
+			ignitionTokenIDKey:         tokenID,
+			ignitionTokenSecretKey:     tokenSecret,
+			ignitionTokenExpirationKey: now.Add(ttl).UTC().Format(time.RFC3339),
+		},
+	}
+	if pool != "" {
+		secret.StringData[ignitionTokenPoolKey] = pool
+	}
+	return tokenID + "." + tokenSecret, secret, nil
+}
+
+// NewIgnitionTokenPointerConfig creates a token for fetching the config of pool, which expires
+// after ttl, and returns the pool's pointer config authenticating with it. The pointer config is
+// the one the operator manages in the pool's user-data Secret.
+func NewIgnitionTokenPointerConfig(ctx context.Context, kubeClient kubernetes.Interface, pool string, ttl time.Duration) ([]byte, string, error) {
+	userData, err := kubeClient.CoreV1().Secrets(UserDataSecretNamespace).Get(ctx, pool+userDataSecretSuffix, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get the pointer config of pool %s: %w", pool, err)
+	}
+	var pointerConfig ign3types.Config
+	if err := json.Unmarshal(userData.Data[userDataSecretKey], &pointerConfig); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the pointer config of pool %s: %w", pool, err)
+	}
+
+	token, secret, err := NewIgnitionTokenSecret(pool, ttl, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := kubeClient.CoreV1().Secrets(IgnitionTokenNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return nil, "", fmt.Errorf("failed to create Ignition token: %w", err)
+	}
+	ctrlcommon.SetPointerConfigToken(&pointerConfig, token)
+	data, err := json.Marshal(pointerConfig)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, secret.StringData[ignitionTokenIDKey], nil
+}
+
+// GetTokenlessUserDataPools returns the sorted pools whose user-data Secret, among secrets, holds a
+// pointer config fetching the config without an Ignition token. Machines created from it can't
+// fetch their config while the MachineConfigServer requires Ignition tokens.
+func GetTokenlessUserDataPools(secrets []*corev1.Secret) []string {
+	var pools []string
+	for _, secret := range secrets {
+		if !strings.HasSuffix(secret.Name, userDataSecretSuffix) {
+			continue
+		}
+		var pointerConfig ign3types.Config
+		if err := json.Unmarshal(secret.Data[userDataSecretKey], &pointerConfig); err == nil && hasPointerConfigToken(&pointerConfig) {
+			continue
+		}
+		pools = append(pools, strings.TrimSuffix(secret.Name, userDataSecretSuffix))
+	}
+	sort.Strings(pools)
+	return pools
+}
+
+// hasPointerConfigToken returns true if every config conf merges is fetched with an Authorization header.
+func hasPointerConfigToken(conf *ign3types.Config) bool {
+	if len(conf.Ignition.Config.Merge) == 0 {
+		return false
+	}
+	for _, resource := range conf.Ignition.Config.Merge {
+		found := false
+		for _, header := range resource.HTTPHeaders {
+			if header.Name == "Authorization" && header.Value != nil && *header.Value != "" {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func randomTokenString(n int) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(ignitionTokenAlphabet)))
+	for i := range b {
+		c, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate token: %w", err)
+		}
+		b[i] = ignitionTokenAlphabet[c.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+var authTestNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestTokenAuthenticator returns an authenticator knowing the tokens of secrets.
+func newTestTokenAuthenticator(t *testing.T, secrets ...*corev1.Secret) *secretTokenAuthenticator {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	kubeClient := k8sfake.NewSimpleClientset()
+	for _, secret := range secrets {
+		// The API server turns StringData into Data.
+		secret = secret.DeepCopy()
+		secret.Data = map[string][]byte{}
+		for k, v := range secret.StringData {
+			secret.Data[k] = []byte(v)
+		}
+		secret.StringData = nil
+		require.NoError(t, indexer.Add(secret))
+		_, err := kubeClient.CoreV1().Secrets(secret.Namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	auth := newSecretTokenAuthenticator(corelisterv1.NewSecretLister(indexer), kubeClient)
+	auth.now = func() time.Time { return authTestNow }
+	return auth
+}
+
+func newTokenRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestNewIgnitionTokenSecret(t *testing.T) {
+	token, secret, err := NewIgnitionTokenSecret("worker", time.Hour, authTestNow)
+	require.NoError(t, err)
+	match := ignitionTokenRegexp.FindStringSubmatch(token)
+	require.NotNil(t, match)
+	assert.Equal(t, ignitionTokenSecretPrefix+match[1], secret.Name)
+	assert.Equal(t, IgnitionTokenNamespace, secret.Namespace)
+	assert.Equal(t, IgnitionTokenSecretType, secret.Type)
+	assert.Equal(t, map[string]string{
+		ignitionTokenIDKey:         match[1],
+		ignitionTokenSecretKey:     match[2],
+		ignitionTokenExpirationKey: "2021-03-01T13:00:00Z",
+		ignitionTokenPoolKey:       "worker",
+	}, secret.StringData)
+
+	other, _, err := NewIgnitionTokenSecret("", time.Hour, authTestNow)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestSecretTokenAuthenticator(t *testing.T) {
+	workerToken, workerSecret, err := NewIgnitionTokenSecret("worker", time.Hour, authTestNow)
+	require.NoError(t, err)
+	anyToken, anySecret, err := NewIgnitionTokenSecret("", time.Hour, authTestNow)
+	require.NoError(t, err)
+	expiredToken, expiredSecret, err := NewIgnitionTokenSecret("", -time.Minute, authTestNow)
+	require.NoError(t, err)
+	auth := newTestTokenAuthenticator(t, workerSecret, anySecret, expiredSecret)
+
+	tokenID, err := auth.Authenticate(newTokenRequest(workerToken), "worker")
+	require.NoError(t, err)
+	assert.Equal(t, workerSecret.StringData[ignitionTokenIDKey], tokenID)
+
+	queryReq := httptest.NewRequest(http.MethodGet, "http://testrequest/config/infra?token="+anyToken, nil)
+	_, err = auth.Authenticate(queryReq, "infra")
+	assert.NoError(t, err)
+
+	_, err = auth.Authenticate(newTokenRequest(""), "worker")
+	assert.ErrorIs(t, err, errMissingToken)
+
+	for name, token := range map[string]string{
+		"malformed":    "not-a-token",
+		"unknown":      "zzzzzz.0123456789abcdef",
+		"wrong secret": workerToken[:7] + "0123456789abcdef",
+		"expired":      expiredToken,
+	} {
+		_, err = auth.Authenticate(newTokenRequest(token), "worker")
+		assert.Error(t, err, name)
+		assert.NotErrorIs(t, err, errMissingToken, name)
+	}
+	_, err = auth.Authenticate(newTokenRequest(workerToken), "master")
+	assert.EqualError(t, err, "token "+tokenID+" is not valid for pool master")
+
+	// Tokens can only be consumed once.
+	require.NoError(t, auth.Consume(tokenID))
+	assert.Error(t, auth.Consume(tokenID))
+}
+
+func TestAPIHandlerAuthentication(t *testing.T) {
+	token, secret, err := NewIgnitionTokenSecret("worker", time.Hour, authTestNow)
+	require.NoError(t, err)
+	auth := newTestTokenAuthenticator(t, secret)
+	handler := NewAuthenticatedServerAPIHandler(&mockServer{GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+		return &runtime.RawExtension{Raw: []byte("{}")}, nil
+	}}, auth)
+
+	get := func(w http.ResponseWriter, method, token string) {
+		req := newTokenRequest(token)
+		req.Method = method
+		req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+		handler.ServeHTTP(w, req)
+	}
+	code := func(method, token string) int {
+		w := httptest.NewRecorder()
+		get(w, method, token)
+		return w.Code
+	}
+	consumed := func() bool {
+		_, err := auth.kubeClient.CoreV1().Secrets(IgnitionTokenNamespace).Get(context.TODO(), secret.Name, metav1.GetOptions{})
+		return apierrors.IsNotFound(err)
+	}
+	assert.Equal(t, http.StatusUnauthorized, code(http.MethodGet, ""))
+	assert.Equal(t, http.StatusForbidden, code(http.MethodGet, "zzzzzz.0123456789abcdef"))
+	assert.Equal(t, http.StatusOK, code(http.MethodHead, token))
+	assert.False(t, consumed())
+
+	// A failed transfer doesn't use up the token.
+	get(failingResponseWriter{httptest.NewRecorder()}, http.MethodGet, token)
+	assert.False(t, consumed())
+
+	assert.Equal(t, http.StatusOK, code(http.MethodGet, token))
+	assert.True(t, consumed())
+}
+
+// failingResponseWriter fails to write response bodies, like a dropped connection.
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestNewIgnitionTokenPointerConfig(t *testing.T) {
+	pointerConfig, err := ctrlcommon.PointerConfig("10.0.0.1:22623", []byte("root CA"))
+	require.NoError(t, err)
+	pointerConfigData, err := json.Marshal(pointerConfig)
+	require.NoError(t, err)
+	kubeClient := k8sfake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-user-data-managed", Namespace: UserDataSecretNamespace},
+		Data:       map[string][]byte{userDataSecretKey: pointerConfigData},
+	})
+
+	_, _, err = NewIgnitionTokenPointerConfig(context.TODO(), kubeClient, "infra", time.Hour)
+	assert.Error(t, err)
+
+	data, tokenID, err := NewIgnitionTokenPointerConfig(context.TODO(), kubeClient, "worker", time.Hour)
+	require.NoError(t, err)
+	secret, err := kubeClient.CoreV1().Secrets(IgnitionTokenNamespace).Get(context.TODO(), ignitionTokenSecretPrefix+tokenID, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "worker", secret.StringData[ignitionTokenPoolKey])
+
+	var tokenPointerConfig ign3types.Config
+	require.NoError(t, json.Unmarshal(data, &tokenPointerConfig))
+	headers := tokenPointerConfig.Ignition.Config.Merge[0].HTTPHeaders
+	require.Len(t, headers, 1)
+	assert.Equal(t, "Bearer "+tokenID+"."+secret.StringData[ignitionTokenSecretKey], *headers[0].Value)
+}
@@ -19,27 +19,41 @@ import (
 // Server interface.
 var _ = Server(&bootstrapServer{})
 
+// BootstrapAllPools allows the bootstrap server to serve every pool rendered
+// into its machine-pools directory.
+const BootstrapAllPools = "*"
+
 type bootstrapServer struct {
 
 	// serverBaseDir is the root, relative to which
 	// the MachineConfigPool configs will be picked
 	serverBaseDir string
 
+	// allowedPools are the pools served, or BootstrapAllPools.
+	allowedPools []string
+
 	kubeconfigFunc kubeconfigFunc
 }
 
 // NewBootstrapServer initializes a new Bootstrap server that implements
-// the Server interface.
-func NewBootstrapServer(dir, kubeconfig string) (Server, error) {
+// the Server interface. It only serves the allowedPools, which may contain
+// BootstrapAllPools to serve every pool.
+func NewBootstrapServer(dir, kubeconfig string, allowedPools []string) (Server, error) {
 	if _, err := os.Stat(kubeconfig); err != nil {
 		return nil, fmt.Errorf("kubeconfig not found at location: %s", kubeconfig)
 	}
 	return &bootstrapServer{
 		serverBaseDir:  dir,
+		allowedPools:   allowedPools,
 		kubeconfigFunc: func() ([]byte, []byte, error) { return kubeconfigFromFile(kubeconfig) },
 	}, nil
 }
 
+// allowsPool returns whether the bootstrap server may serve the config of pool.
+func (bsc *bootstrapServer) allowsPool(pool string) bool {
+	return ctrlcommon.InSlice(BootstrapAllPools, bsc.allowedPools) || ctrlcommon.InSlice(pool, bsc.allowedPools)
+}
+
 // GetConfig fetches the machine config(type - Ignition) from the bootstrap server,
 // based on the pool request.
 // If a config cannot be found or parsed, it returns a nil conf, along with an error.
@@ -57,7 +71,7 @@ func NewBootstrapServer(dir, kubeconfig string) (Server, error) {
 // 4. Append the machine annotations file.
 // 5. Append the KubeConfig file.
 func (bsc *bootstrapServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error) {
-	if cr.machineConfigPool != "master" {
+	if !bsc.allowsPool(cr.machineConfigPool) {
 		return nil, fmt.Errorf("refusing to serve bootstrap configuration to pool %q", cr.machineConfigPool)
 	}
 	// 1. Read the Machine Config Pool object.
@@ -77,6 +91,9 @@ func (bsc *bootstrapServer) GetConfig(cr poolRequest) (*runtime.RawExtension, er
 	if err != nil {
 		return nil, fmt.Errorf("server: could not unmarshal file %s, err: %w", fileName, err)
 	}
+	if mp.Name != cr.machineConfigPool {
+		return nil, fmt.Errorf("server: file %s holds pool %q, not %q", fileName, mp.Name, cr.machineConfigPool)
+	}
 
 	currConf := mp.Status.Configuration.Name
 
@@ -102,11 +119,8 @@ func (bsc *bootstrapServer) GetConfig(cr poolRequest) (*runtime.RawExtension, er
 		return nil, fmt.Errorf("parsing Ignition config failed with error: %w", err)
 	}
 
-	appenders := getAppenders(currConf, nil, bsc.kubeconfigFunc)
-	for _, a := range appenders {
-		if err := a(&ignConf, mc); err != nil {
-			return nil, err
-		}
+	if err := applyAppenders(&ignConf, mc, currConf, cr.version, bsc.kubeconfigFunc); err != nil {
+		return nil, err
 	}
 
 	rawConf, err := json.Marshal(ignConf)
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// configCacheSize is how many served configs are cached, there is one per pool and
+	// requested spec version.
+	configCacheSize = 16
+	// configCacheTTL bounds how long a config is cached, for the inputs of the config that
+	// aren't part of its key such as the bootstrap token.
+	configCacheTTL = 10 * time.Minute
+)
+
+// configKeyer is implemented by servers whose configs can be cached.
+type configKeyer interface {
+	// getConfigKey returns the key identifying the content of the config served for
+	// the request, or an empty key if it must not be cached.
+	getConfigKey(poolRequest) (string, error)
+}
+
+// servedConfig is a config converted to the requested spec version and marshaled,
+// as served.
+type servedConfig struct {
+	data []byte
+	etag string
+
+	gzipOnce sync.Once
+	gzipData []byte
+	gzipETag string
+	gzipErr  error
+}
+
+func newServedConfig(data []byte) *servedConfig {
+	sum := sha256.Sum256(data)
+	return &servedConfig{
+		data: data,
+		etag: strconv.Quote(hex.EncodeToString(sum[:])),
+	}
+}
+
+// gzipped returns the config compressed with gzip and its ETag, which differs from the
+// one of the uncompressed config as strong ETags must.
+func (c *servedConfig) gzipped() ([]byte, string, error) {
+	c.gzipOnce.Do(func() {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(c.data); err != nil {
+			c.gzipErr = err
+			return
+		}
+		if err := zw.Close(); err != nil {
+			c.gzipErr = err
+			return
+		}
+		c.gzipData = buf.Bytes()
+		c.gzipETag = strings.TrimSuffix(c.etag, `"`) + `-gzip"`
+	})
+	return c.gzipData, c.gzipETag, c.gzipErr
+}
+
+// acceptsGzip returns whether an Accept-Encoding header allows gzip content coding.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(coding, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != "gzip" && name != "x-gzip" && name != "*" {
+			continue
+		}
+		accepted := true
+		for _, param := range params[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(q, "q="), 32); err == nil && v == 0 {
+					accepted = false
+				}
+			}
+		}
+		if accepted {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches returns whether an If-None-Match header matches etag, comparing weakly as
+// RFC 7232 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
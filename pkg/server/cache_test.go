@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// mockCachingServer is a mockServer whose configs are cached under key.
+type mockCachingServer struct {
+	mockServer
+	key string
+}
+
+func (ms *mockCachingServer) getConfigKey(pr poolRequest) (string, error) {
+	return ms.key, nil
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, expected := range map[string]bool{
+		"":                          false,
+		"identity":                  false,
+		"gzip":                      true,
+		"deflate, gzip;q=0.5":       true,
+		"GZIP":                      true,
+		"gzip;q=0":                  false,
+		"*":                         true,
+		"br;q=1.0, gzip;q=0, *;q=0": false,
+	} {
+		assert.Equal(t, expected, acceptsGzip(header), header)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`*`, `"abc"`))
+	assert.False(t, etagMatches(``, `"abc"`))
+	assert.False(t, etagMatches(`"abc-gzip"`, `"abc"`))
+}
+
+func TestServedConfigGzipped(t *testing.T) {
+	config := newServedConfig([]byte(`{"ignition":{"version":"3.2.0"}}`))
+	data, etag, err := config.gzipped()
+	require.NoError(t, err)
+	assert.NotEqual(t, config.etag, etag)
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, config.data, uncompressed)
+}
+
+func TestAPIHandlerConfigCache(t *testing.T) {
+	calls := 0
+	server := &mockCachingServer{
+		mockServer: mockServer{GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+			calls++
+			return &runtime.RawExtension{Raw: []byte(`{"ignition":{"version":"3.2.0"}}`)}, nil
+		}},
+		key: "rendered-worker-1",
+	}
+	handler := NewServerAPIHandler(server)
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker", nil)
+		req.Header = header
+		req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(http.Header{})
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, 1, calls)
+
+	// The config is cached and its ETag honoured.
+	w = get(http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Equal(t, 1, calls)
+
+	w = get(http.Header{"Accept-Encoding": {"gzip"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ignition":{"version":"3.2.0"}}`, string(uncompressed))
+	assert.Equal(t, 1, calls)
+
+	// A new key misses the cache.
+	server.key = "rendered-worker-2"
+	assert.Equal(t, http.StatusOK, get(http.Header{}).Code)
+	assert.Equal(t, 2, calls)
+
+	// Empty keys are never cached.
+	server.key = ""
+	assert.Equal(t, http.StatusOK, get(http.Header{}).Code)
+	assert.Equal(t, http.StatusOK, get(http.Header{}).Code)
+	assert.Equal(t, 4, calls)
+}
+
+func TestClusterServerConfigKey(t *testing.T) {
+	mp, err := getTestMachineConfigPool()
+	require.NoError(t, err)
+	controllerConfig := getTestControllerConfig()
+	controllerConfig.ResourceVersion = "1"
+	mc := &mcfgv1.MachineConfig{}
+	mc.Name = mp.Status.Configuration.Name
+	mc.ResourceVersion = "5"
+	csc := &clusterServer{
+		machineConfigPoolLister: &mockMCPLister{pools: []*mcfgv1.MachineConfigPool{mp}},
+		machineConfigLister:     &mockMCLister{configs: []*mcfgv1.MachineConfig{mc}},
+		controllerConfigLister:  &mockCCLister{configs: []*mcfgv1.ControllerConfig{controllerConfig}},
+	}
+
+	cr := poolRequest{machineConfigPool: testPool}
+	key, err := csc.getConfigKey(cr)
+	require.NoError(t, err)
+	assert.Equal(t, mc.Name+"/5//1", key)
+
+	controllerConfig.ResourceVersion = "2"
+	newKey, err := csc.getConfigKey(cr)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, newKey)
+}
@@ -7,10 +7,13 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	yaml "github.com/ghodss/yaml"
+	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	mcfginformers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +38,7 @@ type clusterServer struct {
 	machineConfigPoolLister v1.MachineConfigPoolLister
 	machineConfigLister     v1.MachineConfigLister
 	controllerConfigLister  v1.ControllerConfigLister
+	nodeConfigOverlayLister v1.NodeConfigOverlayLister
 
 	kubeconfigFunc kubeconfigFunc
 }
@@ -66,20 +70,22 @@ func NewClusterServer(kubeConfig, apiserverURL string) (Server, error) {
 	machineConfigClient := clientsBuilder.MachineConfigClientOrDie("machine-config-shared-informer")
 	sharedInformerFactory := mcfginformers.NewSharedInformerFactory(machineConfigClient, resyncPeriod()())
 
-	mcpInformer, mcInformer, ccInformer :=
+	mcpInformer, mcInformer, ccInformer, ncoInformer :=
 		sharedInformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 		sharedInformerFactory.Machineconfiguration().V1().MachineConfigs(),
-		sharedInformerFactory.Machineconfiguration().V1().ControllerConfigs()
-	mcpLister, mcLister, ccLister := mcpInformer.Lister(), mcInformer.Lister(), ccInformer.Lister()
-	mcpListerHasSynced, mcListerHasSynced, ccListerHasSynced :=
+		sharedInformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+		sharedInformerFactory.Machineconfiguration().V1().NodeConfigOverlays()
+	mcpLister, mcLister, ccLister, ncoLister := mcpInformer.Lister(), mcInformer.Lister(), ccInformer.Lister(), ncoInformer.Lister()
+	mcpListerHasSynced, mcListerHasSynced, ccListerHasSynced, ncoListerHasSynced :=
 		mcpInformer.Informer().HasSynced,
 		mcInformer.Informer().HasSynced,
-		ccInformer.Informer().HasSynced
+		ccInformer.Informer().HasSynced,
+		ncoInformer.Informer().HasSynced
 
 	var informerStopCh chan struct{}
 	go sharedInformerFactory.Start(informerStopCh)
 
-	if !cache.WaitForCacheSync(informerStopCh, mcpListerHasSynced, mcListerHasSynced, ccListerHasSynced) {
+	if !cache.WaitForCacheSync(informerStopCh, mcpListerHasSynced, mcListerHasSynced, ccListerHasSynced, ncoListerHasSynced) {
 		return nil, errors.New("failed to wait for cache sync")
 	}
 
@@ -87,6 +93,7 @@ func NewClusterServer(kubeConfig, apiserverURL string) (Server, error) {
 		machineConfigPoolLister: mcpLister,
 		machineConfigLister:     mcLister,
 		controllerConfigLister:  ccLister,
+		nodeConfigOverlayLister: ncoLister,
 		kubeconfigFunc:          func() ([]byte, []byte, error) { return kubeconfigFromSecret(bootstrapTokenDir, apiserverURL) },
 	}, nil
 }
@@ -99,16 +106,7 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 		return nil, fmt.Errorf("could not fetch pool. err: %w", err)
 	}
 
-	// For new nodes, we roll out the latest if at least one node has successfully updated.
-	// This avoids deadlocks in situations where the old configuration broke somehow
-	// (e.g. pull secret expired)
-	// and also avoids provisioning a new node, only to update it not long thereafter.
-	var currConf string
-	if mp.Status.UpdatedMachineCount > 0 {
-		currConf = mp.Spec.Configuration.Name
-	} else {
-		currConf = mp.Status.Configuration.Name
-	}
+	currConf := getPoolServedConfigName(mp)
 
 	mc, err := cs.machineConfigLister.Get(currConf)
 	if err != nil {
@@ -136,11 +134,20 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 		}
 	}
 
-	appenders := getAppenders(currConf, cr.version, cs.kubeconfigFunc)
-	for _, a := range appenders {
-		if err := a(&ignConf, mc); err != nil {
-			return nil, err
-		}
+	// Per-host fragments go on top of the pool's config, the appenders only add MCO-owned files.
+	overlays, err := getNodeConfigOverlays(cs.nodeConfigOverlayLister, cr.host)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyNodeConfigOverlays(&ignConf, overlays); err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		glog.Infof("Applied node config overlay %s to config %s for host %q", overlay.Name, currConf, cr.host)
+	}
+
+	if err := applyAppenders(&ignConf, mc, currConf, cr.version, cs.kubeconfigFunc); err != nil {
+		return nil, err
 	}
 
 	rawConf, err := json.Marshal(ignConf)
@@ -150,6 +157,49 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 	return &runtime.RawExtension{Raw: rawConf}, nil
 }
 
+// getConfigKey returns a key identifying the rendered config, spec version and
+// controllerconfig the config served for cr is generated from. Configs with per-host
+// overlays aren't cached, each host only requests them once.
+func (cs *clusterServer) getConfigKey(cr poolRequest) (string, error) {
+	mp, err := cs.machineConfigPoolLister.Get(cr.machineConfigPool)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch pool. err: %w", err)
+	}
+	currConf := getPoolServedConfigName(mp)
+	mc, err := cs.machineConfigLister.Get(currConf)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch config %s, err: %w", currConf, err)
+	}
+	cc, err := cs.controllerConfigLister.Get(ctrlcommon.ControllerConfigName)
+	if err != nil {
+		return "", fmt.Errorf("could not get controllerconfig: %w", err)
+	}
+	overlays, err := getNodeConfigOverlays(cs.nodeConfigOverlayLister, cr.host)
+	if err != nil {
+		return "", err
+	}
+	if len(overlays) > 0 {
+		return "", nil
+	}
+	var version string
+	if cr.version != nil {
+		version = cr.version.String()
+	}
+	return strings.Join([]string{mc.Name, mc.ResourceVersion, version, cc.ResourceVersion}, "/"), nil
+}
+
+// getPoolServedConfigName returns the name of the rendered config served for pool.
+func getPoolServedConfigName(mp *mcfgv1.MachineConfigPool) string {
+	// For new nodes, we roll out the latest if at least one node has successfully updated.
+	// This avoids deadlocks in situations where the old configuration broke somehow
+	// (e.g. pull secret expired)
+	// and also avoids provisioning a new node, only to update it not long thereafter.
+	if mp.Status.UpdatedMachineCount > 0 {
+		return mp.Spec.Configuration.Name
+	}
+	return mp.Status.Configuration.Name
+}
+
 // kubeconfigFromSecret creates a kubeconfig with the certificate
 // and token files in secretDir
 func kubeconfigFromSecret(secretDir, apiserverURL string) ([]byte, []byte, error) {
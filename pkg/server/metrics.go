@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsBindAddress is the address of the MCS metrics listener. It can't use
+	// ctrlcommon.DefaultBindAddress, the MCD listens on it on the same hosts.
+	MetricsBindAddress = "127.0.0.1:8798"
+
+	configCacheHit      = "hit"
+	configCacheMiss     = "miss"
+	configCacheUncached = "uncached"
+)
+
+// MCS Metrics
+var (
+	// mcsRequests counts the config requests by pool, requested Ignition spec version and status code
+	mcsRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcs_requests_total",
+			Help: "Total number of config requests, by pool, Ignition spec version and HTTP status code.",
+		}, []string{"pool", "spec_version", "code"})
+
+	// mcsRequestDuration observes how long config requests took
+	mcsRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcs_request_duration_seconds",
+			Help:    "Time taken to handle a config request, by pool, Ignition spec version and HTTP status code.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"pool", "spec_version", "code"})
+
+	// mcsRequestsRateLimited counts the config requests rejected by the per source IP rate limit
+	mcsRequestsRateLimited = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mcs_requests_rate_limited_total",
+			Help: "Total number of config requests rejected by the per source IP rate limit.",
+		})
+
+	// mcsConfigCacheRequests counts the config requests by whether the served config cache had them
+	mcsConfigCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcs_config_cache_requests_total",
+			Help: "Total number of served configs, by result of the config cache lookup (hit, miss or uncached).",
+		}, []string{"result"})
+
+	// mcsConfigServeDuration observes how long serving a config took
+	mcsConfigServeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcs_config_serve_duration_seconds",
+			Help:    "Time taken to serve a config, by result of the config cache lookup.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"cache"})
+)
+
+// RegisterMCSMetrics registers the machine-config-server metrics.
+func RegisterMCSMetrics() error {
+	err := ctrlcommon.RegisterMetrics([]prometheus.Collector{
+		mcsRequests,
+		mcsRequestDuration,
+		mcsRequestsRateLimited,
+		mcsConfigCacheRequests,
+		mcsConfigServeDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("could not register machine-config-server metrics: %w", err)
+	}
+	return nil
+}
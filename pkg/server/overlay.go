@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	ign3 "github.com/coreos/ignition/v2/config/v3_2"
+	igntypes "github.com/coreos/ignition/v2/config/v3_2/types"
+	"k8s.io/apimachinery/pkg/labels"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	v1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// nodeQueryParam is the query parameter identifying the requesting host by name.
+	nodeQueryParam = "node"
+	// macQueryParam is the query parameter identifying the requesting host by MAC address.
+	// It may be repeated for hosts with several interfaces.
+	macQueryParam = "mac"
+)
+
+// hostIdentity identifies the host requesting a config, to select its NodeConfigOverlays.
+type hostIdentity struct {
+	name string
+	// macAddresses are in the form of NodeConfigOverlayMACAddressLabelKey values.
+	macAddresses []string
+}
+
+// getHostIdentity returns the identity of the host given in the query of a config request.
+func getHostIdentity(query map[string][]string) (hostIdentity, error) {
+	var host hostIdentity
+	if names := query[nodeQueryParam]; len(names) > 0 {
+		host.name = names[0]
+	}
+	for _, mac := range query[macQueryParam] {
+		hw, err := net.ParseMAC(mac)
+		if err != nil {
+			return hostIdentity{}, fmt.Errorf("invalid %s query parameter: %w", macQueryParam, err)
+		}
+		host.macAddresses = append(host.macAddresses, strings.ReplaceAll(hw.String(), ":", "-"))
+	}
+	return host, nil
+}
+
+func (host hostIdentity) String() string {
+	var ids []string
+	if host.name != "" {
+		ids = append(ids, host.name)
+	}
+	ids = append(ids, host.macAddresses...)
+	return strings.Join(ids, ",")
+}
+
+// selects returns whether overlay applies to the host.
+func (host hostIdentity) selects(overlay *mcfgv1.NodeConfigOverlay) bool {
+	if host.name != "" && (overlay.Name == host.name || overlay.Labels[mcfgv1.NodeConfigOverlayNodeLabelKey] == host.name) {
+		return true
+	}
+	mac, ok := overlay.Labels[mcfgv1.NodeConfigOverlayMACAddressLabelKey]
+	return ok && ctrlcommon.InSlice(mac, host.macAddresses)
+}
+
+// getNodeConfigOverlays returns the NodeConfigOverlays applying to host, sorted by name.
+func getNodeConfigOverlays(lister v1.NodeConfigOverlayLister, host hostIdentity) ([]*mcfgv1.NodeConfigOverlay, error) {
+	if lister == nil || (host.name == "" && len(host.macAddresses) == 0) {
+		return nil, nil
+	}
+	overlays, err := lister.NodeConfigOverlays(ctrlcommon.MCONamespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("could not list node config overlays: %w", err)
+	}
+	var selected []*mcfgv1.NodeConfigOverlay
+	for _, overlay := range overlays {
+		if host.selects(overlay) {
+			selected = append(selected, overlay)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected, nil
+}
+
+// applyNodeConfigOverlays merges overlays, in order, on top of conf. Overlays may not replace
+// the files and units of conf, which the MachineConfigDaemon would otherwise report as drifted
+// and overwrite on the next update.
+func applyNodeConfigOverlays(conf *igntypes.Config, overlays []*mcfgv1.NodeConfigOverlay) error {
+	files := make(map[string]bool)
+	for _, f := range conf.Storage.Files {
+		files[f.Path] = true
+	}
+	units := make(map[string]bool)
+	for _, u := range conf.Systemd.Units {
+		units[u.Name] = true
+	}
+
+	for _, overlay := range overlays {
+		overlayConf, err := ctrlcommon.ParseAndConvertConfig(overlay.Spec.Config.Raw)
+		if err != nil {
+			return fmt.Errorf("parsing node config overlay %s failed: %w", overlay.Name, err)
+		}
+		for _, f := range overlayConf.Storage.Files {
+			if files[f.Path] {
+				return fmt.Errorf("node config overlay %s replaces file %s of the rendered config", overlay.Name, f.Path)
+			}
+		}
+		for _, u := range overlayConf.Systemd.Units {
+			if units[u.Name] {
+				return fmt.Errorf("node config overlay %s replaces unit %s of the rendered config", overlay.Name, u.Name)
+			}
+		}
+		*conf = ign3.Merge(*conf, overlayConf)
+	}
+	return nil
+}
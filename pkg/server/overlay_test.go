@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/clarketm/json"
+	igntypes "github.com/coreos/ignition/v2/config/v3_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	v1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+func newNodeConfigOverlay(t *testing.T, namespace, name string, labels map[string]string, files ...igntypes.File) *mcfgv1.NodeConfigOverlay {
+	conf := ctrlcommon.NewIgnConfig()
+	conf.Storage.Files = files
+	raw, err := json.Marshal(conf)
+	require.NoError(t, err)
+	return &mcfgv1.NodeConfigOverlay{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       mcfgv1.NodeConfigOverlaySpec{Config: runtime.RawExtension{Raw: raw}},
+	}
+}
+
+func TestGetHostIdentity(t *testing.T) {
+	host, err := getHostIdentity(url.Values{"node": {"worker-0"}, "mac": {"52:54:00:A1:B2:C3", "52-54-00-a1-b2-c4"}})
+	require.NoError(t, err)
+	assert.Equal(t, hostIdentity{name: "worker-0", macAddresses: []string{"52-54-00-a1-b2-c3", "52-54-00-a1-b2-c4"}}, host)
+	assert.Equal(t, "worker-0,52-54-00-a1-b2-c3,52-54-00-a1-b2-c4", host.String())
+
+	_, err = getHostIdentity(url.Values{"mac": {"not-a-mac"}})
+	assert.Error(t, err)
+}
+
+func TestGetNodeConfigOverlays(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, overlay := range []*mcfgv1.NodeConfigOverlay{
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "worker-0", nil),
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "bond", map[string]string{mcfgv1.NodeConfigOverlayNodeLabelKey: "worker-0"}),
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "nic", map[string]string{mcfgv1.NodeConfigOverlayMACAddressLabelKey: "52-54-00-a1-b2-c3"}),
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "worker-1", nil),
+		newNodeConfigOverlay(t, "default", "other-namespace", map[string]string{mcfgv1.NodeConfigOverlayNodeLabelKey: "worker-0"}),
+	} {
+		require.NoError(t, indexer.Add(overlay))
+	}
+	lister := v1.NewNodeConfigOverlayLister(indexer)
+
+	names := func(host hostIdentity) []string {
+		overlays, err := getNodeConfigOverlays(lister, host)
+		require.NoError(t, err)
+		var names []string
+		for _, overlay := range overlays {
+			names = append(names, overlay.Name)
+		}
+		return names
+	}
+	assert.Empty(t, names(hostIdentity{}))
+	assert.Equal(t, []string{"bond", "worker-0"}, names(hostIdentity{name: "worker-0"}))
+	assert.Equal(t, []string{"nic"}, names(hostIdentity{macAddresses: []string{"52-54-00-a1-b2-c3"}}))
+	assert.Equal(t, []string{"bond", "nic", "worker-0"}, names(hostIdentity{name: "worker-0", macAddresses: []string{"52-54-00-a1-b2-c3"}}))
+}
+
+func TestApplyNodeConfigOverlays(t *testing.T) {
+	conf := ctrlcommon.NewIgnConfig()
+	conf.Storage.Files = []igntypes.File{ctrlcommon.NewIgnFile("/etc/motd", "hello")}
+
+	hostname := ctrlcommon.NewIgnFile("/etc/hostname", "worker-0")
+	bond := ctrlcommon.NewIgnFile("/etc/NetworkManager/system-connections/bond0.nmconnection", "[connection]")
+	overlays := []*mcfgv1.NodeConfigOverlay{
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "bond", nil, bond),
+		newNodeConfigOverlay(t, ctrlcommon.MCONamespace, "worker-0", nil, hostname),
+	}
+	require.NoError(t, applyNodeConfigOverlays(&conf, overlays))
+	var paths []string
+	for _, f := range conf.Storage.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"/etc/motd", bond.Path, hostname.Path}, paths)
+
+	conf = ctrlcommon.NewIgnConfig()
+	conf.Storage.Files = []igntypes.File{ctrlcommon.NewIgnFile("/etc/hostname", "localhost")}
+	assert.EqualError(t, applyNodeConfigOverlays(&conf, overlays), "node config overlay worker-0 replaces file /etc/hostname of the rendered config")
+}
+
+func TestAPIHandlerHostIdentity(t *testing.T) {
+	var requested poolRequest
+	handler := NewServerAPIHandler(&mockServer{GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+		requested = pr
+		return &runtime.RawExtension{Raw: []byte("{}")}, nil
+	}})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker?node=worker-0&mac=52:54:00:a1:b2:c3", nil)
+	req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.2.0")
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "worker", requested.machineConfigPool)
+	assert.Equal(t, hostIdentity{name: "worker-0", macAddresses: []string{"52-54-00-a1-b2-c3"}}, requested.host)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker?mac=bogus", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
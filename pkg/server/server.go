@@ -61,6 +61,18 @@ func getAppenders(currMachineConfig string, version *semver.Version, f kubeconfi
 	return appenders
 }
 
+// applyAppenders applies the appenders to conf, the Ignition config of mc served for
+// currMachineConfig. The bootstrap and cluster servers share them, so that machines get
+// the same content from either.
+func applyAppenders(conf *igntypes.Config, mc *mcfgv1.MachineConfig, currMachineConfig string, version *semver.Version, f kubeconfigFunc) error {
+	for _, a := range getAppenders(currMachineConfig, version, f) {
+		if err := a(conf, mc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // appendEncapsulated empties out the ignition portion of a MachineConfig and adds
 // it to /etc/ignition-machine-config-encapsulated.json.  This is used by
 // machine-config-daemon-firstboot.service to process the bits that the main Ignition (that runs in the initramfs)
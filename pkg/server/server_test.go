@@ -18,6 +18,7 @@ import (
 	ign3types "github.com/coreos/ignition/v2/config/v3_2/types"
 	yaml "github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -181,6 +182,7 @@ func TestBootstrapServer(t *testing.T) {
 	// initialize bootstrap server and get config.
 	bs := &bootstrapServer{
 		serverBaseDir:  testDir,
+		allowedPools:   []string{"master"},
 		kubeconfigFunc: func() ([]byte, []byte, error) { return getKubeConfigContent(t) },
 	}
 	if err != nil {
@@ -214,6 +216,67 @@ func TestBootstrapServer(t *testing.T) {
 	}
 }
 
+func TestBootstrapServerAllowedPools(t *testing.T) {
+	newBootstrapServer := func(allowedPools ...string) *bootstrapServer {
+		return &bootstrapServer{
+			serverBaseDir:  testDir,
+			allowedPools:   allowedPools,
+			kubeconfigFunc: func() ([]byte, []byte, error) { return getKubeConfigContent(t) },
+		}
+	}
+
+	res, err := newBootstrapServer("master", testPool).GetConfig(poolRequest{machineConfigPool: testPool})
+	require.NoError(t, err)
+	assert.NotNil(t, res)
+
+	bs := newBootstrapServer(BootstrapAllPools)
+	for _, pool := range []string{"master", testPool} {
+		res, err := bs.GetConfig(poolRequest{machineConfigPool: pool})
+		require.NoError(t, err)
+		assert.NotNil(t, res, pool)
+	}
+	// Pools that weren't rendered aren't found.
+	res, err = bs.GetConfig(poolRequest{machineConfigPool: "infra"})
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	_, err = newBootstrapServer().GetConfig(poolRequest{machineConfigPool: "master"})
+	assert.Error(t, err)
+}
+
+// TestBootstrapAndClusterServerConfigs checks that machines get the same config from the
+// bootstrap server and from the cluster server.
+func TestBootstrapAndClusterServerConfigs(t *testing.T) {
+	mp, err := getTestMachineConfigPool()
+	require.NoError(t, err)
+	mcData, err := os.ReadFile(filepath.Join(testDir, "machine-configs", testConfig+".yaml"))
+	require.NoError(t, err)
+	mc := new(mcfgv1.MachineConfig)
+	require.NoError(t, yaml.Unmarshal(mcData, mc))
+
+	kubeconfigFunc := func() ([]byte, []byte, error) { return getKubeConfigContent(t) }
+	bs := &bootstrapServer{
+		serverBaseDir:  testDir,
+		allowedPools:   []string{BootstrapAllPools},
+		kubeconfigFunc: kubeconfigFunc,
+	}
+	cs := &clusterServer{
+		machineConfigPoolLister: &mockMCPLister{pools: []*mcfgv1.MachineConfigPool{mp}},
+		machineConfigLister:     &mockMCLister{configs: []*mcfgv1.MachineConfig{mc}},
+		controllerConfigLister:  &mockCCLister{configs: []*mcfgv1.ControllerConfig{getTestControllerConfig()}},
+		kubeconfigFunc:          kubeconfigFunc,
+	}
+
+	for _, version := range []*semver.Version{semver.New("2.2.0"), semver.New("3.2.0")} {
+		cr := poolRequest{machineConfigPool: testPool, version: version}
+		bootstrapConf, err := bs.GetConfig(cr)
+		require.NoError(t, err)
+		clusterConf, err := cs.GetConfig(cr)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(clusterConf.Raw), string(bootstrapConf.Raw), version.String())
+	}
+}
+
 type mockMCPLister struct {
 	pools []*mcfgv1.MachineConfigPool
 }